@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/services/queue"
+)
+
+const dlqUsage = `Usage: api dlq <command> [flags]
+
+Commands:
+  list     List dead-lettered jobs, newest first
+  requeue  Move dead-lettered jobs back to their queue with their attempts reset
+  purge    Delete all dead-lettered jobs
+
+Flags:
+  -queue string  Queue name (default QUEUE_NAME)
+  -limit int     Number of jobs to list (default 20)
+  -id string     Requeue only the job with this ID
+`
+
+// runDLQ implements the "dlq" subcommand
+func runDLQ(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, dlqUsage)
+		return fmt.Errorf("missing dlq command")
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("dlq "+command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, dlqUsage) }
+	queueName := flags.String("queue", cfg.Queue.Name, "queue name")
+	limit := flags.Int64("limit", 20, "number of jobs to list")
+	id := flags.String("id", "", "requeue only the job with this ID")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	taskQueue, err := queue.NewQueue(cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer taskQueue.Close()
+
+	ctx := context.Background()
+
+	switch command {
+	case "list":
+		tasks, err := taskQueue.ListDeadLetters(ctx, *queueName, 0, *limit)
+		if err != nil {
+			return err
+		}
+
+		total, err := taskQueue.CountDeadLetters(ctx, *queueName)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTYPE\tATTEMPTS\tFAILED AT\tLAST ERROR")
+		for _, task := range tasks {
+			failedAt := "-"
+			if task.FailedAt != nil {
+				failedAt = task.FailedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", task.ID, task.Type, task.Attempts, failedAt, task.LastError)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Printf("%d of %d dead-lettered jobs shown\n", len(tasks), total)
+		return nil
+	case "requeue":
+		requeued, err := taskQueue.RequeueDeadLetters(ctx, *queueName, *id)
+		if err != nil {
+			return err
+		}
+		if *id != "" && requeued == 0 {
+			return fmt.Errorf("no dead-lettered job with ID %s", *id)
+		}
+		fmt.Printf("Requeued %d jobs\n", requeued)
+		return nil
+	case "purge":
+		purged, err := taskQueue.PurgeDeadLetters(ctx, *queueName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Purged %d jobs\n", purged)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, dlqUsage)
+		return fmt.Errorf("unknown dlq command: %s", command)
+	}
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"time"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jaimesHub/golang-todo-app/internal/config"
@@ -11,11 +16,12 @@ import (
 	"github.com/jaimesHub/golang-todo-app/internal/logger"
 	"github.com/jaimesHub/golang-todo-app/internal/middleware"
 	"github.com/jaimesHub/golang-todo-app/internal/monitoring"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
 	"github.com/jaimesHub/golang-todo-app/internal/routes"
 	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
 	"github.com/jaimesHub/golang-todo-app/internal/services/queue"
 	"github.com/jaimesHub/golang-todo-app/internal/services/redis"
-	"github.com/jaimesHub/golang-todo-app/internal/services/worker"
+	"github.com/jaimesHub/golang-todo-app/internal/services/storage"
 	"github.com/joho/godotenv"
 )
 
@@ -31,6 +37,38 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Run the migrate, dlq, scheduled or users subcommand instead of the server when requested
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "dlq" {
+		if err := runDLQ(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Dead-letter command failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "scheduled" {
+		if err := runScheduled(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Scheduled jobs command failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "users" {
+		if err := runUsers(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Users command failed: %v", err)
+		}
+		return
+	}
+
+	// Refuse to sign tokens with the placeholder secret unless development
+	// mode is enabled explicitly
+	if cfg.JWT.UsesDefaultSecret() && !cfg.Server.DevMode {
+		log.Fatalf("JWT_SECRET is not set; configure it or JWT_KEY_DIR, or set DEV_MODE=true for local development")
+	}
+
 	// Initialize logger
 	appLogger, err := logger.NewLogger(cfg.Logging)
 	if err != nil {
@@ -50,10 +88,12 @@ func main() {
 	appLogger.Info("Connected to database")
 
 	// Run migrations
-	if err := database.Migrate(db); err != nil {
-		appLogger.Fatal("Failed to run migrations", map[string]interface{}{"error": err.Error()})
+	if cfg.Database.MigrateOnStart {
+		if err := database.Migrate(db); err != nil {
+			appLogger.Fatal("Failed to run migrations", map[string]interface{}{"error": err.Error()})
+		}
+		appLogger.Info("Database migrations completed")
 	}
-	appLogger.Info("Database migrations completed")
 
 	// Initialize Redis client
 	redisClient, err := redis.NewClient(cfg.Redis)
@@ -73,54 +113,64 @@ func main() {
 		appLogger.Info("Task queue initialized")
 	}
 
-	// Initialize worker
-	taskWorker, err := worker.NewWorker(cfg.Redis, "tasks")
-	if err != nil {
-		appLogger.Warn("Failed to initialize task worker", map[string]interface{}{"error": err.Error()})
-	} else {
-		defer taskWorker.Close()
-
-		// Register task handlers
-		taskWorker.RegisterHandler("email_notification", func(task *queue.Task) error {
-			appLogger.Info("Processing email notification task", map[string]interface{}{"task_id": task.ID, "data": task.Data})
-			// Simulate work
-			time.Sleep(1 * time.Second)
-			return nil
-		})
+	// Initialize repositories
+	repos := repository.NewGormRepositories(db)
 
-		taskWorker.RegisterHandler("task_reminder", func(task *queue.Task) error {
-			appLogger.Info("Processing task reminder", map[string]interface{}{"task_id": task.ID, "data": task.Data})
-			// Simulate work
-			time.Sleep(1 * time.Second)
-			return nil
-		})
-
-		// Start worker
-		taskWorker.Start()
-		appLogger.Info("Task worker started")
+	// Initialize JWT service
+	jwtService, err := auth.NewJWTService(&cfg.JWT)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize JWT service", map[string]interface{}{"error": err.Error()})
 	}
 
-	// Initialize JWT service
-	jwtService := auth.NewJWTService(&cfg.JWT)
+	// Initialize attachment storage
+	fileStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize storage", map[string]interface{}{"error": err.Error()})
+	}
 
 	// Initialize Gin router
 	router := gin.Default()
 
 	// Apply middleware
-	middleware.Setup(router, cfg, jwtService, appLogger)
+	if err := middleware.Setup(router, cfg, jwtService, appLogger); err != nil {
+		appLogger.Fatal("Failed to set up middleware", map[string]interface{}{"error": err.Error()})
+	}
 	router.Use(monitoring.MetricsMiddleware(appLogger))
 
 	// Setup health check and metrics endpoints
 	monitoring.SetupHealthCheck(router, appLogger)
 
 	// Register routes
-	routes.Register(router, db, redisClient, cfg)
+	routes.Register(router, repos, redisClient, taskQueue, fileStorage, jwtService, cfg, appLogger)
 	appLogger.Info("Routes registered")
 
 	// Start server
 	serverAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	appLogger.Info("Starting server", map[string]interface{}{"address": serverAddr})
-	if err := router.Run(serverAddr); err != nil {
-		appLogger.Fatal("Failed to start server", map[string]interface{}{"error": err.Error()})
+	server := &http.Server{
+		Addr:    serverAddr,
+		Handler: router,
 	}
+
+	go func() {
+		appLogger.Info("Starting server", map[string]interface{}{"address": serverAddr})
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			appLogger.Fatal("Failed to start server", map[string]interface{}{"error": err.Error()})
+		}
+	}()
+
+	// Wait for a shutdown signal
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+	appLogger.Info("Shutting down", map[string]interface{}{"signal": sig.String(), "timeout": cfg.Server.ShutdownTimeout.String()})
+
+	// Stop accepting requests, then let in-flight ones finish
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		appLogger.Error("Server did not shut down cleanly", map[string]interface{}{"error": err.Error()})
+	}
+
+	appLogger.Info("Server stopped")
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/database"
+	"github.com/jaimesHub/golang-todo-app/migrations"
+)
+
+const migrateUsage = `Usage: api migrate <command> [flags]
+
+Commands:
+  up      Apply pending migrations
+  down    Roll back applied migrations (one by default)
+  status  List migrations and whether they have been applied
+
+Flags:
+  -steps int   Number of migrations to apply or roll back (0 = all for up, 1 for down)
+  -dry-run     Print the migrations that would run without applying them
+`
+
+// runMigrate implements the "migrate" subcommand
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return fmt.Errorf("missing migrate command")
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	steps := flags.Int("steps", 0, "number of migrations to apply or roll back")
+	dryRun := flags.Bool("dry-run", false, "print the migrations without applying them")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(*steps, *dryRun)
+		if err == nil || len(applied) > 0 {
+			printMigrations("apply", applied, *dryRun, true)
+		}
+		return err
+	case "down":
+		rolledBack, err := migrator.Down(*steps, *dryRun)
+		if err == nil || len(rolledBack) > 0 {
+			printMigrations("roll back", rolledBack, *dryRun, false)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return fmt.Errorf("unknown migrate command: %s", command)
+	}
+}
+
+// printMigrations reports the migrations an up or down run completed,
+// including their SQL in dry-run mode
+func printMigrations(verb string, list []database.Migration, dryRun, up bool) {
+	if len(list) == 0 {
+		fmt.Printf("No migrations to %s\n", verb)
+		return
+	}
+
+	for _, migration := range list {
+		if !dryRun {
+			fmt.Printf("Migration %03d_%s: %s done\n", migration.Version, migration.Name, verb)
+			continue
+		}
+
+		sql := migration.Down
+		if up {
+			sql = migration.Up
+		}
+		fmt.Printf("-- [dry run] would %s %03d_%s\n%s\n", verb, migration.Version, migration.Name, sql)
+	}
+}
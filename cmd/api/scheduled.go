@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/services/queue"
+)
+
+const scheduledUsage = `Usage: api scheduled <command> [flags]
+
+Commands:
+  list    List scheduled jobs, soonest first; retries have IDs of the
+          form retry:<job-id>:<attempt>
+  show    Show one scheduled job (-id required)
+  cancel  Cancel one scheduled job (-id required)
+
+Flags:
+  -queue string  Queue name (default QUEUE_NAME)
+  -limit int     Number of jobs to list (default 20)
+  -id string     Job ID
+`
+
+// runScheduled implements the "scheduled" subcommand
+func runScheduled(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, scheduledUsage)
+		return fmt.Errorf("missing scheduled command")
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("scheduled "+command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, scheduledUsage) }
+	queueName := flags.String("queue", cfg.Queue.Name, "queue name")
+	limit := flags.Int64("limit", 20, "number of jobs to list")
+	id := flags.String("id", "", "job ID")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if (command == "show" || command == "cancel") && *id == "" {
+		return fmt.Errorf("-id is required for %s", command)
+	}
+
+	taskQueue, err := queue.NewQueue(cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer taskQueue.Close()
+
+	ctx := context.Background()
+
+	switch command {
+	case "list":
+		tasks, err := taskQueue.ListScheduledTasks(ctx, *queueName, 0, *limit)
+		if err != nil {
+			return err
+		}
+
+		total, err := taskQueue.CountScheduledTasks(ctx, *queueName)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTYPE\tEXECUTE AT\tATTEMPTS")
+		for _, task := range tasks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", task.ScheduleID, task.Type, task.ExecuteAt.Format("2006-01-02 15:04:05"), task.Attempts)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Printf("%d of %d scheduled jobs shown\n", len(tasks), total)
+		return nil
+	case "show":
+		task, err := taskQueue.GetScheduledTask(ctx, *queueName, *id)
+		if errors.Is(err, queue.ErrNotScheduled) {
+			return fmt.Errorf("no scheduled job with ID %s", *id)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("ID:         %s\nType:       %s\nExecute at: %s\nAttempts:   %d\nData:       %v\n",
+			task.ScheduleID, task.Type, task.ExecuteAt.Format("2006-01-02 15:04:05"), task.Attempts, task.Data)
+		if task.LastError != "" {
+			fmt.Printf("Last error: %s\n", task.LastError)
+		}
+		return nil
+	case "cancel":
+		cancelled, err := taskQueue.CancelScheduledTask(ctx, *queueName, *id)
+		if err != nil {
+			return err
+		}
+		if !cancelled {
+			return fmt.Errorf("no scheduled job with ID %s", *id)
+		}
+		fmt.Printf("Cancelled job %s\n", *id)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, scheduledUsage)
+		return fmt.Errorf("unknown scheduled command: %s", command)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/database"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+)
+
+const usersUsage = `Usage: api users <command> [flags]
+
+Commands:
+  set-role  Give a user a role, e.g. to create the first admin
+            (-email and -role required)
+
+Flags:
+  -email string  Email of the user
+  -role string   Built-in role (user or admin) or custom role
+`
+
+// runUsers implements the "users" subcommand
+func runUsers(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usersUsage)
+		return fmt.Errorf("missing users command")
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("users "+command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usersUsage) }
+	email := flags.String("email", "", "email of the user")
+	role := flags.String("role", "", "role to give the user")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch command {
+	case "set-role":
+		if *email == "" || *role == "" {
+			return fmt.Errorf("-email and -role are required for %s", command)
+		}
+
+		db, err := database.Connect(cfg.Database)
+		if err != nil {
+			return err
+		}
+		repos := repository.NewGormRepositories(db)
+
+		user, err := services.NewUserService(repos).GetUserByEmail(*email)
+		if errors.Is(err, services.ErrUserNotFound) {
+			return fmt.Errorf("no user with email %s", *email)
+		}
+		if err != nil {
+			return err
+		}
+
+		// No admin acts here, so uuid.Nil stands in for the actor
+		adminService := services.NewAdminService(repos, nil, services.NewRoleService(repos))
+		if _, err := adminService.SetRole(uuid.Nil, user.ID, *role); err != nil {
+			return err
+		}
+		fmt.Printf("Role of %s set to %s\n", user.Email, *role)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usersUsage)
+		return fmt.Errorf("unknown users command: %s", command)
+	}
+}
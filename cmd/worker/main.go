@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/database"
+	"github.com/jaimesHub/golang-todo-app/internal/logger"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+	"github.com/jaimesHub/golang-todo-app/internal/services/mailer"
+	"github.com/jaimesHub/golang-todo-app/internal/services/notifier"
+	"github.com/jaimesHub/golang-todo-app/internal/services/storage"
+	"github.com/jaimesHub/golang-todo-app/internal/services/worker"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using environment variables")
+	}
+
+	// Initialize configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Initialize logger
+	appLogger, err := logger.NewLogger(cfg.Logging)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	appLogger.Info("Starting worker", map[string]interface{}{
+		"queue":       cfg.Queue.Name,
+		"concurrency": cfg.Queue.Concurrency,
+	})
+
+	// Initialize database connection; the API owns migrations
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		appLogger.Fatal("Failed to connect to database", map[string]interface{}{"error": err.Error()})
+	}
+	appLogger.Info("Connected to database")
+
+	repos := repository.NewGormRepositories(db)
+
+	// Initialize notification delivery
+	jobNotifier, err := notifier.New(cfg.Notifier, appLogger)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize notifier", map[string]interface{}{"error": err.Error()})
+	}
+	reminderService := services.NewReminderService(repos, jobNotifier)
+	mentionService := services.NewMentionService(repos, jobNotifier)
+	userService := services.NewUserService(repos)
+
+	// Initialize attachment storage
+	fileStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize storage", map[string]interface{}{"error": err.Error()})
+	}
+	attachmentService := services.NewAttachmentService(repos, services.NewTaskService(repos), fileStorage, cfg.Attachment)
+
+	// Initialize email delivery
+	jobMailer, err := mailer.New(cfg.Mailer, appLogger)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize mailer", map[string]interface{}{"error": err.Error()})
+	}
+
+	// Register job handlers
+	registry := jobs.NewRegistry()
+	jobs.Handle(registry, jobs.TaskReminder, reminderService.HandleReminder)
+	jobs.Handle(registry, jobs.CommentMention, mentionService.HandleMention)
+	jobs.Handle(registry, jobs.AttachmentCleanup, attachmentService.HandleCleanup)
+	jobs.Handle(registry, jobs.AccountPurge, func(ctx context.Context, payload jobs.AccountPurgePayload) error {
+		if err := userService.HandleAccountPurge(ctx, payload); err != nil {
+			return err
+		}
+		// The purged tasks leave their attachments behind
+		return attachmentService.RemoveOrphaned(ctx)
+	})
+	jobs.Handle(registry, jobs.EmailNotification, func(ctx context.Context, payload jobs.EmailNotificationPayload) error {
+		return jobMailer.Send(ctx, mailer.Message{
+			To:      payload.To,
+			Subject: payload.Subject,
+			Body:    payload.Body,
+		})
+	})
+
+	// Initialize worker
+	taskWorker, err := worker.NewWorker(cfg.Redis, cfg.Queue, cfg.Queue.Name)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize task worker", map[string]interface{}{"error": err.Error()})
+	}
+	defer taskWorker.Close()
+
+	registry.Register(taskWorker)
+	taskWorker.Start()
+	appLogger.Info("Task worker started", map[string]interface{}{"job_types": registry.Types()})
+
+	// Wait for a shutdown signal
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+	appLogger.Info("Shutting down", map[string]interface{}{"signal": sig.String(), "timeout": cfg.Server.ShutdownTimeout.String()})
+
+	// Stop taking jobs, then let running ones finish
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := taskWorker.Stop(ctx); err != nil {
+		appLogger.Error("Task worker did not finish running jobs", map[string]interface{}{"error": err.Error()})
+	}
+
+	appLogger.Info("Worker stopped")
+}
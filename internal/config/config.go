@@ -4,21 +4,44 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	Logging  LoggingConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	Redis      RedisConfig
+	Queue      QueueConfig
+	JWT        JWTConfig
+	Logging    LoggingConfig
+	Notifier   NotifierConfig
+	Mailer     MailerConfig
+	Account    AccountConfig
+	Login      LoginConfig
+	TwoFactor  TwoFactorConfig
+	Workspace  WorkspaceConfig
+	Storage    StorageConfig
+	Attachment AttachmentConfig
 }
 
 // ServerConfig holds the server configuration
 type ServerConfig struct {
 	Host string
 	Port int
+
+	// ShutdownTimeout is how long in-flight requests and jobs may run after
+	// a shutdown signal
+	ShutdownTimeout time.Duration
+
+	// DevMode allows starting with the placeholder JWT secret
+	DevMode bool
+
+	// TrustedProxies are the addresses or CIDR ranges whose X-Forwarded-For
+	// and X-Real-IP headers are believed; with none, the client IP is the
+	// address of the connection
+	TrustedProxies []string
 }
 
 // DatabaseConfig holds the database configuration
@@ -29,6 +52,9 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	// MigrateOnStart applies pending SQL migrations when the API starts
+	MigrateOnStart bool
 }
 
 // RedisConfig holds the Redis configuration
@@ -39,10 +65,55 @@ type RedisConfig struct {
 	DB       int
 }
 
+// QueueConfig holds the background job queue configuration
+type QueueConfig struct {
+	// Name is the queue the API schedules jobs on and the worker processes
+	Name string
+
+	// VisibilityTimeout is how long a worker may hold a job before it is
+	// handed to another worker
+	VisibilityTimeout time.Duration
+
+	// MaxRetries is how many times a failed job is retried before it is
+	// moved to the dead-letter list
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry; it doubles with
+	// every further attempt
+	RetryBackoff time.Duration
+
+	// Concurrency is how many jobs a worker runs at the same time
+	Concurrency int
+
+	// TypeConcurrency limits how many jobs of a task type run at the same
+	// time; types without a limit share the worker's concurrency
+	TypeConcurrency map[string]int
+}
+
+// DefaultJWTSecret is the placeholder JWT secret used when none is configured
+const DefaultJWTSecret = "your-secret-key"
+
 // JWTConfig holds the JWT configuration
 type JWTConfig struct {
-	Secret     string
-	Expiration int // in hours
+	Secret          string
+	AccessTokenTTL  time.Duration // lifetime of access tokens
+	RefreshTokenTTL time.Duration // lifetime of refresh tokens
+
+	// PreviousSecrets still verify HS256 tokens after the secret is rotated
+	PreviousSecrets []string
+
+	// KeyDir holds RSA or Ed25519 keys as <kid>.pem files. When set, tokens
+	// are signed with a private key from it instead of the secret.
+	KeyDir string
+
+	// SigningKeyID selects the key in KeyDir new tokens are signed with;
+	// by default the private key with the last kid in sort order is used
+	SigningKeyID string
+}
+
+// UsesDefaultSecret reports whether tokens are signed with the placeholder secret
+func (c JWTConfig) UsesDefaultSecret() bool {
+	return c.KeyDir == "" && c.Secret == DefaultJWTSecret
 }
 
 // LoggingConfig holds the logging configuration
@@ -51,6 +122,97 @@ type LoggingConfig struct {
 	File  string
 }
 
+// NotifierConfig holds the notification delivery configuration
+type NotifierConfig struct {
+	Sink string // log or file
+	File string // used by the file sink
+}
+
+// MailerConfig holds the email delivery configuration
+type MailerConfig struct {
+	Backend  string // log, file or smtp
+	From     string
+	Dir      string // used by the file backend
+	SMTPHost string // used by the smtp backend
+	SMTPPort int
+}
+
+// AccountConfig holds the account verification and recovery configuration
+type AccountConfig struct {
+	// AppURL is the base URL of links sent by email
+	AppURL string
+
+	// RequireEmailVerification blocks login until the email address is verified
+	RequireEmailVerification bool
+
+	VerificationTokenTTL time.Duration // lifetime of email verification links
+	ResetTokenTTL        time.Duration // lifetime of password reset links
+
+	// DeletionGracePeriod is how long a deleted account's data is kept
+	// before it is removed and the account anonymized
+	DeletionGracePeriod time.Duration
+}
+
+// LoginConfig holds the login brute-force protection configuration. Failed
+// logins are counted per email and per IP address; a limit of 0 disables
+// the lockout for that key.
+type LoginConfig struct {
+	MaxAttemptsPerEmail int
+	MaxAttemptsPerIP    int
+
+	// AttemptWindow is how long failures are remembered after the last one
+	AttemptWindow time.Duration
+
+	// LockoutDuration is how long logins are refused once a limit is reached
+	LockoutDuration time.Duration
+
+	// DelayBase is the wait after the first failure; it doubles with every
+	// further failure up to DelayMax
+	DelayBase time.Duration
+	DelayMax  time.Duration
+}
+
+// TwoFactorConfig holds the TOTP two-factor authentication configuration
+type TwoFactorConfig struct {
+	// Issuer is the account name prefix shown by authenticator apps
+	Issuer string
+
+	// ChallengeTTL is how long the second login step may take after the
+	// password was accepted
+	ChallengeTTL time.Duration
+}
+
+// WorkspaceConfig holds the workspace invitation configuration
+type WorkspaceConfig struct {
+	// AppURL is the base URL of links sent by email
+	AppURL string
+
+	InvitationTTL time.Duration // how long an invitation can be accepted
+}
+
+// StorageConfig holds the file storage configuration
+type StorageConfig struct {
+	Backend string // local or s3
+	Dir     string // used by the local backend
+
+	// S3 settings, used by the s3 backend. Objects are addressed path-style
+	// as <endpoint>/<bucket>/<key>, which AWS and MinIO both accept.
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// AttachmentConfig holds the task attachment limits
+type AttachmentConfig struct {
+	MaxSize int64 // largest accepted file, in bytes
+
+	// AllowedTypes lists the accepted MIME types; "image/*" accepts every
+	// image type
+	AllowedTypes []string
+}
+
 // Load loads the configuration from environment variables
 func Load() (*Config, error) {
 	serverPort, err := strconv.Atoi(getEnv("SERVER_PORT", "8080"))
@@ -73,15 +235,136 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid redis db: %v", err)
 	}
 
-	jwtExpiration, err := strconv.Atoi(getEnv("JWT_EXPIRATION", "24"))
+	visibilityTimeout, err := time.ParseDuration(getEnv("QUEUE_VISIBILITY_TIMEOUT", "5m"))
 	if err != nil {
-		return nil, fmt.Errorf("invalid jwt expiration: %v", err)
+		return nil, fmt.Errorf("invalid queue visibility timeout: %v", err)
+	}
+
+	maxRetries, err := strconv.Atoi(getEnv("QUEUE_MAX_RETRIES", "5"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid queue max retries: %v", err)
+	}
+
+	retryBackoff, err := time.ParseDuration(getEnv("QUEUE_RETRY_BACKOFF", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid queue retry backoff: %v", err)
+	}
+
+	concurrency, err := strconv.Atoi(getEnv("WORKER_CONCURRENCY", "4"))
+	if err != nil || concurrency < 1 {
+		return nil, fmt.Errorf("invalid worker concurrency: %s", getEnv("WORKER_CONCURRENCY", "4"))
 	}
 
+	typeConcurrency, err := parseTypeConcurrency(getEnv("WORKER_TYPE_CONCURRENCY", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid worker type concurrency: %v", err)
+	}
+
+	shutdownTimeout, err := time.ParseDuration(getEnv("SHUTDOWN_TIMEOUT", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid shutdown timeout: %v", err)
+	}
+
+	devMode, err := strconv.ParseBool(getEnv("DEV_MODE", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid dev mode: %v", err)
+	}
+
+	migrateOnStart, err := strconv.ParseBool(getEnv("DB_MIGRATE_ON_START", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid db migrate on start: %v", err)
+	}
+
+	accessTokenTTL, err := time.ParseDuration(getEnv("JWT_ACCESS_TTL", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwt access ttl: %v", err)
+	}
+
+	refreshTokenTTL, err := time.ParseDuration(getEnv("JWT_REFRESH_TTL", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwt refresh ttl: %v", err)
+	}
+
+	smtpPort, err := strconv.Atoi(getEnv("SMTP_PORT", "1025"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp port: %v", err)
+	}
+
+	requireEmailVerification, err := strconv.ParseBool(getEnv("REQUIRE_EMAIL_VERIFICATION", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid require email verification: %v", err)
+	}
+
+	verificationTokenTTL, err := time.ParseDuration(getEnv("EMAIL_VERIFICATION_TTL", "48h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid email verification ttl: %v", err)
+	}
+
+	resetTokenTTL, err := time.ParseDuration(getEnv("PASSWORD_RESET_TTL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid password reset ttl: %v", err)
+	}
+
+	deletionGracePeriod, err := time.ParseDuration(getEnv("ACCOUNT_DELETION_GRACE", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid account deletion grace: %v", err)
+	}
+
+	maxAttemptsPerEmail, err := strconv.Atoi(getEnv("LOGIN_MAX_ATTEMPTS_PER_EMAIL", "5"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid login max attempts per email: %v", err)
+	}
+
+	maxAttemptsPerIP, err := strconv.Atoi(getEnv("LOGIN_MAX_ATTEMPTS_PER_IP", "20"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid login max attempts per ip: %v", err)
+	}
+
+	attemptWindow, err := time.ParseDuration(getEnv("LOGIN_ATTEMPT_WINDOW", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid login attempt window: %v", err)
+	}
+
+	lockoutDuration, err := time.ParseDuration(getEnv("LOGIN_LOCKOUT_DURATION", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid login lockout duration: %v", err)
+	}
+
+	delayBase, err := time.ParseDuration(getEnv("LOGIN_DELAY_BASE", "1s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid login delay base: %v", err)
+	}
+
+	delayMax, err := time.ParseDuration(getEnv("LOGIN_DELAY_MAX", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid login delay max: %v", err)
+	}
+
+	challengeTTL, err := time.ParseDuration(getEnv("TWO_FACTOR_CHALLENGE_TTL", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid two factor challenge ttl: %v", err)
+	}
+
+	invitationTTL, err := time.ParseDuration(getEnv("WORKSPACE_INVITATION_TTL", "168h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace invitation ttl: %v", err)
+	}
+
+	attachmentMaxSize, err := strconv.ParseInt(getEnv("ATTACHMENT_MAX_SIZE", "10485760"), 10, 64)
+	if err != nil || attachmentMaxSize < 1 {
+		return nil, fmt.Errorf("invalid attachment max size: %s", getEnv("ATTACHMENT_MAX_SIZE", "10485760"))
+	}
+
+	appURL := strings.TrimSuffix(getEnv("APP_URL", "http://localhost:8080"), "/")
+
 	return &Config{
 		Server: ServerConfig{
 			Host: getEnv("SERVER_HOST", "0.0.0.0"),
 			Port: serverPort,
+
+			ShutdownTimeout: shutdownTimeout,
+			DevMode:         devMode,
+			TrustedProxies:  splitList(getEnv("TRUSTED_PROXIES", "")),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -90,6 +373,8 @@ func Load() (*Config, error) {
 			Password: getEnv("DB_PASSWORD", "postgres"),
 			DBName:   getEnv("DB_NAME", "todo_app"),
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+
+			MigrateOnStart: migrateOnStart,
 		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
@@ -97,17 +382,111 @@ func Load() (*Config, error) {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       redisDB,
 		},
+		Queue: QueueConfig{
+			Name:              getEnv("QUEUE_NAME", "tasks"),
+			VisibilityTimeout: visibilityTimeout,
+			MaxRetries:        maxRetries,
+			RetryBackoff:      retryBackoff,
+			Concurrency:       concurrency,
+			TypeConcurrency:   typeConcurrency,
+		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "your-secret-key"),
-			Expiration: jwtExpiration,
+			Secret:          getEnv("JWT_SECRET", DefaultJWTSecret),
+			AccessTokenTTL:  accessTokenTTL,
+			RefreshTokenTTL: refreshTokenTTL,
+			PreviousSecrets: splitList(getEnv("JWT_PREVIOUS_SECRETS", "")),
+			KeyDir:          getEnv("JWT_KEY_DIR", ""),
+			SigningKeyID:    getEnv("JWT_SIGNING_KEY_ID", ""),
 		},
 		Logging: LoggingConfig{
 			Level: getEnv("LOG_LEVEL", "info"),
 			File:  getEnv("LOG_FILE", ""),
 		},
+		Notifier: NotifierConfig{
+			Sink: getEnv("NOTIFIER_SINK", "log"),
+			File: getEnv("NOTIFIER_FILE", "logs/notifications.log"),
+		},
+		Mailer: MailerConfig{
+			Backend:  getEnv("MAILER_BACKEND", "log"),
+			From:     getEnv("MAIL_FROM", "no-reply@todo-app.local"),
+			Dir:      getEnv("MAILER_DIR", "logs/mail"),
+			SMTPHost: getEnv("SMTP_HOST", "localhost"),
+			SMTPPort: smtpPort,
+		},
+		Account: AccountConfig{
+			AppURL:                   appURL,
+			RequireEmailVerification: requireEmailVerification,
+			VerificationTokenTTL:     verificationTokenTTL,
+			ResetTokenTTL:            resetTokenTTL,
+			DeletionGracePeriod:      deletionGracePeriod,
+		},
+		Login: LoginConfig{
+			MaxAttemptsPerEmail: maxAttemptsPerEmail,
+			MaxAttemptsPerIP:    maxAttemptsPerIP,
+			AttemptWindow:       attemptWindow,
+			LockoutDuration:     lockoutDuration,
+			DelayBase:           delayBase,
+			DelayMax:            delayMax,
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:       getEnv("TOTP_ISSUER", "Todo App"),
+			ChallengeTTL: challengeTTL,
+		},
+		Workspace: WorkspaceConfig{
+			AppURL:        appURL,
+			InvitationTTL: invitationTTL,
+		},
+		Storage: StorageConfig{
+			Backend:     getEnv("STORAGE_BACKEND", "local"),
+			Dir:         getEnv("STORAGE_DIR", "data/attachments"),
+			S3Endpoint:  strings.TrimSuffix(getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"), "/"),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("S3_BUCKET", ""),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+		},
+		Attachment: AttachmentConfig{
+			MaxSize:      attachmentMaxSize,
+			AllowedTypes: splitList(getEnv("ATTACHMENT_ALLOWED_TYPES", "image/*,application/pdf,text/plain")),
+		},
 	}, nil
 }
 
+// parseTypeConcurrency parses per-type concurrency limits in the form
+// "task_reminder=2,email_notification=1"
+func parseTypeConcurrency(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	if value == "" {
+		return limits, nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		taskType, limit, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found || taskType == "" {
+			return nil, fmt.Errorf("expected type=limit, got %q", part)
+		}
+
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid limit for %s: %q", taskType, limit)
+		}
+		limits[taskType] = n
+	}
+
+	return limits, nil
+}
+
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -4,7 +4,7 @@ import (
 	"fmt"
 
 	"github.com/jaimesHub/golang-todo-app/internal/config"
-	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/migrations"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -48,20 +48,13 @@ func connectWithDSN(dsn string) (*gorm.DB, error) {
 	return db, nil
 }
 
-// Migrate runs database migrations
+// Migrate applies all pending SQL migrations
 func Migrate(db *gorm.DB) error {
-	// Add models to migrate here
-	return db.AutoMigrate(
-		&models.User{},
-		&models.Task{},
-		&models.Activity{},
-	)
-}
+	migrator, err := NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
 
-// RunSQLMigration executes SQL migration files
-func RunSQLMigration(db *gorm.DB, filePath string) error {
-	// This is a placeholder for running SQL migrations
-	// In a real implementation, you would read the SQL file and execute it
-	// For now, we'll rely on GORM's AutoMigrate
-	return nil
+	_, err = migrator.Up(0, false)
+	return err
 }
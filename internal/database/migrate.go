@@ -0,0 +1,327 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockID is the key of the Postgres advisory lock held while migrating,
+// so several replicas starting at once apply each migration only once
+const migrationLockID int64 = 7342186091
+
+// migrationFilePattern matches NNN_name.sql and NNN_name.down.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+?)(\.down)?\.sql$`)
+
+// Migration is a versioned SQL migration
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// AppliedMigration is a row of the schema_migrations table
+type AppliedMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	Checksum  string    `gorm:"type:varchar(64);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName overrides the table name used by AppliedMigration
+func (AppliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// LoadMigrations reads the migration files from a file system, ordered by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %q: %w", matches[1], err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(".", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, migration.Name, matches[2])
+		}
+
+		if matches[3] != "" {
+			migration.Down = string(content)
+		} else {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies and rolls back versioned SQL migrations
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the migrations found in fsys
+func NewMigrator(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies up to steps pending migrations (all of them when steps <= 0)
+// and returns those that were applied, even when a later one fails. With
+// dryRun set, the pending migrations are returned without being applied.
+func (m *Migrator) Up(steps int, dryRun bool) ([]Migration, error) {
+	var done []Migration
+
+	err := m.withLock(dryRun, func(tx *gorm.DB) error {
+		applied, err := m.applied(tx)
+		if err != nil {
+			return err
+		}
+
+		pending, err := planUp(m.migrations, applied, steps)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			done = pending
+			return nil
+		}
+
+		for _, migration := range pending {
+			if err := tx.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&AppliedMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					Checksum:  migration.Checksum,
+					AppliedAt: time.Now(),
+				}).Error
+			}); err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations (one when steps <= 0)
+// and returns those that were rolled back, even when a later one fails. With
+// dryRun set, the migrations are returned without being rolled back.
+func (m *Migrator) Down(steps int, dryRun bool) ([]Migration, error) {
+	var done []Migration
+
+	err := m.withLock(dryRun, func(tx *gorm.DB) error {
+		applied, err := m.applied(tx)
+		if err != nil {
+			return err
+		}
+
+		rollback, err := planDown(m.migrations, applied, steps)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			done = rollback
+			return nil
+		}
+
+		for _, migration := range rollback {
+			if err := tx.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&AppliedMigration{}, "version = ?", migration.Version).Error
+			}); err != nil {
+				return fmt.Errorf("rollback of migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Status reports every known migration and whether it has been applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock.
+// In dry-run mode the schema_migrations table is not created.
+func (m *Migrator) withLock(dryRun bool, fn func(tx *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		tx := conn.Session(&gorm.Session{})
+
+		if err := tx.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer tx.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+
+		if !dryRun {
+			if err := tx.AutoMigrate(&AppliedMigration{}); err != nil {
+				return fmt.Errorf("failed to create schema_migrations table: %w", err)
+			}
+		}
+
+		return fn(tx)
+	})
+}
+
+// applied returns the applied migrations keyed by version
+func (m *Migrator) applied(tx *gorm.DB) (map[int]AppliedMigration, error) {
+	applied := make(map[int]AppliedMigration)
+
+	if !tx.Migrator().HasTable(&AppliedMigration{}) {
+		return applied, nil
+	}
+
+	var records []AppliedMigration
+	if err := tx.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	return applied, nil
+}
+
+// planUp returns the migrations to apply, refusing to continue if an applied
+// migration was edited after the fact
+func planUp(migrations []Migration, applied map[int]AppliedMigration, steps int) ([]Migration, error) {
+	known := make(map[int]bool, len(migrations))
+	var pending []Migration
+
+	for _, migration := range migrations {
+		known[migration.Version] = true
+
+		record, ok := applied[migration.Version]
+		if !ok {
+			pending = append(pending, migration)
+			continue
+		}
+
+		if record.Checksum != migration.Checksum {
+			return nil, fmt.Errorf("checksum mismatch for applied migration %d_%s", migration.Version, migration.Name)
+		}
+	}
+
+	for version := range applied {
+		if !known[version] {
+			return nil, fmt.Errorf("applied migration %d has no migration file", version)
+		}
+	}
+
+	if steps > 0 && steps < len(pending) {
+		pending = pending[:steps]
+	}
+
+	return pending, nil
+}
+
+// planDown returns the applied migrations to roll back, newest first
+func planDown(migrations []Migration, applied map[int]AppliedMigration, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+
+	var rollback []Migration
+	for i := len(migrations) - 1; i >= 0 && len(rollback) < steps; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+
+		rollback = append(rollback, migration)
+	}
+
+	return rollback, nil
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/jaimesHub/golang-todo-app/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_add_index.sql":         {Data: []byte("CREATE INDEX idx ON tasks(title);")},
+		"001_init.sql":              {Data: []byte("CREATE TABLE t (id INT);")},
+		"001_init.down.sql":         {Data: []byte("DROP TABLE t;")},
+		"migrations.go":             {Data: []byte("package migrations")},
+		"README.md":                 {Data: []byte("ignored")},
+		"003_broken.down.sql":       {Data: []byte("DROP TABLE broken;")},
+		"not_a_migration_file.sql":  {Data: []byte("SELECT 1;")},
+		"004_second_thoughts.sqlx":  {Data: []byte("SELECT 1;")},
+		"002_add_index.down.sql":    {Data: []byte("DROP INDEX idx;")},
+		"005_no_down_migration.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	// A down file without an up file is rejected
+	_, err := LoadMigrations(fsys)
+	assert.EqualError(t, err, "migration 3_broken has no up file")
+
+	delete(fsys, "003_broken.down.sql")
+	list, err := LoadMigrations(fsys)
+	assert.NoError(t, err)
+	assert.Len(t, list, 3)
+
+	assert.Equal(t, 1, list[0].Version)
+	assert.Equal(t, "init", list[0].Name)
+	assert.Equal(t, "DROP TABLE t;", list[0].Down)
+	assert.Len(t, list[0].Checksum, 64)
+	assert.Equal(t, 2, list[1].Version)
+	assert.Equal(t, 5, list[2].Version)
+	assert.Empty(t, list[2].Down)
+}
+
+func TestPlanUpAndDown(t *testing.T) {
+	list := []Migration{
+		{Version: 1, Name: "init", Up: "up1", Down: "down1", Checksum: "a"},
+		{Version: 2, Name: "second", Up: "up2", Down: "down2", Checksum: "b"},
+		{Version: 3, Name: "third", Up: "up3", Checksum: "c"},
+	}
+	applied := map[int]AppliedMigration{
+		1: {Version: 1, Checksum: "a"},
+	}
+
+	pending, err := planUp(list, applied, 0)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.Equal(t, 2, pending[0].Version)
+
+	pending, err = planUp(list, applied, 1)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	// Edited migrations are refused
+	_, err = planUp(list, map[int]AppliedMigration{1: {Version: 1, Checksum: "edited"}}, 0)
+	assert.Error(t, err)
+
+	// Unknown applied versions are refused
+	_, err = planUp(list, map[int]AppliedMigration{1: {Version: 1, Checksum: "a"}, 9: {Version: 9}}, 0)
+	assert.Error(t, err)
+
+	applied[2] = AppliedMigration{Version: 2, Checksum: "b"}
+	rollback, err := planDown(list, applied, 0)
+	assert.NoError(t, err)
+	assert.Len(t, rollback, 1)
+	assert.Equal(t, 2, rollback[0].Version)
+
+	rollback, err = planDown(list, applied, 5)
+	assert.NoError(t, err)
+	assert.Len(t, rollback, 2)
+	assert.Equal(t, 1, rollback[1].Version)
+
+	// Rolling back a migration without a down file fails
+	applied[3] = AppliedMigration{Version: 3, Checksum: "c"}
+	_, err = planDown(list, applied, 1)
+	assert.Error(t, err)
+}
+
+func TestEmbeddedMigrationsHaveDownFiles(t *testing.T) {
+	list, err := LoadMigrations(migrations.FS)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, list)
+
+	for _, migration := range list {
+		assert.NotEmpty(t, migration.Down, "migration %d_%s has no down file", migration.Version, migration.Name)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+)
+
+// AccountHandler handles email verification and password recovery requests
+type AccountHandler struct {
+	userService    *services.UserService
+	accountService *services.AccountService
+}
+
+// NewAccountHandler creates a new account handler
+func NewAccountHandler(userService *services.UserService, accountService *services.AccountService) *AccountHandler {
+	return &AccountHandler{
+		userService:    userService,
+		accountService: accountService,
+	}
+}
+
+// VerifyEmail redeems an email verification token
+func (h *AccountHandler) VerifyEmail(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.accountService.VerifyEmail(input.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidUserToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	// Log activity
+	if err := h.userService.LogActivity(user.ID, "verify_email", "user", user.ID, "Email address verified"); err != nil {
+		// Just log the error, don't fail the verification
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerification sends a new verification email
+func (h *AccountHandler) ResendVerification(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.ResendVerification(c.Request.Context(), input.Email); err != nil {
+		respondMailError(c, err)
+		return
+	}
+
+	// Respond the same way whether or not the account exists
+	c.JSON(http.StatusOK, gin.H{"message": "If the account exists and is not verified, a verification email has been sent"})
+}
+
+// ForgotPassword sends a password reset email
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.RequestPasswordReset(c.Request.Context(), input.Email); err != nil {
+		respondMailError(c, err)
+		return
+	}
+
+	// Respond the same way whether or not the account exists
+	c.JSON(http.StatusOK, gin.H{"message": "If the account exists, a password reset email has been sent"})
+}
+
+// ResetPassword sets a new password using a password reset token
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	var input struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,min=6"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.accountService.ResetPassword(c.Request.Context(), input.Token, input.Password)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidUserToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	// Log activity
+	if err := h.userService.LogActivity(user.ID, "reset_password", "user", user.ID, "Password reset"); err != nil {
+		// Just log the error, don't fail the reset
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+// respondMailError responds to a failure to send an account email
+func respondMailError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrMailUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send email"})
+}
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+)
+
+// AdminHandler handles the admin API for managing users and roles
+type AdminHandler struct {
+	adminService *services.AdminService
+	roleService  *services.RoleService
+	userService  *services.UserService
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(adminService *services.AdminService, roleService *services.RoleService, userService *services.UserService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+		roleService:  roleService,
+		userService:  userService,
+	}
+}
+
+// ListUsers handles listing and searching users
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, total, err := h.adminService.ListUsers(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"pagination": gin.H{
+			"total":  total,
+			"limit":  filter.Limit,
+			"offset": filter.Offset,
+		},
+	})
+}
+
+// GetUser handles getting any user by ID
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	// Parse user ID from URL
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.adminService.GetUser(userID)
+	if err != nil {
+		respondAdminError(c, err, "Failed to get user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+	})
+}
+
+// Deactivate handles deactivating a user, which ends their sessions
+func (h *AdminHandler) Deactivate(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+// Reactivate handles reactivating a deactivated user
+func (h *AdminHandler) Reactivate(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+// setActive deactivates or reactivates the user of the request URL
+func (h *AdminHandler) setActive(c *gin.Context, active bool) {
+	// Get user ID from context (set by auth middleware)
+	actorID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse user ID from URL
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.adminService.SetActive(c.Request.Context(), actorID.(uuid.UUID), userID, active)
+	if err != nil {
+		respondAdminError(c, err, "Failed to update user")
+		return
+	}
+
+	action, message := "deactivate_user", "User deactivated successfully"
+	if active {
+		action, message = "reactivate_user", "User reactivated successfully"
+	}
+
+	// Log activity
+	h.userService.LogActivity(actorID.(uuid.UUID), action, "user", user.ID, "User "+user.Email)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"user":    user,
+	})
+}
+
+// ForceLogout handles ending every session of a user
+func (h *AdminHandler) ForceLogout(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	actorID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse user ID from URL
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.adminService.ForceLogout(c.Request.Context(), actorID.(uuid.UUID), userID); err != nil {
+		respondAdminError(c, err, "Failed to log out user")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(actorID.(uuid.UUID), "force_logout", "user", userID, "All sessions ended")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User logged out of all sessions",
+	})
+}
+
+// SetRole handles giving a user a role
+func (h *AdminHandler) SetRole(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	actorID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse user ID from URL
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.adminService.SetRole(actorID.(uuid.UUID), userID, input.Role)
+	if err != nil {
+		respondAdminError(c, err, "Failed to set role")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(actorID.(uuid.UUID), "set_role", "user", user.ID, "Role of "+user.Email+" set to "+user.Role)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role updated successfully",
+		"user":    user,
+	})
+}
+
+// GetUserActivities handles listing the activities of any user
+func (h *AdminHandler) GetUserActivities(c *gin.Context) {
+	// Parse user ID from URL
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Parse pagination parameters
+	limit := 10 // Default limit
+	offset := 0 // Default offset
+
+	if limitParam := c.Query("limit"); limitParam != "" {
+		if parsedLimit, err := strconv.Atoi(limitParam); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	if offsetParam := c.Query("offset"); offsetParam != "" {
+		if parsedOffset, err := strconv.Atoi(offsetParam); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	activities, err := h.adminService.GetUserActivities(userID, limit, offset)
+	if err != nil {
+		respondAdminError(c, err, "Failed to get activities")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"activities": activities,
+		"pagination": gin.H{
+			"limit":  limit,
+			"offset": offset,
+			"count":  len(activities),
+		},
+	})
+}
+
+// ListRoles handles listing the built-in and custom roles
+func (h *AdminHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles": roles,
+	})
+}
+
+// CreateRole handles creating a custom role
+func (h *AdminHandler) CreateRole(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	actorID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input struct {
+		Name        string   `json:"name" binding:"required"`
+		Description string   `json:"description" binding:"max=255"`
+		Permissions []string `json:"permissions"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.CreateRole(actorID.(uuid.UUID), input.Name, input.Description, input.Permissions)
+	if err != nil {
+		respondAdminError(c, err, "Failed to create role")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(actorID.(uuid.UUID), "create", "role", role.ID, "Role created: "+role.Name)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Role created successfully",
+		"role":    role,
+	})
+}
+
+// UpdateRole handles changing the description or permissions of a custom role
+func (h *AdminHandler) UpdateRole(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	actorID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input struct {
+		Description *string  `json:"description" binding:"omitempty,max=255"`
+		Permissions []string `json:"permissions"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(actorID.(uuid.UUID), c.Param("name"), input.Description, input.Permissions)
+	if err != nil {
+		respondAdminError(c, err, "Failed to update role")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(actorID.(uuid.UUID), "update", "role", role.ID, "Role updated: "+role.Name)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role updated successfully",
+		"role":    role,
+	})
+}
+
+// DeleteRole handles deleting a custom role no user has
+func (h *AdminHandler) DeleteRole(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	actorID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Get role before deletion for activity logging
+	role, err := h.roleService.GetRole(c.Param("name"))
+	if err != nil {
+		respondAdminError(c, err, "Failed to delete role")
+		return
+	}
+
+	if err := h.roleService.DeleteRole(role.Name); err != nil {
+		respondAdminError(c, err, "Failed to delete role")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(actorID.(uuid.UUID), "delete", "role", role.ID, "Role deleted: "+role.Name)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role deleted successfully",
+	})
+}
+
+// parseUserFilter builds a user filter from the list query parameters
+func parseUserFilter(c *gin.Context) (repository.UserFilter, error) {
+	filter := repository.UserFilter{
+		Query:  c.Query("q"),
+		Role:   c.Query("role"),
+		Limit:  10, // Default limit
+		Offset: 0,  // Default offset
+	}
+
+	if limitParam := c.Query("limit"); limitParam != "" {
+		if parsedLimit, err := strconv.Atoi(limitParam); err == nil && parsedLimit > 0 {
+			filter.Limit = parsedLimit
+		}
+	}
+
+	if offsetParam := c.Query("offset"); offsetParam != "" {
+		if parsedOffset, err := strconv.Atoi(offsetParam); err == nil && parsedOffset >= 0 {
+			filter.Offset = parsedOffset
+		}
+	}
+
+	if activeParam := c.Query("active"); activeParam != "" {
+		active, err := strconv.ParseBool(activeParam)
+		if err != nil {
+			return filter, errors.New("active must be true or false")
+		}
+		filter.Active = &active
+	}
+
+	return filter, nil
+}
+
+// respondAdminError responds to a failed admin request
+func respondAdminError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrRoleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCannotModifySelf), errors.Is(err, services.ErrBuiltinRole),
+		errors.Is(err, services.ErrPermissionEscalation):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrRoleExists), errors.Is(err, services.ErrRoleInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidRoleName), errors.Is(err, services.ErrInvalidPermissions):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+)
+
+// APITokenHandler handles personal access token requests
+type APITokenHandler struct {
+	apiTokenService *services.APITokenService
+	userService     *services.UserService
+}
+
+// NewAPITokenHandler creates a new API token handler
+func NewAPITokenHandler(apiTokenService *services.APITokenService, userService *services.UserService) *APITokenHandler {
+	return &APITokenHandler{
+		apiTokenService: apiTokenService,
+		userService:     userService,
+	}
+}
+
+// Create handles creating a personal access token. The token value is only
+// part of this response.
+func (h *APITokenHandler) Create(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input struct {
+		Name      string     `json:"name" binding:"required,max=100"`
+		Scopes    []string   `json:"scopes" binding:"required"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, value, err := h.apiTokenService.CreateToken(userID.(uuid.UUID), input.Name, input.Scopes, input.ExpiresAt)
+	if err != nil {
+		respondAPITokenError(c, err, "Failed to create API token")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"create",
+		"api_token",
+		token.ID,
+		"API token created: "+token.Name,
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "API token created successfully",
+		"api_token": token,
+		"token":     value,
+	})
+}
+
+// List handles listing the current user's personal access tokens
+func (h *APITokenHandler) List(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	tokens, err := h.apiTokenService.GetTokens(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_tokens": tokens,
+	})
+}
+
+// GetByID handles getting a personal access token by ID
+func (h *APITokenHandler) GetByID(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse token ID from URL
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API token ID"})
+		return
+	}
+
+	token, err := h.apiTokenService.GetToken(tokenID, userID.(uuid.UUID))
+	if err != nil {
+		respondAPITokenError(c, err, "Failed to get API token")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_token": token,
+	})
+}
+
+// Update handles renaming a personal access token or changing its scopes
+func (h *APITokenHandler) Update(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse token ID from URL
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API token ID"})
+		return
+	}
+
+	var input struct {
+		Name   *string  `json:"name" binding:"omitempty,min=1,max=100"`
+		Scopes []string `json:"scopes"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.apiTokenService.UpdateToken(tokenID, userID.(uuid.UUID), input.Name, input.Scopes)
+	if err != nil {
+		respondAPITokenError(c, err, "Failed to update API token")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"update",
+		"api_token",
+		token.ID,
+		"API token updated: "+token.Name,
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "API token updated successfully",
+		"api_token": token,
+	})
+}
+
+// Delete handles revoking a personal access token
+func (h *APITokenHandler) Delete(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse token ID from URL
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API token ID"})
+		return
+	}
+
+	// Get token before deletion for activity logging
+	token, err := h.apiTokenService.GetToken(tokenID, userID.(uuid.UUID))
+	if err != nil {
+		respondAPITokenError(c, err, "Failed to delete API token")
+		return
+	}
+
+	if err := h.apiTokenService.DeleteToken(tokenID, userID.(uuid.UUID)); err != nil {
+		respondAPITokenError(c, err, "Failed to delete API token")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"delete",
+		"api_token",
+		tokenID,
+		"API token deleted: "+token.Name,
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API token deleted successfully",
+	})
+}
+
+// respondAPITokenError responds to a failed personal access token request
+func respondAPITokenError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrAPITokenNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidScopes), errors.Is(err, services.ErrInvalidExpiry):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
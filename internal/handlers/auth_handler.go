@@ -1,32 +1,42 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
 	"github.com/jaimesHub/golang-todo-app/internal/services"
 	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
-	userService *services.UserService
-	jwtService  *auth.JWTService
+	userService      *services.UserService
+	authService      *services.AuthService
+	accountService   *services.AccountService
+	twoFactorService *services.TwoFactorService
 }
 
 // NewAuthHandler creates a new authentication handler
-func NewAuthHandler(userService *services.UserService, jwtService *auth.JWTService) *AuthHandler {
+func NewAuthHandler(userService *services.UserService, authService *services.AuthService, accountService *services.AccountService, twoFactorService *services.TwoFactorService) *AuthHandler {
 	return &AuthHandler{
-		userService: userService,
-		jwtService:  jwtService,
+		userService:      userService,
+		authService:      authService,
+		accountService:   accountService,
+		twoFactorService: twoFactorService,
 	}
 }
 
 // Register handles user registration
 // Note: This is now handled by UserHandler
 
-// Login handles user login
+// Login handles user login. Repeated failures are answered with 429 and a
+// Retry-After header. Accounts with two-factor authentication get a
+// challenge token instead of a session, to be completed by LoginTwoFactor.
 func (h *AuthHandler) Login(c *gin.Context) {
 	var input struct {
 		Email    string `json:"email" binding:"required,email"`
@@ -38,21 +48,70 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Get user by email
-	user, err := h.userService.GetUserByEmail(input.Email)
+	// Verify email and password
+	user, err := h.authService.Authenticate(c.Request.Context(), input.Email, input.Password, c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		respondLoginError(c, err)
 		return
 	}
 
-	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+	// Unverified accounts may be blocked
+	if err := h.accountService.CheckLogin(user); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The password alone is not enough with two-factor authentication
+	if user.TwoFactorEnabled() {
+		challenge, err := h.twoFactorService.Challenge(user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":             "Two-factor authentication required",
+			"two_factor_required": true,
+			"challenge_token":     challenge.Token,
+			"expires_in":          challenge.ExpiresIn,
+		})
+		return
+	}
+
+	h.startSession(c, user)
+}
+
+// LoginTwoFactor completes a login with the challenge token returned by
+// Login and a TOTP or recovery code
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var input struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Generate token
-	token, err := h.jwtService.GenerateToken(user.ID)
+	user, err := h.twoFactorService.CompleteLogin(c.Request.Context(), input.ChallengeToken, input.Code, c.ClientIP())
+	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	// The account may have been deactivated since the password was checked
+	if err := h.accountService.CheckLogin(user); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.startSession(c, user)
+}
+
+// startSession issues a token pair to a user who just logged in
+func (h *AuthHandler) startSession(c *gin.Context, user *models.User) {
+	tokens, err := h.authService.IssueTokens(user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -65,8 +124,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   token,
+		"message":       "Login successful",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 		"user": gin.H{
 			"id":         user.ID,
 			"email":      user.Email,
@@ -76,10 +137,27 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
-// RefreshToken handles refreshing JWT tokens
+// respondLoginError responds to a failed login step
+func respondLoginError(c *gin.Context, err error) {
+	var throttled *services.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		retryAfter := int64((throttled.RetryAfter + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "retry_after": retryAfter})
+	case errors.Is(err, services.ErrInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+	case errors.Is(err, services.ErrInvalidChallenge), errors.Is(err, services.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+	}
+}
+
+// RefreshToken exchanges a refresh token for a new token pair
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	var input struct {
-		Token string `json:"token" binding:"required"`
+		RefreshToken string `json:"refresh_token" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -87,15 +165,81 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Refresh token
-	newToken, err := h.jwtService.RefreshToken(input.Token)
+	// Rotate the refresh token
+	tokens, err := h.authService.Refresh(input.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Token refreshed successfully",
-		"token":   newToken,
+		"message":       "Token refreshed successfully",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
+
+// Logout ends the current session
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// The body is optional; without a refresh token only the access token is revoked
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	tokenClaims := claims.(*auth.TokenClaims)
+
+	if err := h.authService.Logout(c.Request.Context(), tokenClaims, input.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	// Log activity
+	if err := h.userService.LogActivity(tokenClaims.UserID, "logout", "user", tokenClaims.UserID, "User logged out"); err != nil {
+		// Just log the error, don't fail the logout
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll ends every session of the current user
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	tokenClaims := claims.(*auth.TokenClaims)
+
+	if err := h.authService.LogoutAll(c.Request.Context(), tokenClaims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	// Log activity
+	if err := h.userService.LogActivity(tokenClaims.UserID, "logout_all", "user", tokenClaims.UserID, "User logged out of all sessions"); err != nil {
+		// Just log the error, don't fail the logout
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions"})
+}
+
+// JWKS publishes the public keys access tokens can be verified with
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.authService.JWKS())
+}
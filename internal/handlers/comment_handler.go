@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+)
+
+// CommentHandler handles requests for the comments on tasks
+type CommentHandler struct {
+	commentService *services.CommentService
+	userService    *services.UserService
+}
+
+// NewCommentHandler creates a new comment handler
+func NewCommentHandler(commentService *services.CommentService, userService *services.UserService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+		userService:    userService,
+	}
+}
+
+// commentInput is the body of a create or update comment request
+type commentInput struct {
+	Body string `json:"body" binding:"required,max=5000"`
+}
+
+// Create handles adding a comment to a task
+func (h *CommentHandler) Create(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse task ID from URL
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var input commentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	author, err := h.userService.GetUserByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(c.Request.Context(), taskID, author, input.Body)
+	if err != nil {
+		respondCommentError(c, err, "Failed to create comment")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		author.ID,
+		"create",
+		"comment",
+		comment.ID,
+		"Comment added to task "+taskID.String(),
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Comment created successfully",
+		"comment": commentResponse(comment),
+	})
+}
+
+// List handles listing the comments of a task, oldest first, a page at a time
+func (h *CommentHandler) List(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse task ID from URL
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	limit := 20
+	if limitParam := c.Query("limit"); limitParam != "" {
+		if parsedLimit, err := strconv.Atoi(limitParam); err == nil && parsedLimit > 0 {
+			limit = min(parsedLimit, 100)
+		}
+	}
+
+	page, err := h.commentService.GetComments(taskID, userID.(uuid.UUID), c.Query("cursor"), limit)
+	if err != nil {
+		respondCommentError(c, err, "Failed to list comments")
+		return
+	}
+
+	comments := make([]gin.H, 0, len(page.Comments))
+	for i := range page.Comments {
+		comments = append(comments, commentResponse(&page.Comments[i]))
+	}
+
+	var nextCursor *string
+	if page.NextCursor != "" {
+		nextCursor = &page.NextCursor
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": comments,
+		"pagination": gin.H{
+			"limit":       limit,
+			"next_cursor": nextCursor,
+		},
+	})
+}
+
+// Update handles editing one of the current user's comments
+func (h *CommentHandler) Update(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse task and comment IDs from URL
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	commentID, err := uuid.Parse(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	var input commentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	author, err := h.userService.GetUserByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(c.Request.Context(), taskID, commentID, author, input.Body)
+	if err != nil {
+		respondCommentError(c, err, "Failed to update comment")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		author.ID,
+		"update",
+		"comment",
+		comment.ID,
+		"Comment edited on task "+taskID.String(),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment updated successfully",
+		"comment": commentResponse(comment),
+	})
+}
+
+// Delete handles deleting a comment
+func (h *CommentHandler) Delete(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse task and comment IDs from URL
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	commentID, err := uuid.Parse(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	if _, err := h.commentService.DeleteComment(taskID, commentID, userID.(uuid.UUID)); err != nil {
+		respondCommentError(c, err, "Failed to delete comment")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"delete",
+		"comment",
+		commentID,
+		"Comment deleted from task "+taskID.String(),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment deleted successfully",
+	})
+}
+
+// commentResponse shows a comment with the public part of its author's profile
+func commentResponse(comment *models.Comment) gin.H {
+	response := gin.H{
+		"id":         comment.ID,
+		"task_id":    comment.TaskID,
+		"user_id":    comment.UserID,
+		"body":       comment.Body,
+		"mentions":   comment.Mentions,
+		"edited_at":  comment.EditedAt,
+		"created_at": comment.CreatedAt,
+		"updated_at": comment.UpdatedAt,
+	}
+	if comment.Mentions == nil {
+		response["mentions"] = []string{}
+	}
+	if comment.User != nil {
+		response["author"] = gin.H{
+			"email":      comment.User.Email,
+			"first_name": comment.User.FirstName,
+			"last_name":  comment.User.LastName,
+		}
+	}
+	return response
+}
+
+// respondCommentError maps comment service errors to HTTP responses
+func respondCommentError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound), errors.Is(err, services.ErrCommentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCommentForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCommentEmpty), errors.Is(err, services.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
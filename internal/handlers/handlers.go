@@ -4,7 +4,6 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // HealthCheck handles the health check endpoint
@@ -14,99 +13,3 @@ func HealthCheck(c *gin.Context) {
 		"message": "Service is running",
 	})
 }
-
-// UserHandler handles user-related requests
-type UserHandler struct {
-	db *gorm.DB
-}
-
-// NewUserHandler creates a new user handler
-func NewUserHandler(db *gorm.DB) *UserHandler {
-	return &UserHandler{db: db}
-}
-
-// GetProfile handles getting the current user's profile
-func (h *UserHandler) GetProfile(c *gin.Context) {
-	// Implementation will be added in user management step
-	c.JSON(http.StatusOK, gin.H{"message": "Get profile endpoint"})
-}
-
-// UpdateProfile handles updating the current user's profile
-func (h *UserHandler) UpdateProfile(c *gin.Context) {
-	// Implementation will be added in user management step
-	c.JSON(http.StatusOK, gin.H{"message": "Update profile endpoint"})
-}
-
-// GetActivities handles getting the current user's activities
-func (h *UserHandler) GetActivities(c *gin.Context) {
-	// Implementation will be added in user management step
-	c.JSON(http.StatusOK, gin.H{"message": "Get activities endpoint"})
-}
-
-// TaskHandler handles task-related requests
-type TaskHandler struct {
-	db *gorm.DB
-}
-
-// NewTaskHandler creates a new task handler
-func NewTaskHandler(db *gorm.DB) *TaskHandler {
-	return &TaskHandler{db: db}
-}
-
-// Create handles creating a new task
-func (h *TaskHandler) Create(c *gin.Context) {
-	// Implementation will be added in task management step
-	c.JSON(http.StatusOK, gin.H{"message": "Create task endpoint"})
-}
-
-// List handles listing all tasks
-func (h *TaskHandler) List(c *gin.Context) {
-	// Implementation will be added in task management step
-	c.JSON(http.StatusOK, gin.H{"message": "List tasks endpoint"})
-}
-
-// GetByID handles getting a task by ID
-func (h *TaskHandler) GetByID(c *gin.Context) {
-	// Implementation will be added in task management step
-	c.JSON(http.StatusOK, gin.H{"message": "Get task by ID endpoint"})
-}
-
-// Update handles updating a task
-func (h *TaskHandler) Update(c *gin.Context) {
-	// Implementation will be added in task management step
-	c.JSON(http.StatusOK, gin.H{"message": "Update task endpoint"})
-}
-
-// Delete handles deleting a task
-func (h *TaskHandler) Delete(c *gin.Context) {
-	// Implementation will be added in task management step
-	c.JSON(http.StatusOK, gin.H{"message": "Delete task endpoint"})
-}
-
-// AuthHandler handles authentication-related requests
-type AuthHandler struct {
-	db *gorm.DB
-}
-
-// NewAuthHandler creates a new authentication handler
-func NewAuthHandler(db *gorm.DB) *AuthHandler {
-	return &AuthHandler{db: db}
-}
-
-// Register handles user registration
-func (h *AuthHandler) Register(c *gin.Context) {
-	// Implementation will be added in authentication step
-	c.JSON(http.StatusOK, gin.H{"message": "Register endpoint"})
-}
-
-// Login handles user login
-func (h *AuthHandler) Login(c *gin.Context) {
-	// Implementation will be added in authentication step
-	c.JSON(http.StatusOK, gin.H{"message": "Login endpoint"})
-}
-
-// RefreshToken handles refreshing JWT tokens
-func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	// Implementation will be added in authentication step
-	c.JSON(http.StatusOK, gin.H{"message": "Refresh token endpoint"})
-}
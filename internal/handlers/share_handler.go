@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+)
+
+// ShareHandler handles sharing tasks and projects with other users
+type ShareHandler struct {
+	shareService *services.ShareService
+	userService  *services.UserService
+}
+
+// NewShareHandler creates a new share handler
+func NewShareHandler(shareService *services.ShareService, userService *services.UserService) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+		userService:  userService,
+	}
+}
+
+// shareInput is the body of a share request
+type shareInput struct {
+	Email      string `json:"email" binding:"required,email"`
+	Permission string `json:"permission" binding:"required"`
+}
+
+// ShareTask handles sharing a task with a user, or changing their permission
+func (h *ShareHandler) ShareTask(c *gin.Context) {
+	h.share(c, "task", h.shareService.ShareTask)
+}
+
+// ListTaskShares handles listing the users a task is shared with
+func (h *ShareHandler) ListTaskShares(c *gin.Context) {
+	h.list(c, "task", h.shareService.GetTaskShares)
+}
+
+// UnshareTask handles revoking the share of a task with a user
+func (h *ShareHandler) UnshareTask(c *gin.Context) {
+	h.unshare(c, "task", h.shareService.UnshareTask)
+}
+
+// ShareProject handles sharing the tasks of a project with a user, or
+// changing their permission
+func (h *ShareHandler) ShareProject(c *gin.Context) {
+	h.share(c, "project", h.shareService.ShareProject)
+}
+
+// ListProjectShares handles listing the users a project is shared with
+func (h *ShareHandler) ListProjectShares(c *gin.Context) {
+	h.list(c, "project", h.shareService.GetProjectShares)
+}
+
+// UnshareProject handles revoking the share of a project with a user
+func (h *ShareHandler) UnshareProject(c *gin.Context) {
+	h.unshare(c, "project", h.shareService.UnshareProject)
+}
+
+// share grants a user access to the task or project named by the id parameter
+func (h *ShareHandler) share(c *gin.Context, entity string, grant func(id, ownerID uuid.UUID, email, permission string) (*models.Share, error)) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + entity + " ID"})
+		return
+	}
+
+	var input shareInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	share, err := grant(id, userID.(uuid.UUID), input.Email, input.Permission)
+	if err != nil {
+		respondShareError(c, err, "Failed to share "+entity)
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"share",
+		entity,
+		id,
+		"Shared with "+share.User.Email+" ("+share.Permission+")",
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Shared successfully",
+		"share":   shareResponse(share),
+	})
+}
+
+// list shows the shares of the task or project named by the id parameter
+func (h *ShareHandler) list(c *gin.Context, entity string, find func(id, ownerID uuid.UUID) ([]models.Share, error)) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + entity + " ID"})
+		return
+	}
+
+	shares, err := find(id, userID.(uuid.UUID))
+	if err != nil {
+		respondShareError(c, err, "Failed to list shares")
+		return
+	}
+
+	response := make([]gin.H, 0, len(shares))
+	for i := range shares {
+		response = append(response, shareResponse(&shares[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shares": response,
+	})
+}
+
+// unshare revokes the share of the task or project named by the id
+// parameter with the user named by the userId parameter
+func (h *ShareHandler) unshare(c *gin.Context, entity string, revoke func(id, actorID, userID uuid.UUID) error) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + entity + " ID"})
+		return
+	}
+
+	recipientID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := revoke(id, userID.(uuid.UUID), recipientID); err != nil {
+		respondShareError(c, err, "Failed to revoke share")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"unshare",
+		entity,
+		id,
+		"Share revoked for user "+recipientID.String(),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Share revoked successfully",
+	})
+}
+
+// shareResponse shows a share with the public part of the recipient's profile
+func shareResponse(share *models.Share) gin.H {
+	response := gin.H{
+		"id":         share.ID,
+		"user_id":    share.UserID,
+		"permission": share.Permission,
+		"created_at": share.CreatedAt,
+		"updated_at": share.UpdatedAt,
+	}
+	if share.TaskID != nil {
+		response["task_id"] = share.TaskID
+	}
+	if share.ProjectID != nil {
+		response["project_id"] = share.ProjectID
+	}
+	if share.User != nil {
+		response["email"] = share.User.Email
+		response["first_name"] = share.User.FirstName
+		response["last_name"] = share.User.LastName
+	}
+	return response
+}
+
+// respondShareError maps share service errors to HTTP responses
+func respondShareError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound), errors.Is(err, services.ErrProjectNotFound),
+		errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrShareNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrShareOwnerOnly):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidSharePermission), errors.Is(err, services.ErrShareWithSelf),
+		errors.Is(err, services.ErrShareWorkspaceTask):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -1,26 +1,34 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
 	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/jaimesHub/golang-todo-app/internal/services/recurrence"
 )
 
 // TaskHandler handles task-related requests
 type TaskHandler struct {
-	taskService *services.TaskService
-	userService *services.UserService
+	taskService      *services.TaskService
+	userService      *services.UserService
+	tagService       *services.TagService
+	workspaceService *services.WorkspaceService
 }
 
 // NewTaskHandler creates a new task handler
-func NewTaskHandler(taskService *services.TaskService, userService *services.UserService) *TaskHandler {
+func NewTaskHandler(taskService *services.TaskService, userService *services.UserService, tagService *services.TagService, workspaceService *services.WorkspaceService) *TaskHandler {
 	return &TaskHandler{
-		taskService: taskService,
-		userService: userService,
+		taskService:      taskService,
+		userService:      userService,
+		tagService:       tagService,
+		workspaceService: workspaceService,
 	}
 }
 
@@ -34,10 +42,16 @@ func (h *TaskHandler) Create(c *gin.Context) {
 	}
 
 	var input struct {
-		Title       string     `json:"title" binding:"required"`
-		Description string     `json:"description"`
-		Priority    int        `json:"priority"`
-		DueDate     *time.Time `json:"due_date"`
+		Title           string     `json:"title" binding:"required"`
+		Description     string     `json:"description"`
+		Priority        int        `json:"priority"`
+		DueDate         *time.Time `json:"due_date"`
+		ReminderMinutes *int       `json:"reminder_minutes"`
+		ProjectID       *string    `json:"project_id"`
+		ParentID        *string    `json:"parent_id"`
+		Recurrence      *string    `json:"recurrence"`
+		WorkspaceID     *string    `json:"workspace_id"`
+		AssigneeID      *string    `json:"assignee_id"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -45,15 +59,47 @@ func (h *TaskHandler) Create(c *gin.Context) {
 		return
 	}
 
-	task, err := h.taskService.CreateTask(
-		userID.(uuid.UUID),
-		input.Title,
-		input.Description,
-		input.Priority,
-		input.DueDate,
-	)
+	projectID, err := parseOptionalUUID(input.ProjectID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	parentID, err := parseOptionalUUID(input.ParentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent task ID"})
+		return
+	}
+
+	workspaceID, err := parseOptionalUUID(input.WorkspaceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+	if workspaceID != nil && *workspaceID == uuid.Nil {
+		workspaceID = nil
+	}
+
+	assigneeID, err := parseOptionalUUID(input.AssigneeID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignee ID"})
+		return
+	}
+
+	task, err := h.taskService.CreateTask(userID.(uuid.UUID), services.TaskInput{
+		Title:           input.Title,
+		Description:     input.Description,
+		Priority:        input.Priority,
+		DueDate:         input.DueDate,
+		ReminderMinutes: input.ReminderMinutes,
+		ProjectID:       projectID,
+		ParentID:        parentID,
+		Recurrence:      input.Recurrence,
+		WorkspaceID:     workspaceID,
+		AssigneeID:      assigneeID,
+	})
+	if err != nil {
+		c.JSON(taskErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -81,38 +127,33 @@ func (h *TaskHandler) List(c *gin.Context) {
 		return
 	}
 
-	// Parse query parameters
-	status := c.Query("status")
-
-	priority := -1 // Default value to indicate no filter
-	if priorityParam := c.Query("priority"); priorityParam != "" {
-		if parsedPriority, err := strconv.Atoi(priorityParam); err == nil {
-			priority = parsedPriority
-		}
+	filter, err := parseTaskFilter(c, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	limit := 10 // Default limit
-	if limitParam := c.Query("limit"); limitParam != "" {
-		if parsedLimit, err := strconv.Atoi(limitParam); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
+	if err := parseTaskScope(c, &filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	offset := 0 // Default offset
-	if offsetParam := c.Query("offset"); offsetParam != "" {
-		if parsedOffset, err := strconv.Atoi(offsetParam); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
+	// Only members list the tasks of a workspace
+	if filter.WorkspaceID != nil {
+		if _, err := h.workspaceService.GetWorkspace(*filter.WorkspaceID, filter.UserID); err != nil {
+			respondWorkspaceError(c, err, "Failed to list tasks")
+			return
 		}
 	}
 
-	tasks, err := h.taskService.GetTasks(userID.(uuid.UUID), status, priority, limit, offset)
+	tasks, err := h.taskService.GetTasks(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Get total count for pagination
-	totalCount, err := h.taskService.CountTasks(userID.(uuid.UUID), status)
+	totalCount, err := h.taskService.CountTasks(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -122,8 +163,77 @@ func (h *TaskHandler) List(c *gin.Context) {
 		"tasks": tasks,
 		"pagination": gin.H{
 			"total":  totalCount,
-			"limit":  limit,
-			"offset": offset,
+			"limit":  filter.Limit,
+			"offset": filter.Offset,
+		},
+	})
+}
+
+// Search handles full-text search of tasks. It takes the query as q and the
+// same filters as List; results are ordered by relevance.
+func (h *TaskHandler) Search(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	filter, err := parseTaskFilter(c, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := parseTaskScope(c, &filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Search = c.Query("q")
+
+	// Only members search the tasks of a workspace
+	if filter.WorkspaceID != nil {
+		if _, err := h.workspaceService.GetWorkspace(*filter.WorkspaceID, filter.UserID); err != nil {
+			respondWorkspaceError(c, err, "Failed to search tasks")
+			return
+		}
+	}
+
+	matches, err := h.taskService.SearchTasks(filter)
+	if err != nil {
+		if errors.Is(err, services.ErrEmptySearch) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search tasks"})
+		return
+	}
+
+	// Get total count for pagination
+	totalCount, err := h.taskService.CountTasks(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]gin.H, 0, len(matches))
+	for _, match := range matches {
+		results = append(results, gin.H{
+			"task": match.Task,
+			"rank": match.Rank,
+			"highlights": gin.H{
+				"title":       match.TitleHighlight,
+				"description": match.Snippet,
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"pagination": gin.H{
+			"total":  totalCount,
+			"limit":  filter.Limit,
+			"offset": filter.Offset,
 		},
 	})
 }
@@ -145,6 +255,20 @@ func (h *TaskHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	// With ?subtasks=true the task is returned with its subtree and completion
+	if c.Query("subtasks") == "true" {
+		tree, err := h.taskService.GetTaskTree(taskID, userID.(uuid.UUID))
+		if err != nil {
+			c.JSON(taskErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"task": tree,
+		})
+		return
+	}
+
 	task, err := h.taskService.GetTaskByID(taskID, userID.(uuid.UUID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -174,11 +298,16 @@ func (h *TaskHandler) Update(c *gin.Context) {
 	}
 
 	var input struct {
-		Title       string     `json:"title"`
-		Description string     `json:"description"`
-		Status      string     `json:"status"`
-		Priority    int        `json:"priority"`
-		DueDate     *time.Time `json:"due_date"`
+		Title           string     `json:"title"`
+		Description     string     `json:"description"`
+		Status          string     `json:"status"`
+		Priority        int        `json:"priority"`
+		DueDate         *time.Time `json:"due_date"`
+		ReminderMinutes *int       `json:"reminder_minutes"`
+		ProjectID       *string    `json:"project_id"`
+		ParentID        *string    `json:"parent_id"`
+		Recurrence      *string    `json:"recurrence"`
+		AssigneeID      *string    `json:"assignee_id"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -186,17 +315,38 @@ func (h *TaskHandler) Update(c *gin.Context) {
 		return
 	}
 
-	task, err := h.taskService.UpdateTask(
-		taskID,
-		userID.(uuid.UUID),
-		input.Title,
-		input.Description,
-		input.Status,
-		input.Priority,
-		input.DueDate,
-	)
+	projectID, err := parseOptionalUUID(input.ProjectID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	parentID, err := parseOptionalUUID(input.ParentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent task ID"})
+		return
+	}
+
+	assigneeID, err := parseOptionalUUID(input.AssigneeID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignee ID"})
+		return
+	}
+
+	task, err := h.taskService.UpdateTask(taskID, userID.(uuid.UUID), services.TaskInput{
+		Title:           input.Title,
+		Description:     input.Description,
+		Status:          input.Status,
+		Priority:        input.Priority,
+		DueDate:         input.DueDate,
+		ReminderMinutes: input.ReminderMinutes,
+		ProjectID:       projectID,
+		ParentID:        parentID,
+		Recurrence:      input.Recurrence,
+		AssigneeID:      assigneeID,
+	})
+	if err != nil {
+		c.JSON(taskErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -240,7 +390,7 @@ func (h *TaskHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.taskService.DeleteTask(taskID, userID.(uuid.UUID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(taskErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -257,3 +407,405 @@ func (h *TaskHandler) Delete(c *gin.Context) {
 		"message": "Task deleted successfully",
 	})
 }
+
+// AttachTag handles attaching a tag to a task, either by tag ID or by name
+// (creating the tag if it does not exist yet)
+func (h *TaskHandler) AttachTag(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse task ID from URL
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var input struct {
+		TagID string `json:"tag_id"`
+		Name  string `json:"name"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var tagID uuid.UUID
+	switch {
+	case input.TagID != "":
+		if tagID, err = uuid.Parse(input.TagID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+			return
+		}
+	case input.Name != "":
+		// Make sure the task exists before creating a tag for it
+		if _, err := h.taskService.GetTaskByID(taskID, userID.(uuid.UUID)); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		tag, err := h.tagService.FindOrCreateTag(userID.(uuid.UUID), input.Name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		tagID = tag.ID
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tag_id or name is required"})
+		return
+	}
+
+	task, err := h.taskService.AttachTag(taskID, userID.(uuid.UUID), tagID)
+	if err != nil {
+		c.JSON(taskErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag attached successfully",
+		"task":    task,
+	})
+}
+
+// DetachTag handles removing a tag from a task
+func (h *TaskHandler) DetachTag(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse task and tag IDs from URL
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	task, err := h.taskService.DetachTag(taskID, userID.(uuid.UUID), tagID)
+	if err != nil {
+		c.JSON(taskErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag detached successfully",
+		"task":    task,
+	})
+}
+
+// ListOccurrences handles previewing the next due dates of a recurring task
+func (h *TaskHandler) ListOccurrences(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse task ID from URL
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", "5"))
+	if err != nil || count < 1 || count > services.MaxPreviewOccurrences {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "count must be between 1 and " + strconv.Itoa(services.MaxPreviewOccurrences)})
+		return
+	}
+
+	occurrences, err := h.taskService.PreviewOccurrences(taskID, userID.(uuid.UUID), count)
+	if err != nil {
+		c.JSON(taskErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"occurrences": occurrences,
+	})
+}
+
+// ListDependencies handles listing the tasks a task is blocked by and the tasks it blocks
+func (h *TaskHandler) ListDependencies(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse task ID from URL
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	dependencies, err := h.taskService.GetDependencies(taskID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(taskErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dependencies": dependencies,
+	})
+}
+
+// AddDependency handles marking a task as blocked by another task
+func (h *TaskHandler) AddDependency(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse task ID from URL
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var input struct {
+		BlockedByID string `json:"blocked_by_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	blockedByID, err := uuid.Parse(input.BlockedByID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid blocking task ID"})
+		return
+	}
+
+	dependencies, err := h.taskService.AddDependency(taskID, userID.(uuid.UUID), blockedByID)
+	if err != nil {
+		c.JSON(taskErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"add_dependency",
+		"task",
+		taskID,
+		"Task blocked by: "+blockedByID.String(),
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Dependency added successfully",
+		"dependencies": dependencies,
+	})
+}
+
+// RemoveDependency handles removing a blocked-by edge from a task
+func (h *TaskHandler) RemoveDependency(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse task and blocking task IDs from URL
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	blockedByID, err := uuid.Parse(c.Param("blockerId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid blocking task ID"})
+		return
+	}
+
+	dependencies, err := h.taskService.RemoveDependency(taskID, userID.(uuid.UUID), blockedByID)
+	if err != nil {
+		c.JSON(taskErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"remove_dependency",
+		"task",
+		taskID,
+		"Task no longer blocked by: "+blockedByID.String(),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Dependency removed successfully",
+		"dependencies": dependencies,
+	})
+}
+
+// parseTaskFilter builds a task filter from the list query parameters
+func parseTaskFilter(c *gin.Context, userID uuid.UUID) (repository.TaskFilter, error) {
+	filter := repository.TaskFilter{
+		UserID:   userID,
+		Status:   c.Query("status"),
+		Priority: -1, // Default value to indicate no filter
+		Limit:    10, // Default limit
+		Offset:   0,  // Default offset
+	}
+
+	if priorityParam := c.Query("priority"); priorityParam != "" {
+		if parsedPriority, err := strconv.Atoi(priorityParam); err == nil {
+			filter.Priority = parsedPriority
+		}
+	}
+
+	if limitParam := c.Query("limit"); limitParam != "" {
+		if parsedLimit, err := strconv.Atoi(limitParam); err == nil && parsedLimit > 0 {
+			filter.Limit = parsedLimit
+		}
+	}
+
+	if offsetParam := c.Query("offset"); offsetParam != "" {
+		if parsedOffset, err := strconv.Atoi(offsetParam); err == nil && parsedOffset >= 0 {
+			filter.Offset = parsedOffset
+		}
+	}
+
+	if tagsParam := c.Query("tags"); tagsParam != "" {
+		for _, name := range strings.Split(tagsParam, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				filter.Tags = append(filter.Tags, name)
+			}
+		}
+	}
+
+	if projectParam := c.Query("project_id"); projectParam != "" {
+		projectID, err := uuid.Parse(projectParam)
+		if err != nil {
+			return filter, errors.New("invalid project ID")
+		}
+		filter.ProjectID = &projectID
+	}
+
+	if includeArchived, err := strconv.ParseBool(c.DefaultQuery("include_archived", "false")); err == nil {
+		filter.IncludeArchived = includeArchived
+	}
+
+	if blockedParam := c.Query("blocked"); blockedParam != "" {
+		blocked, err := strconv.ParseBool(blockedParam)
+		if err != nil {
+			return filter, errors.New("blocked must be true or false")
+		}
+		filter.Blocked = &blocked
+	}
+
+	switch c.DefaultQuery("tag_match", "any") {
+	case "any":
+		filter.MatchAllTags = false
+	case "all":
+		filter.MatchAllTags = true
+	default:
+		return filter, errors.New("tag_match must be any or all")
+	}
+
+	return filter, nil
+}
+
+// parseTaskScope reads the workspace_id, assignee and shared_with_me list
+// parameters into a filter. assignee is "me" or a user ID; another user's
+// assignments can only be listed within a workspace.
+func parseTaskScope(c *gin.Context, filter *repository.TaskFilter) error {
+	if workspaceParam := c.Query("workspace_id"); workspaceParam != "" {
+		workspaceID, err := uuid.Parse(workspaceParam)
+		if err != nil {
+			return errors.New("invalid workspace ID")
+		}
+		filter.WorkspaceID = &workspaceID
+	}
+
+	if assigneeParam := c.Query("assignee"); assigneeParam != "" {
+		assigneeID := filter.UserID
+		if assigneeParam != "me" {
+			parsed, err := uuid.Parse(assigneeParam)
+			if err != nil {
+				return errors.New("assignee must be me or a user ID")
+			}
+			assigneeID = parsed
+		}
+		if filter.WorkspaceID == nil && assigneeID != filter.UserID {
+			return errors.New("assignee other than me requires workspace_id")
+		}
+		filter.AssigneeID = &assigneeID
+	}
+
+	if sharedParam := c.Query("shared_with_me"); sharedParam != "" {
+		shared, err := strconv.ParseBool(sharedParam)
+		if err != nil {
+			return errors.New("shared_with_me must be true or false")
+		}
+		if shared && (filter.WorkspaceID != nil || filter.AssigneeID != nil) {
+			return errors.New("shared_with_me cannot be combined with workspace_id or assignee")
+		}
+		filter.SharedWithMe = shared
+	}
+
+	return nil
+}
+
+// parseOptionalUUID parses an optional UUID from a request body. A nil value
+// stays nil and an empty string becomes uuid.Nil, meaning "clear".
+func parseOptionalUUID(value *string) (*uuid.UUID, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if *value == "" {
+		return &uuid.Nil, nil
+	}
+
+	id, err := uuid.Parse(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// taskErrorStatus maps task service errors to HTTP status codes
+func taskErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound), errors.Is(err, services.ErrTagNotFound),
+		errors.Is(err, services.ErrDependencyNotFound), errors.Is(err, services.ErrWorkspaceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrProjectNotFound), errors.Is(err, services.ErrProjectArchived),
+		errors.Is(err, services.ErrParentNotFound), errors.Is(err, services.ErrTaskCycle),
+		errors.Is(err, services.ErrTaskTooDeep), errors.Is(err, services.ErrDependencyCycle),
+		errors.Is(err, recurrence.ErrInvalidRule), errors.Is(err, services.ErrTaskNotRecurring),
+		errors.Is(err, services.ErrProjectInWorkspace), errors.Is(err, services.ErrInvalidAssignee):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrWorkspaceForbidden), errors.Is(err, services.ErrShareReadOnly),
+		errors.Is(err, services.ErrShareOwnerOnly):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrDependencyExists), errors.Is(err, services.ErrTaskBlocked):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -11,12 +12,16 @@ import (
 
 // UserHandler handles user-related requests
 type UserHandler struct {
-	userService *services.UserService
+	userService    *services.UserService
+	accountService *services.AccountService
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(userService *services.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService *services.UserService, accountService *services.AccountService) *UserHandler {
+	return &UserHandler{
+		userService:    userService,
+		accountService: accountService,
+	}
 }
 
 // Register handles user registration
@@ -39,6 +44,11 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// Send the verification email; the user can ask for another one
+	if err := h.accountService.SendVerification(c.Request.Context(), user); err != nil {
+		// Just log the error, don't fail the registration
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "User registered successfully",
 		"user": gin.H{
@@ -68,13 +78,15 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"user": gin.H{
-			"id":         user.ID,
-			"email":      user.Email,
-			"first_name": user.FirstName,
-			"last_name":  user.LastName,
-			"is_active":  user.IsActive,
-			"created_at": user.CreatedAt,
-			"updated_at": user.UpdatedAt,
+			"id":                 user.ID,
+			"email":              user.Email,
+			"first_name":         user.FirstName,
+			"last_name":          user.LastName,
+			"is_active":          user.IsActive,
+			"email_verified_at":  user.EmailVerifiedAt,
+			"two_factor_enabled": user.TwoFactorEnabled(),
+			"created_at":         user.CreatedAt,
+			"updated_at":         user.UpdatedAt,
 		},
 	})
 }
@@ -116,6 +128,130 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	})
 }
 
+// ChangePassword handles changing the current user's password. Other
+// sessions end and a new token pair is returned for this one.
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required,min=6"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.accountService.ChangePassword(c.Request.Context(), userID.(uuid.UUID), input.CurrentPassword, input.NewPassword)
+	if err != nil {
+		respondAccountError(c, err, "Failed to change password")
+		return
+	}
+
+	// Log activity
+	if err := h.userService.LogActivity(userID.(uuid.UUID), "change_password", "user", userID.(uuid.UUID), "Password changed"); err != nil {
+		// Just log the error, don't fail the change
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Password changed successfully",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// ChangeEmail handles changing the current user's email address. The new
+// address takes effect once it is verified.
+func (h *UserHandler) ChangeEmail(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.accountService.ChangeEmail(c.Request.Context(), userID.(uuid.UUID), input.Password, input.Email)
+	if err != nil {
+		respondAccountError(c, err, "Failed to change email")
+		return
+	}
+
+	// Log activity
+	if err := h.userService.LogActivity(user.ID, "change_email", "user", user.ID, "Email change requested"); err != nil {
+		// Just log the error, don't fail the change
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":       "Verification email sent to the new address",
+		"email":         user.Email,
+		"pending_email": user.PendingEmail,
+	})
+}
+
+// DeleteAccount handles deleting the current user's account
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input struct {
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.DeleteAccount(c.Request.Context(), userID.(uuid.UUID), input.Password); err != nil {
+		respondAccountError(c, err, "Failed to delete account")
+		return
+	}
+
+	// Log activity; it is purged with the rest of the account
+	if err := h.userService.LogActivity(userID.(uuid.UUID), "delete", "user", userID.(uuid.UUID), "Account deleted"); err != nil {
+		// Just log the error, don't fail the deletion
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
+
+// respondAccountError responds to a failed change of credentials or account
+func respondAccountError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrWrongPassword):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEmailTaken), errors.Is(err, services.ErrOwnsWorkspaces):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEmailUnchanged):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMailUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
 // GetActivities handles getting the current user's activities
 func (h *UserHandler) GetActivities(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
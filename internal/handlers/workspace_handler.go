@@ -0,0 +1,569 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+)
+
+// WorkspaceHandler handles workspace, member and invitation requests
+type WorkspaceHandler struct {
+	workspaceService *services.WorkspaceService
+	userService      *services.UserService
+}
+
+// NewWorkspaceHandler creates a new workspace handler
+func NewWorkspaceHandler(workspaceService *services.WorkspaceService, userService *services.UserService) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		workspaceService: workspaceService,
+		userService:      userService,
+	}
+}
+
+// Create handles creating a workspace owned by the current user
+func (h *WorkspaceHandler) Create(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input struct {
+		Name string `json:"name" binding:"required,max=100"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := h.workspaceService.CreateWorkspace(userID.(uuid.UUID), input.Name)
+	if err != nil {
+		respondWorkspaceError(c, err, "Failed to create workspace")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"create",
+		"workspace",
+		workspace.ID,
+		"Workspace created: "+workspace.Name,
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Workspace created successfully",
+		"workspace": workspace,
+	})
+}
+
+// List handles listing the workspaces the current user is a member of
+func (h *WorkspaceHandler) List(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	workspaces, err := h.workspaceService.GetWorkspaces(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workspaces": workspaces,
+	})
+}
+
+// GetByID handles getting a workspace by ID
+func (h *WorkspaceHandler) GetByID(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse workspace ID from URL
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	workspace, err := h.workspaceService.GetWorkspace(workspaceID, userID.(uuid.UUID))
+	if err != nil {
+		respondWorkspaceError(c, err, "Failed to get workspace")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workspace": workspace,
+	})
+}
+
+// Update handles renaming a workspace
+func (h *WorkspaceHandler) Update(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse workspace ID from URL
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var input struct {
+		Name string `json:"name" binding:"required,max=100"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := h.workspaceService.UpdateWorkspace(workspaceID, userID.(uuid.UUID), input.Name)
+	if err != nil {
+		respondWorkspaceError(c, err, "Failed to update workspace")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"update",
+		"workspace",
+		workspace.ID,
+		"Workspace updated: "+workspace.Name,
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Workspace updated successfully",
+		"workspace": workspace,
+	})
+}
+
+// Delete handles deleting a workspace with its tasks
+func (h *WorkspaceHandler) Delete(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse workspace ID from URL
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	if err := h.workspaceService.DeleteWorkspace(workspaceID, userID.(uuid.UUID)); err != nil {
+		respondWorkspaceError(c, err, "Failed to delete workspace")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"delete",
+		"workspace",
+		workspaceID,
+		"Workspace deleted",
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Workspace deleted successfully",
+	})
+}
+
+// ListMembers handles listing the members of a workspace
+func (h *WorkspaceHandler) ListMembers(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse workspace ID from URL
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	members, err := h.workspaceService.GetMembers(workspaceID, userID.(uuid.UUID))
+	if err != nil {
+		respondWorkspaceError(c, err, "Failed to list members")
+		return
+	}
+
+	response := make([]gin.H, 0, len(members))
+	for i := range members {
+		response = append(response, memberResponse(&members[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"members": response,
+	})
+}
+
+// UpdateMember handles changing the role of a member
+func (h *WorkspaceHandler) UpdateMember(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse workspace and member IDs from URL
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.workspaceService.UpdateMemberRole(workspaceID, userID.(uuid.UUID), memberID, input.Role)
+	if err != nil {
+		respondWorkspaceError(c, err, "Failed to update member")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"update",
+		"workspace_member",
+		memberID,
+		"Workspace role changed to "+member.Role,
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member updated successfully",
+		"member":  memberResponse(member),
+	})
+}
+
+// RemoveMember handles removing a member from a workspace, or leaving it
+// when the member is the current user
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse workspace and member IDs from URL
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.workspaceService.RemoveMember(workspaceID, userID.(uuid.UUID), memberID); err != nil {
+		respondWorkspaceError(c, err, "Failed to remove member")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"delete",
+		"workspace_member",
+		memberID,
+		"Member removed from workspace",
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member removed successfully",
+	})
+}
+
+// Invite handles inviting an email address to a workspace
+func (h *WorkspaceHandler) Invite(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse workspace ID from URL
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+		Role  string `json:"role"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Role == "" {
+		input.Role = models.WorkspaceRoleMember
+	}
+
+	user, err := h.userService.GetUserByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := h.workspaceService.Invite(c.Request.Context(), workspaceID, user, input.Email, input.Role)
+	if err != nil {
+		respondWorkspaceError(c, err, "Failed to invite")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"create",
+		"workspace_invitation",
+		invitation.ID,
+		"Invited "+invitation.Email+" as "+invitation.Role,
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Invitation sent successfully",
+		"invitation": invitation,
+	})
+}
+
+// ListInvitations handles listing the pending invitations of a workspace
+func (h *WorkspaceHandler) ListInvitations(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse workspace ID from URL
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	invitations, err := h.workspaceService.GetInvitations(workspaceID, userID.(uuid.UUID))
+	if err != nil {
+		respondWorkspaceError(c, err, "Failed to list invitations")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitations": invitations,
+	})
+}
+
+// RevokeInvitation handles deleting a pending invitation of a workspace
+func (h *WorkspaceHandler) RevokeInvitation(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse workspace and invitation IDs from URL
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	invitationID, err := uuid.Parse(c.Param("invitationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	if err := h.workspaceService.RevokeInvitation(workspaceID, userID.(uuid.UUID), invitationID); err != nil {
+		respondWorkspaceError(c, err, "Failed to revoke invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitation revoked successfully",
+	})
+}
+
+// ListMyInvitations handles listing the pending invitations sent to the
+// current user's email address
+func (h *WorkspaceHandler) ListMyInvitations(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := h.userService.GetUserByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitations, err := h.workspaceService.GetUserInvitations(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitations": invitations,
+	})
+}
+
+// AcceptInvitation handles joining a workspace through an invitation sent
+// to the current user
+func (h *WorkspaceHandler) AcceptInvitation(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse invitation ID from URL
+	invitationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	user, err := h.userService.GetUserByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := h.workspaceService.AcceptInvitation(invitationID, user)
+	if err != nil {
+		respondWorkspaceError(c, err, "Failed to accept invitation")
+		return
+	}
+
+	// Log activity
+	h.userService.LogActivity(
+		userID.(uuid.UUID),
+		"join",
+		"workspace",
+		workspace.ID,
+		"Joined workspace: "+workspace.Name,
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Invitation accepted successfully",
+		"workspace": workspace,
+	})
+}
+
+// DeclineInvitation handles declining an invitation sent to the current user
+func (h *WorkspaceHandler) DeclineInvitation(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Parse invitation ID from URL
+	invitationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	user, err := h.userService.GetUserByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.workspaceService.DeclineInvitation(invitationID, user); err != nil {
+		respondWorkspaceError(c, err, "Failed to decline invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitation declined successfully",
+	})
+}
+
+// memberResponse shows a member with the public part of their profile
+func memberResponse(member *models.WorkspaceMember) gin.H {
+	response := gin.H{
+		"user_id":   member.UserID,
+		"role":      member.Role,
+		"joined_at": member.CreatedAt,
+	}
+	if member.User != nil {
+		response["email"] = member.User.Email
+		response["first_name"] = member.User.FirstName
+		response["last_name"] = member.User.LastName
+	}
+	return response
+}
+
+// respondWorkspaceError maps workspace service errors to HTTP responses
+func respondWorkspaceError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrWorkspaceNotFound), errors.Is(err, services.ErrMemberNotFound),
+		errors.Is(err, services.ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrWorkspaceForbidden), errors.Is(err, services.ErrOwnerCannotLeave):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadyMember):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidWorkspaceRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
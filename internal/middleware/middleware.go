@@ -1,13 +1,19 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/jaimesHub/golang-todo-app/internal/config"
 	"github.com/jaimesHub/golang-todo-app/internal/logger"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
 	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
 )
 
@@ -49,8 +55,31 @@ func LoggingMiddleware(log *logger.Logger) gin.HandlerFunc {
 	}
 }
 
-// AuthMiddleware verifies JWT token
-func AuthMiddleware(jwtService *auth.JWTService, log *logger.Logger) gin.HandlerFunc {
+// TokenAuthenticator resolves personal access tokens;
+// *services.APITokenService implements it
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, token string) (*models.APIToken, error)
+}
+
+// UserLoader loads the user a request is authenticated as;
+// *services.UserService implements it
+type UserLoader interface {
+	GetUserByID(id uuid.UUID) (*models.User, error)
+}
+
+// PermissionChecker resolves the permissions of roles;
+// *services.RoleService implements it
+type PermissionChecker interface {
+	HasPermission(role, permission string) (bool, error)
+}
+
+// AuthMiddleware verifies JWT tokens and rejects tokens on the denylist. The
+// denylist may be nil, in which case revoked tokens stay valid until they
+// expire. Personal access tokens are accepted too when apiTokens is set;
+// their scopes are stored in the context for RequireScope. When users is
+// set, requests of deactivated users are refused and the user is stored in
+// the context for RequirePermission.
+func AuthMiddleware(jwtService *auth.JWTService, denylist auth.Denylist, apiTokens TokenAuthenticator, users UserLoader, log *logger.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -79,34 +108,236 @@ func AuthMiddleware(jwtService *auth.JWTService, log *logger.Logger) gin.Handler
 		// Extract token
 		tokenString := parts[1]
 
-		// Validate token
-		claims, err := jwtService.ValidateToken(tokenString)
+		// Personal access tokens are opaque and carry a prefix JWTs never start with
+		if apiTokens != nil && strings.HasPrefix(tokenString, services.APITokenPrefix) {
+			if !authenticateAPIToken(c, apiTokens, tokenString, log) {
+				return
+			}
+		} else if !authenticateJWT(c, jwtService, denylist, tokenString, log) {
+			return
+		}
+
+		if users != nil && !loadUser(c, users, log) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authenticateJWT authenticates a request with an access token, reporting
+// whether it may go on
+func authenticateJWT(c *gin.Context, jwtService *auth.JWTService, denylist auth.Denylist, tokenString string, log *logger.Logger) bool {
+	// Validate token
+	claims, err := jwtService.ValidateToken(tokenString)
+	if err != nil {
+		log.Warn("Invalid or expired token", map[string]interface{}{
+			"path":      c.Request.URL.Path,
+			"client_ip": c.ClientIP(),
+			"error":     err.Error(),
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return false
+	}
+
+	// Reject tokens revoked by logging out
+	if denylist != nil {
+		denied, err := denylist.IsDenied(c.Request.Context(), claims)
 		if err != nil {
-			log.Warn("Invalid or expired token", map[string]interface{}{
+			log.Error("Failed to check token denylist", map[string]interface{}{
+				"path":  c.Request.URL.Path,
+				"error": err.Error(),
+			})
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Authentication is temporarily unavailable"})
+			c.Abort()
+			return false
+		}
+
+		if denied {
+			log.Warn("Revoked token", map[string]interface{}{
 				"path":      c.Request.URL.Path,
 				"client_ip": c.ClientIP(),
-				"error":     err.Error(),
+				"user_id":   claims.UserID,
 			})
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
-			return
+			return false
 		}
+	}
+
+	// Set user ID and claims in context
+	c.Set("userID", claims.UserID)
+	c.Set("claims", claims)
 
-		// Set user ID in context
-		c.Set("userID", claims.UserID)
+	log.Debug("User authenticated", map[string]interface{}{
+		"user_id":   claims.UserID,
+		"path":      c.Request.URL.Path,
+		"client_ip": c.ClientIP(),
+	})
+
+	return true
+}
+
+// authenticateAPIToken authenticates a request with a personal access
+// token, reporting whether it may go on
+func authenticateAPIToken(c *gin.Context, apiTokens TokenAuthenticator, tokenString string, log *logger.Logger) bool {
+	token, err := apiTokens.AuthenticateToken(c.Request.Context(), tokenString)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIToken) {
+			log.Warn("Invalid or expired API token", map[string]interface{}{
+				"path":      c.Request.URL.Path,
+				"client_ip": c.ClientIP(),
+			})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		} else {
+			log.Error("Failed to check API token", map[string]interface{}{
+				"path":  c.Request.URL.Path,
+				"error": err.Error(),
+			})
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Authentication is temporarily unavailable"})
+		}
+		c.Abort()
+		return false
+	}
+
+	// Set user ID and the token's scopes in context
+	c.Set("userID", token.UserID)
+	c.Set("apiToken", token)
+	c.Set("scopes", []string(token.Scopes))
+
+	log.Debug("API token authenticated", map[string]interface{}{
+		"user_id":   token.UserID,
+		"token_id":  token.ID,
+		"path":      c.Request.URL.Path,
+		"client_ip": c.ClientIP(),
+	})
+
+	return true
+}
+
+// loadUser loads the authenticated user and refuses deleted and deactivated
+// users, reporting whether the request may go on
+func loadUser(c *gin.Context, users UserLoader, log *logger.Logger) bool {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	user, err := users.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			log.Warn("Token of a deleted user", map[string]interface{}{
+				"path":    c.Request.URL.Path,
+				"user_id": userID,
+			})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		} else {
+			log.Error("Failed to load user", map[string]interface{}{
+				"path":  c.Request.URL.Path,
+				"error": err.Error(),
+			})
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Authentication is temporarily unavailable"})
+		}
+		c.Abort()
+		return false
+	}
 
-		log.Debug("User authenticated", map[string]interface{}{
-			"user_id":   claims.UserID,
+	if !user.IsActive {
+		log.Warn("Request of a deactivated user", map[string]interface{}{
 			"path":      c.Request.URL.Path,
 			"client_ip": c.ClientIP(),
+			"user_id":   userID,
 		})
+		c.JSON(http.StatusForbidden, gin.H{"error": services.ErrAccountDisabled.Error()})
+		c.Abort()
+		return false
+	}
+
+	c.Set("user", user)
+	return true
+}
+
+// RequireScope limits requests authenticated with a personal access token
+// to tokens with the read scope of a resource for safe methods and its
+// write scope otherwise. Sessions are not limited.
+func RequireScope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, limited := c.Get("scopes")
+		if !limited {
+			c.Next()
+			return
+		}
+
+		scope := auth.ScopeFor(resource, c.Request.Method)
+		if !auth.HasScope(scopes.([]string), scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Token lacks the " + scope + " scope"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSession refuses personal access tokens, for routes that manage
+// credentials and sessions
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isToken := c.Get("apiToken"); isToken {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This endpoint cannot be used with an API token"})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
 }
 
-// Setup configures middleware for the router
-func Setup(router *gin.Engine, cfg *config.Config, jwtService *auth.JWTService, log *logger.Logger) {
+// RequirePermission limits a route to users whose role grants a
+// permission. It must run after AuthMiddleware with a UserLoader.
+func RequirePermission(checker PermissionChecker, permission string, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+		user := value.(*models.User)
+
+		allowed, err := checker.HasPermission(user.Role, permission)
+		if err != nil {
+			log.Error("Failed to check permission", map[string]interface{}{
+				"path":       c.Request.URL.Path,
+				"permission": permission,
+				"error":      err.Error(),
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			c.Abort()
+			return
+		}
+
+		if !allowed {
+			log.Warn("Permission denied", map[string]interface{}{
+				"path":       c.Request.URL.Path,
+				"user_id":    user.ID,
+				"role":       user.Role,
+				"permission": permission,
+			})
+			c.JSON(http.StatusForbidden, gin.H{"error": "Missing the " + permission + " permission"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Setup configures middleware for the router. Client IPs are only taken
+// from forwarding headers sent by the configured trusted proxies.
+func Setup(router *gin.Engine, cfg *config.Config, jwtService *auth.JWTService, log *logger.Logger) error {
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
 	// Add CORS middleware
 	router.Use(corsMiddleware())
 
@@ -115,6 +346,7 @@ func Setup(router *gin.Engine, cfg *config.Config, jwtService *auth.JWTService,
 
 	// Add recovery middleware with logging
 	router.Use(RecoveryMiddleware(log))
+	return nil
 }
 
 // corsMiddleware handles Cross-Origin Resource Sharing
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,15 +12,21 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Email     string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
-	Password  string         `gorm:"type:varchar(255);not null" json:"-"`
-	FirstName string         `gorm:"type:varchar(100)" json:"first_name"`
-	LastName  string         `gorm:"type:varchar(100)" json:"last_name"`
-	IsActive  bool           `gorm:"default:true" json:"is_active"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Email           string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
+	Password        string         `gorm:"type:varchar(255);not null" json:"-"`
+	FirstName       string         `gorm:"type:varchar(100)" json:"first_name"`
+	LastName        string         `gorm:"type:varchar(100)" json:"last_name"`
+	IsActive        bool           `gorm:"default:true" json:"is_active"`
+	Role            string         `gorm:"type:varchar(50);not null;default:'user';index" json:"role"` // built-in user or admin, or a custom role
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"`
+	PendingEmail    *string        `gorm:"type:varchar(255)" json:"pending_email,omitempty"` // awaiting verification
+	TOTPSecret      *string        `gorm:"column:totp_secret;type:varchar(64)" json:"-"`     // base32, set on enrollment
+	TOTPEnabledAt   *time.Time     `gorm:"column:totp_enabled_at" json:"totp_enabled_at"`    // set once enrollment is confirmed
+	TOTPLastCounter int64          `gorm:"column:totp_last_counter;default:0" json:"-"`      // time step of the last accepted code
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
 	Tasks      []Task     `gorm:"foreignKey:UserID" json:"-"`
@@ -26,16 +35,272 @@ type User struct {
 
 // Task represents a todo task
 type Task struct {
-	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Title       string         `gorm:"type:varchar(255);not null" json:"title"`
-	Description string         `gorm:"type:text" json:"description"`
-	Status      string         `gorm:"type:varchar(50);default:'pending'" json:"status"` // pending, in_progress, completed
-	Priority    int            `gorm:"default:0" json:"priority"`                        // 0: low, 1: medium, 2: high
-	DueDate     *time.Time     `json:"due_date"`
-	UserID      uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Title           string         `gorm:"type:varchar(255);not null" json:"title"`
+	Description     string         `gorm:"type:text" json:"description"`
+	Status          string         `gorm:"type:varchar(50);default:'pending'" json:"status"` // pending, in_progress, completed
+	Priority        int            `gorm:"default:0" json:"priority"`                        // 0: low, 1: medium, 2: high
+	DueDate         *time.Time     `json:"due_date"`
+	ReminderMinutes *int           `json:"reminder_minutes"` // minutes before DueDate to send a reminder
+	UserID          uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
+	WorkspaceID     *uuid.UUID     `gorm:"type:uuid;index" json:"workspace_id"` // nil for personal tasks
+	AssigneeID      *uuid.UUID     `gorm:"type:uuid;index" json:"assignee_id"`
+	ProjectID       *uuid.UUID     `gorm:"type:uuid;index" json:"project_id"`
+	ParentID        *uuid.UUID     `gorm:"type:uuid;index" json:"parent_id"`
+	Recurrence      string         `gorm:"type:varchar(255)" json:"recurrence"` // RRULE-style rule, e.g. FREQ=WEEKLY;BYDAY=MO
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Tags []Tag `gorm:"many2many:task_tags" json:"tags"`
+
+	// NextOccurrence is the task generated when a recurring task is completed
+	NextOccurrence *Task `gorm:"-" json:"next_occurrence,omitempty"`
+}
+
+// Tag represents a user-defined label that can be attached to tasks
+type Tag struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_tags_user_id_name" json:"user_id"`
+	Name      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_tags_user_id_name" json:"name"`
+	Color     string    `gorm:"type:varchar(7)" json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Project represents a list that groups a user's tasks
+type Project struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
+	Color     string         `gorm:"type:varchar(7)" json:"color"`
+	Archived  bool           `gorm:"default:false" json:"archived"`
+	Position  int            `gorm:"default:0" json:"position"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TaskDependency records that a task cannot start until another task is completed
+type TaskDependency struct {
+	TaskID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"task_id"`
+	BlockedByID uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"blocked_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RefreshToken is a single-use refresh token. Tokens issued by rotating one
+// another share a family, so reuse of a rotated token revokes the whole chain.
+type RefreshToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	FamilyID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"` // hex SHA-256 of the token
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`    // set when the token is rotated
+	RevokedAt *time.Time `json:"revoked_at"` // set on logout or reuse
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// User token purposes
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+	TokenPurposeChangeEmail   = "change_email"
+
+	// TokenPurposeLoginChallenge tokens are handed out instead of a session
+	// when a password is correct but a second factor is still required
+	TokenPurposeLoginChallenge = "login_challenge"
+)
+
+// UserToken is a single-use token sent to a user by email, such as an email
+// verification or password reset link
+type UserToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Purpose   string     `gorm:"type:varchar(32);not null" json:"purpose"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"` // hex SHA-256 of the token
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Built-in roles. Every user has the user role unless given another one;
+// admins have every permission.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Role is a custom role granting a set of admin permissions
+type Role struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string     `gorm:"type:varchar(50);uniqueIndex;not null" json:"name"`
+	Description string     `gorm:"type:varchar(255)" json:"description"`
+	Permissions StringList `gorm:"type:text;not null" json:"permissions"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Builtin is set on the user and admin roles, which are not stored
+	Builtin bool `gorm:"-" json:"builtin"`
+}
+
+// Workspace member roles, from least to most privileged. Viewers can read
+// tasks, members can also edit them, admins manage members and the owner
+// can also delete the workspace.
+const (
+	WorkspaceRoleViewer = "viewer"
+	WorkspaceRoleMember = "member"
+	WorkspaceRoleAdmin  = "admin"
+	WorkspaceRoleOwner  = "owner"
+)
+
+// Workspace is a team whose members share tasks
+type Workspace struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Role is the role of the user the workspace was loaded for
+	Role string `gorm:"-" json:"role,omitempty"`
+}
+
+// WorkspaceMember gives a user a role in a workspace
+type WorkspaceMember struct {
+	WorkspaceID uuid.UUID `gorm:"type:uuid;primaryKey" json:"workspace_id"`
+	UserID      uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	Role        string    `gorm:"type:varchar(20);not null" json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// User is loaded when listing members
+	User *User `gorm:"-" json:"user,omitempty"`
+}
+
+// WorkspaceInvitation invites an email address to join a workspace. It is
+// accepted or declined by the user with that email.
+type WorkspaceInvitation struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	WorkspaceID uuid.UUID  `gorm:"type:uuid;not null;index" json:"workspace_id"`
+	Email       string     `gorm:"type:varchar(255);not null;index" json:"email"` // lowercased
+	Role        string     `gorm:"type:varchar(20);not null" json:"role"`
+	InvitedByID uuid.UUID  `gorm:"type:uuid;not null" json:"invited_by_id"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	DeclinedAt  *time.Time `json:"declined_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Workspace is loaded when listing the invitations of a user
+	Workspace *Workspace `gorm:"-" json:"workspace,omitempty"`
+}
+
+// Pending reports whether an invitation can still be accepted or declined
+func (i *WorkspaceInvitation) Pending(now time.Time) bool {
+	return i.AcceptedAt == nil && i.DeclinedAt == nil && now.Before(i.ExpiresAt)
+}
+
+// Share permissions. Read lets the user see a task, edit also lets them
+// change its fields; only the owner moves, tags or deletes it.
+const (
+	SharePermissionRead = "read"
+	SharePermissionEdit = "edit"
+)
+
+// Share gives another user access to a personal task, or to every task of a
+// personal project. Exactly one of TaskID and ProjectID is set.
+type Share struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID     *uuid.UUID `gorm:"type:uuid;index" json:"task_id,omitempty"`
+	ProjectID  *uuid.UUID `gorm:"type:uuid;index" json:"project_id,omitempty"`
+	OwnerID    uuid.UUID  `gorm:"type:uuid;not null" json:"owner_id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Permission string     `gorm:"type:varchar(10);not null" json:"permission"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// User is loaded when listing the shares of a task or project
+	User *User `gorm:"-" json:"user,omitempty"`
+}
+
+// Comment is a message on a task by a user who can see the task
+type Comment struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"task_id"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	Body      string         `gorm:"type:text;not null" json:"body"`
+	Mentions  StringList     `gorm:"type:text;not null;default:''" json:"mentions"` // lowercased emails of the mentioned users who were notified
+	EditedAt  *time.Time     `json:"edited_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// User is the author, loaded when listing the comments of a task
+	User *User `gorm:"-" json:"user,omitempty"`
+}
+
+// Attachment is a file uploaded to a task. Its content lives in the
+// configured storage under StorageKey.
+type Attachment struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID      uuid.UUID `gorm:"type:uuid;not null;index" json:"task_id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"` // the uploader
+	FileName    string    `gorm:"type:varchar(255);not null" json:"file_name"`
+	ContentType string    `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	StorageKey  string    `gorm:"type:varchar(255);not null" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RecoveryCode is a single-use code that stands in for a TOTP code when the
+// authenticator is lost
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(64);not null" json:"-"` // hex SHA-256 of the normalized code
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// APIToken is a long-lived personal access token for scripts and CI. Its
+// access is limited to its scopes.
+type APIToken struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"`        // start of the token, to tell tokens apart
+	TokenHash  string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"` // hex SHA-256 of the token
+	Scopes     StringList `gorm:"type:varchar(255);not null" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"` // nil for tokens that do not expire
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// StringList is a list of words such as scopes or permissions, stored as a
+// space-separated string
+type StringList []string
+
+// Value implements driver.Valuer
+func (s StringList) Value() (driver.Value, error) {
+	return strings.Join(s, " "), nil
+}
+
+// Scan implements sql.Scanner
+func (s *StringList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*s = strings.Fields(v)
+	case []byte:
+		*s = strings.Fields(string(v))
+	case nil:
+		*s = nil
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+	return nil
 }
 
 // Activity represents a user activity log
@@ -57,6 +322,11 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// TwoFactorEnabled reports whether logins need a TOTP code after the password
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
 // BeforeCreate is a GORM hook that runs before creating a record
 func (t *Task) BeforeCreate(tx *gorm.DB) error {
 	if t.ID == uuid.Nil {
@@ -65,6 +335,22 @@ func (t *Task) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BeforeCreate is a GORM hook that runs before creating a record
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (p *Project) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
 // BeforeCreate is a GORM hook that runs before creating a record
 func (a *Activity) BeforeCreate(tx *gorm.DB) error {
 	if a.ID == uuid.Nil {
@@ -72,3 +358,83 @@ func (a *Activity) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (t *UserToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (c *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (t *APIToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (w *Workspace) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (i *WorkspaceInvitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (s *Share) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (c *Comment) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (a *Attachment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (r *Role) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
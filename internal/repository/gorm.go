@@ -0,0 +1,1363 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewGormRepositories creates repositories backed by a GORM (Postgres) connection
+func NewGormRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Tasks:        &GormTaskRepository{db: db},
+		Users:        &GormUserRepository{db: db},
+		Activities:   &GormActivityRepository{db: db},
+		Tags:         &GormTagRepository{db: db},
+		Projects:     &GormProjectRepository{db: db},
+		Dependencies: &GormDependencyRepository{db: db},
+		Tokens:       &GormRefreshTokenRepository{db: db},
+		UserTokens:   &GormUserTokenRepository{db: db},
+		Recovery:     &GormRecoveryCodeRepository{db: db},
+		APITokens:    &GormAPITokenRepository{db: db},
+		Roles:        &GormRoleRepository{db: db},
+		Workspaces:   &GormWorkspaceRepository{db: db},
+		Invitations:  &GormInvitationRepository{db: db},
+		Shares:       &GormShareRepository{db: db},
+		Comments:     &GormCommentRepository{db: db},
+		Attachments:  &GormAttachmentRepository{db: db},
+	}
+}
+
+// notFound translates GORM's missing record error into ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// GormTaskRepository is a TaskRepository backed by GORM
+type GormTaskRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new task
+func (r *GormTaskRepository) Create(task *models.Task) error {
+	return r.db.Omit(clause.Associations).Create(task).Error
+}
+
+// FindByID retrieves a task by ID
+func (r *GormTaskRepository) FindByID(id uuid.UUID) (*models.Task, error) {
+	var task models.Task
+	if err := r.db.Preload("Tags").Where("id = ?", id).First(&task).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &task, nil
+}
+
+// ListChildren retrieves the direct subtasks of a task, oldest first
+func (r *GormTaskRepository) ListChildren(parentID uuid.UUID) ([]models.Task, error) {
+	var tasks []models.Task
+	if err := r.db.Preload("Tags").Where("parent_id = ?", parentID).Order("created_at").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// List retrieves tasks matching the filter, ordered by priority (high to low) and created_at (newest first)
+func (r *GormTaskRepository) List(filter TaskFilter) ([]models.Task, error) {
+	var tasks []models.Task
+
+	query := r.filtered(filter)
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	if err := query.Preload("Tags").Order("priority DESC, created_at DESC").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// Count counts tasks matching the filter, ignoring pagination
+func (r *GormTaskRepository) Count(filter TaskFilter) (int64, error) {
+	var count int64
+	if err := r.filtered(filter).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// searchHeadline marks matching words the way TaskMatch documents
+const searchHeadline = "StartSel=<mark>, StopSel=</mark>"
+
+// Search retrieves the tasks matching the filter and its Search query, best
+// match first. Matches are found through the GIN-indexed search_vector column.
+func (r *GormTaskRepository) Search(filter TaskFilter) ([]TaskMatch, error) {
+	tsquery := searchQuery(filter.Search)
+
+	var hits []struct {
+		ID             uuid.UUID
+		SearchRank     float64
+		TitleHighlight string
+		Snippet        string
+	}
+	query := r.filtered(filter).Select(
+		"id, ts_rank(search_vector, to_tsquery('simple', ?)) AS search_rank, "+
+			"ts_headline('simple', title, to_tsquery('simple', ?), ?) AS title_highlight, "+
+			"CASE WHEN to_tsvector('simple', coalesce(description, '')) @@ to_tsquery('simple', ?) "+
+			"THEN ts_headline('simple', description, to_tsquery('simple', ?), ?) ELSE '' END AS snippet",
+		tsquery, tsquery, searchHeadline+", HighlightAll=TRUE",
+		tsquery, tsquery, searchHeadline+`, MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" ... "`,
+	)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if err := query.Order("search_rank DESC, priority DESC, created_at DESC").Scan(&hits).Error; err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return []TaskMatch{}, nil
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	var tasks []models.Task
+	if err := r.db.Preload("Tags").Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	matches := make([]TaskMatch, 0, len(hits))
+	for _, hit := range hits {
+		if task, exists := byID[hit.ID]; exists {
+			matches = append(matches, TaskMatch{Task: task, Rank: hit.SearchRank, TitleHighlight: hit.TitleHighlight, Snippet: hit.Snippet})
+		}
+	}
+	return matches, nil
+}
+
+// searchQuery builds a tsquery matching every word of a search query as a
+// prefix, e.g. "fix log" becomes "fix:* & log:*"
+func searchQuery(search string) string {
+	terms := SearchTerms(search)
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// Update saves all fields of a task; associations are managed by their own repositories
+func (r *GormTaskRepository) Update(task *models.Task) error {
+	return r.db.Omit(clause.Associations).Save(task).Error
+}
+
+// Delete soft deletes a task
+func (r *GormTaskRepository) Delete(task *models.Task) error {
+	return r.db.Delete(task).Error
+}
+
+// filtered builds the base query for a task filter
+func (r *GormTaskRepository) filtered(filter TaskFilter) *gorm.DB {
+	query := r.db.Model(&models.Task{})
+
+	switch {
+	case filter.SharedWithMe:
+		sharedTasks := r.db.Model(&models.Share{}).Select("task_id").Where("user_id = ? AND task_id IS NOT NULL", filter.UserID)
+		sharedProjects := r.db.Model(&models.Share{}).Select("project_id").Where("user_id = ? AND project_id IS NOT NULL", filter.UserID)
+		query = query.Where("workspace_id IS NULL AND (id IN (?) OR project_id IN (?))", sharedTasks, sharedProjects)
+	case filter.WorkspaceID != nil:
+		query = query.Where("workspace_id = ?", *filter.WorkspaceID)
+	case filter.AssigneeID == nil:
+		query = query.Where("user_id = ? AND workspace_id IS NULL", filter.UserID)
+	}
+
+	if filter.AssigneeID != nil {
+		query = query.Where("assignee_id = ?", *filter.AssigneeID)
+	}
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.Priority >= 0 {
+		query = query.Where("priority = ?", filter.Priority)
+	}
+
+	if len(filter.Tags) > 0 {
+		tagged := r.db.Table("task_tags").
+			Select("task_tags.task_id").
+			Joins("JOIN tags ON tags.id = task_tags.tag_id").
+			Where("tags.user_id = ? AND tags.name IN ?", filter.UserID, filter.Tags)
+
+		if filter.MatchAllTags {
+			tagged = tagged.Group("task_tags.task_id").Having("COUNT(DISTINCT tags.name) = ?", len(uniqueStrings(filter.Tags)))
+		}
+
+		query = query.Where("id IN (?)", tagged)
+	}
+
+	if filter.ProjectID != nil {
+		query = query.Where("project_id = ?", *filter.ProjectID)
+	} else if !filter.IncludeArchived {
+		archived := r.db.Model(&models.Project{}).Select("id").Where("archived = ?", true)
+		query = query.Where("project_id IS NULL OR project_id NOT IN (?)", archived)
+	}
+
+	if filter.Search != "" {
+		query = query.Where("search_vector @@ to_tsquery('simple', ?)", searchQuery(filter.Search))
+	}
+
+	if filter.Blocked != nil {
+		blocked := r.db.Table("task_dependencies").
+			Select("task_dependencies.task_id").
+			Joins("JOIN tasks blockers ON blockers.id = task_dependencies.blocked_by_id").
+			Where("blockers.status <> ? AND blockers.deleted_at IS NULL", "completed")
+
+		if *filter.Blocked {
+			query = query.Where("id IN (?)", blocked)
+		} else {
+			query = query.Where("id NOT IN (?)", blocked)
+		}
+	}
+
+	return query
+}
+
+// GormUserRepository is a UserRepository backed by GORM
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new user, returning ErrDuplicate if the email is taken
+func (r *GormUserRepository) Create(user *models.User) error {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// FindByID retrieves a user by ID
+func (r *GormUserRepository) FindByID(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+// FindByEmail retrieves a user by email, ignoring case. An exact match is
+// preferred over addresses that only differ in case.
+func (r *GormUserRepository) FindByEmail(email string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("lower(email) = lower(?)", email).
+		Order(clause.Expr{SQL: "email = ? DESC", Vars: []interface{}{email}}).
+		First(&user).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+// EmailTaken reports whether any user, deleted or not, has the email,
+// ignoring case
+func (r *GormUserRepository) EmailTaken(email string) (bool, error) {
+	var count int64
+	if err := r.db.Unscoped().Model(&models.User{}).Where("lower(email) = lower(?)", email).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// List retrieves users matching the filter, newest first
+func (r *GormUserRepository) List(filter UserFilter) ([]models.User, error) {
+	var users []models.User
+
+	query := r.filtered(filter)
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	if err := query.Order("created_at DESC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Count counts users matching the filter, ignoring pagination
+func (r *GormUserRepository) Count(filter UserFilter) (int64, error) {
+	var count int64
+	if err := r.filtered(filter).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// filtered builds the base query for a user filter
+func (r *GormUserRepository) filtered(filter UserFilter) *gorm.DB {
+	query := r.db.Model(&models.User{})
+
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		query = query.Where("email ILIKE ? OR first_name ILIKE ? OR last_name ILIKE ?", pattern, pattern, pattern)
+	}
+
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+
+	if filter.Active != nil {
+		query = query.Where("is_active = ?", *filter.Active)
+	}
+
+	return query
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// Update saves all fields of a user
+func (r *GormUserRepository) Update(user *models.User) error {
+	return r.db.Save(user).Error
+}
+
+// AdvanceTOTPCounter raises the last accepted TOTP counter of a user and
+// reports whether it did
+func (r *GormUserRepository) AdvanceTOTPCounter(id uuid.UUID, counter int64, at time.Time) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_counter < ?", id, counter).
+		Updates(map[string]interface{}{"totp_last_counter": counter, "updated_at": at})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete soft-deletes a user together with their personal tasks and projects
+func (r *GormUserRepository) Delete(user *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND workspace_id IS NULL", user.ID).Delete(&models.Task{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.Project{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(user).Error
+	})
+}
+
+// Purge permanently removes the data of a soft-deleted user and anonymizes
+// the user record
+func (r *GormUserRepository) Purge(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&user).Error; err != nil {
+			return notFound(err)
+		}
+
+		// Workspaces the user is the last member of go with them; members
+		// and invitations cascade with the workspaces
+		others := tx.Model(&models.WorkspaceMember{}).Select("workspace_id").Where("user_id <> ?", id)
+		var soleIDs []uuid.UUID
+		if err := tx.Model(&models.WorkspaceMember{}).Where("user_id = ? AND workspace_id NOT IN (?)", id, others).Pluck("workspace_id", &soleIDs).Error; err != nil {
+			return err
+		}
+		if len(soleIDs) > 0 {
+			if err := tx.Unscoped().Where("workspace_id IN ?", soleIDs).Delete(&models.Task{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("id IN ?", soleIDs).Delete(&models.Workspace{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Task{}).Where("assignee_id = ?", id).Update("assignee_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("owner_id = ? OR user_id = ?", id, id).Delete(&models.Share{}).Error; err != nil {
+			return err
+		}
+
+		// Tag links, dependencies and subtasks cascade with the tasks
+		if err := tx.Unscoped().Where("user_id = ? AND workspace_id IS NULL", id).Delete(&models.Task{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.Project{}, &models.Tag{}, &models.Activity{}, &models.RefreshToken{}, &models.UserToken{}, &models.RecoveryCode{}, &models.APIToken{}, &models.Comment{}} {
+			if err := tx.Unscoped().Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Model(&user).Updates(map[string]interface{}{
+			"email":             AnonymizedEmail(id),
+			"password":          "",
+			"first_name":        "",
+			"last_name":         "",
+			"is_active":         false,
+			"role":              models.RoleUser,
+			"email_verified_at": nil,
+			"pending_email":     nil,
+			"totp_secret":       nil,
+			"totp_enabled_at":   nil,
+			"totp_last_counter": 0,
+		}).Error
+	})
+}
+
+// GormActivityRepository is an ActivityRepository backed by GORM
+type GormActivityRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new activity
+func (r *GormActivityRepository) Create(activity *models.Activity) error {
+	return r.db.Create(activity).Error
+}
+
+// ListByUser retrieves a user's activities, newest first
+func (r *GormActivityRepository) ListByUser(userID uuid.UUID, limit, offset int) ([]models.Activity, error) {
+	var activities []models.Activity
+
+	query := r.db.Where("user_id = ?", userID).Order("created_at DESC")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	if err := query.Find(&activities).Error; err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
+
+// GormTagRepository is a TagRepository backed by GORM
+type GormTagRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new tag
+func (r *GormTagRepository) Create(tag *models.Tag) error {
+	return r.db.Create(tag).Error
+}
+
+// FindByID retrieves a tag by ID
+func (r *GormTagRepository) FindByID(id uuid.UUID) (*models.Tag, error) {
+	var tag models.Tag
+	if err := r.db.First(&tag, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &tag, nil
+}
+
+// FindByName retrieves a user's tag by name
+func (r *GormTagRepository) FindByName(userID uuid.UUID, name string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&tag).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &tag, nil
+}
+
+// ListByUser retrieves a user's tags ordered by name
+func (r *GormTagRepository) ListByUser(userID uuid.UUID) ([]models.Tag, error) {
+	var tags []models.Tag
+	if err := r.db.Where("user_id = ?", userID).Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// Update saves all fields of a tag
+func (r *GormTagRepository) Update(tag *models.Tag) error {
+	return r.db.Save(tag).Error
+}
+
+// Delete removes a tag and detaches it from every task
+func (r *GormTagRepository) Delete(tag *models.Tag) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM task_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+}
+
+// Attach attaches a tag to a task; attaching twice is a no-op
+func (r *GormTagRepository) Attach(taskID, tagID uuid.UUID) error {
+	return r.db.Exec("INSERT INTO task_tags (task_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING", taskID, tagID).Error
+}
+
+// Detach removes a tag from a task
+func (r *GormTagRepository) Detach(taskID, tagID uuid.UUID) error {
+	return r.db.Exec("DELETE FROM task_tags WHERE task_id = ? AND tag_id = ?", taskID, tagID).Error
+}
+
+// GormProjectRepository is a ProjectRepository backed by GORM
+type GormProjectRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new project
+func (r *GormProjectRepository) Create(project *models.Project) error {
+	return r.db.Create(project).Error
+}
+
+// FindByID retrieves a project by ID
+func (r *GormProjectRepository) FindByID(id uuid.UUID) (*models.Project, error) {
+	var project models.Project
+	if err := r.db.First(&project, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &project, nil
+}
+
+// ListByUser retrieves a user's projects in display order
+func (r *GormProjectRepository) ListByUser(userID uuid.UUID, includeArchived bool) ([]models.Project, error) {
+	var projects []models.Project
+
+	query := r.db.Where("user_id = ?", userID)
+	if !includeArchived {
+		query = query.Where("archived = ?", false)
+	}
+
+	if err := query.Order("position, created_at").Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// Update saves all fields of a project
+func (r *GormProjectRepository) Update(project *models.Project) error {
+	return r.db.Save(project).Error
+}
+
+// Delete soft deletes a project and moves its tasks out of it
+func (r *GormProjectRepository) Delete(project *models.Project) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Task{}).Where("project_id = ?", project.ID).Update("project_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.Share{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(project).Error
+	})
+}
+
+// GormDependencyRepository is a DependencyRepository backed by GORM
+type GormDependencyRepository struct {
+	db *gorm.DB
+}
+
+// Add inserts a dependency edge, returning ErrDuplicate if it already exists
+func (r *GormDependencyRepository) Add(dependency *models.TaskDependency) error {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(dependency)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// Remove deletes a dependency edge
+func (r *GormDependencyRepository) Remove(taskID, blockedByID uuid.UUID) error {
+	result := r.db.Where("task_id = ? AND blocked_by_id = ?", taskID, blockedByID).Delete(&models.TaskDependency{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListBlockers retrieves the tasks a task is blocked by, oldest first
+func (r *GormDependencyRepository) ListBlockers(taskID uuid.UUID) ([]models.Task, error) {
+	var tasks []models.Task
+	if err := r.db.Joins("JOIN task_dependencies ON task_dependencies.blocked_by_id = tasks.id").
+		Where("task_dependencies.task_id = ?", taskID).
+		Order("tasks.created_at").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// ListDependents retrieves the tasks blocked by a task, oldest first
+func (r *GormDependencyRepository) ListDependents(taskID uuid.UUID) ([]models.Task, error) {
+	var tasks []models.Task
+	if err := r.db.Joins("JOIN task_dependencies ON task_dependencies.task_id = tasks.id").
+		Where("task_dependencies.blocked_by_id = ?", taskID).
+		Order("tasks.created_at").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GormRefreshTokenRepository is a RefreshTokenRepository backed by GORM
+type GormRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new refresh token
+func (r *GormRefreshTokenRepository) Create(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByHash retrieves a refresh token by the hash of its value
+func (r *GormRefreshTokenRepository) FindByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+// MarkUsed sets UsedAt on an unused, unrevoked token and reports whether it did
+func (r *GormRefreshTokenRepository) MarkUsed(id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeFamily revokes every token of a family
+func (r *GormRefreshTokenRepository) RevokeFamily(familyID uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+// RevokeUser revokes every token of a user
+func (r *GormRefreshTokenRepository) RevokeUser(userID uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// GormUserTokenRepository is a UserTokenRepository backed by GORM
+type GormUserTokenRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new user token
+func (r *GormUserTokenRepository) Create(token *models.UserToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByHash retrieves a user token by the hash of its value
+func (r *GormUserTokenRepository) FindByHash(hash string) (*models.UserToken, error) {
+	var token models.UserToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+// MarkUsed sets UsedAt on an unused token and reports whether it did
+func (r *GormUserTokenRepository) MarkUsed(id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ExpireUser marks the unused tokens of a user with the given purpose as used
+func (r *GormUserTokenRepository) ExpireUser(userID uuid.UUID, purpose string, at time.Time) error {
+	return r.db.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", at).Error
+}
+
+// GormRecoveryCodeRepository is a RecoveryCodeRepository backed by GORM
+type GormRecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// Replace deletes the codes of a user and stores new ones in their place
+func (r *GormRecoveryCodeRepository) Replace(userID uuid.UUID, codes []models.RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		for i := range codes {
+			codes[i].UserID = userID
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Redeem sets UsedAt on an unused code of a user and reports whether it did
+func (r *GormRecoveryCodeRepository) Redeem(userID uuid.UUID, hash string, at time.Time) (bool, error) {
+	// Codes are random, so at most one unused code of a user matches
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUnused counts the codes of a user that can still be redeemed
+func (r *GormRecoveryCodeRepository) CountUnused(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteUser deletes every code of a user
+func (r *GormRecoveryCodeRepository) DeleteUser(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+// GormAPITokenRepository is an APITokenRepository backed by GORM
+type GormAPITokenRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new API token
+func (r *GormAPITokenRepository) Create(token *models.APIToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByID retrieves an API token by ID
+func (r *GormAPITokenRepository) FindByID(id uuid.UUID) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.First(&token, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+// FindByHash retrieves an API token by the hash of its value
+func (r *GormAPITokenRepository) FindByHash(hash string) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+// ListByUser retrieves a user's API tokens, newest first
+func (r *GormAPITokenRepository) ListByUser(userID uuid.UUID) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Update saves all fields of an API token
+func (r *GormAPITokenRepository) Update(token *models.APIToken) error {
+	return r.db.Save(token).Error
+}
+
+// Delete removes an API token
+func (r *GormAPITokenRepository) Delete(token *models.APIToken) error {
+	return r.db.Delete(token).Error
+}
+
+// Touch sets LastUsedAt without changing UpdatedAt
+func (r *GormAPITokenRepository) Touch(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.APIToken{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+// GormRoleRepository is a RoleRepository backed by GORM
+type GormRoleRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new role, returning ErrDuplicate if the name is taken
+func (r *GormRoleRepository) Create(role *models.Role) error {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// FindByName retrieves a role by name
+func (r *GormRoleRepository) FindByName(name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &role, nil
+}
+
+// List retrieves every role ordered by name
+func (r *GormRoleRepository) List() ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// Update saves all fields of a role
+func (r *GormRoleRepository) Update(role *models.Role) error {
+	return r.db.Save(role).Error
+}
+
+// Delete removes a role
+func (r *GormRoleRepository) Delete(role *models.Role) error {
+	return r.db.Delete(role).Error
+}
+
+// GormWorkspaceRepository is a WorkspaceRepository backed by GORM
+type GormWorkspaceRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a workspace together with its owner
+func (r *GormWorkspaceRepository) Create(workspace *models.Workspace, owner *models.WorkspaceMember) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workspace).Error; err != nil {
+			return err
+		}
+		owner.WorkspaceID = workspace.ID
+		return tx.Create(owner).Error
+	})
+}
+
+// FindByID retrieves a workspace by ID
+func (r *GormWorkspaceRepository) FindByID(id uuid.UUID) (*models.Workspace, error) {
+	var workspace models.Workspace
+	if err := r.db.Where("id = ?", id).First(&workspace).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &workspace, nil
+}
+
+// ListByUser retrieves the workspaces a user is a member of, ordered by
+// name, with the user's role
+func (r *GormWorkspaceRepository) ListByUser(userID uuid.UUID) ([]models.Workspace, error) {
+	var members []models.WorkspaceMember
+	if err := r.db.Where("user_id = ?", userID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	workspaces := []models.Workspace{}
+	if len(members) == 0 {
+		return workspaces, nil
+	}
+
+	roles := make(map[uuid.UUID]string, len(members))
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		roles[member.WorkspaceID] = member.Role
+		ids = append(ids, member.WorkspaceID)
+	}
+
+	if err := r.db.Where("id IN ?", ids).Order("name").Find(&workspaces).Error; err != nil {
+		return nil, err
+	}
+	for i := range workspaces {
+		workspaces[i].Role = roles[workspaces[i].ID]
+	}
+	return workspaces, nil
+}
+
+// Update saves all fields of a workspace
+func (r *GormWorkspaceRepository) Update(workspace *models.Workspace) error {
+	return r.db.Save(workspace).Error
+}
+
+// Delete soft-deletes a workspace with its tasks and removes its members and
+// invitations
+func (r *GormWorkspaceRepository) Delete(workspace *models.Workspace) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("workspace_id = ?", workspace.ID).Delete(&models.Task{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workspace_id = ?", workspace.ID).Delete(&models.WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workspace_id = ?", workspace.ID).Delete(&models.WorkspaceInvitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(workspace).Error
+	})
+}
+
+// FindMember retrieves the membership of a user in a workspace
+func (r *GormWorkspaceRepository) FindMember(workspaceID, userID uuid.UUID) (*models.WorkspaceMember, error) {
+	var member models.WorkspaceMember
+	if err := r.db.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).First(&member).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &member, nil
+}
+
+// ListMembers retrieves the members of a workspace with their users, oldest first
+func (r *GormWorkspaceRepository) ListMembers(workspaceID uuid.UUID) ([]models.WorkspaceMember, error) {
+	var members []models.WorkspaceMember
+	if err := r.db.Where("workspace_id = ?", workspaceID).Order("created_at").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return members, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.UserID)
+	}
+
+	var users []models.User
+	if err := r.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	for i := range members {
+		members[i].User = byID[members[i].UserID]
+	}
+	return members, nil
+}
+
+// UpdateMember saves the role of a member
+func (r *GormWorkspaceRepository) UpdateMember(member *models.WorkspaceMember) error {
+	result := r.db.Model(&models.WorkspaceMember{}).
+		Where("workspace_id = ? AND user_id = ?", member.WorkspaceID, member.UserID).
+		Updates(map[string]interface{}{"role": member.Role, "updated_at": member.UpdatedAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TransferOwnership makes a member the owner and the owner an admin. The
+// owner's row is only demoted while it is still the owner, so of two
+// concurrent transfers the second one fails.
+func (r *GormWorkspaceRepository) TransferOwnership(workspaceID, ownerID, memberID uuid.UUID, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.WorkspaceMember{}).
+			Where("workspace_id = ? AND user_id = ? AND role = ?", workspaceID, ownerID, models.WorkspaceRoleOwner).
+			Updates(map[string]interface{}{"role": models.WorkspaceRoleAdmin, "updated_at": at})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		result = tx.Model(&models.WorkspaceMember{}).
+			Where("workspace_id = ? AND user_id = ?", workspaceID, memberID).
+			Updates(map[string]interface{}{"role": models.WorkspaceRoleOwner, "updated_at": at})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// RemoveMember removes a member and unassigns them from the tasks of the
+// workspace
+func (r *GormWorkspaceRepository) RemoveMember(workspaceID, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).Delete(&models.WorkspaceMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		return tx.Model(&models.Task{}).
+			Where("workspace_id = ? AND assignee_id = ?", workspaceID, userID).
+			Update("assignee_id", nil).Error
+	})
+}
+
+// GormInvitationRepository is an InvitationRepository backed by GORM
+type GormInvitationRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new invitation
+func (r *GormInvitationRepository) Create(invitation *models.WorkspaceInvitation) error {
+	return r.db.Create(invitation).Error
+}
+
+// FindByID retrieves an invitation by ID
+func (r *GormInvitationRepository) FindByID(id uuid.UUID) (*models.WorkspaceInvitation, error) {
+	var invitation models.WorkspaceInvitation
+	if err := r.db.Where("id = ?", id).First(&invitation).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &invitation, nil
+}
+
+// pending restricts a query to invitations that are neither answered nor expired
+func (r *GormInvitationRepository) pending(now time.Time) *gorm.DB {
+	return r.db.Where("accepted_at IS NULL AND declined_at IS NULL AND expires_at > ?", now)
+}
+
+// ListByWorkspace retrieves the pending invitations of a workspace, newest first
+func (r *GormInvitationRepository) ListByWorkspace(workspaceID uuid.UUID, now time.Time) ([]models.WorkspaceInvitation, error) {
+	var invitations []models.WorkspaceInvitation
+	err := r.pending(now).Where("workspace_id = ?", workspaceID).Order("created_at DESC").Find(&invitations).Error
+	return invitations, err
+}
+
+// ListByEmail retrieves the pending invitations of an email address with
+// their workspaces, newest first
+func (r *GormInvitationRepository) ListByEmail(email string, now time.Time) ([]models.WorkspaceInvitation, error) {
+	var invitations []models.WorkspaceInvitation
+	if err := r.pending(now).Where("email = ?", email).Order("created_at DESC").Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return invitations, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(invitations))
+	for _, invitation := range invitations {
+		ids = append(ids, invitation.WorkspaceID)
+	}
+
+	var workspaces []models.Workspace
+	if err := r.db.Where("id IN ?", ids).Find(&workspaces).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Workspace, len(workspaces))
+	for i := range workspaces {
+		byID[workspaces[i].ID] = &workspaces[i]
+	}
+	for i := range invitations {
+		invitations[i].Workspace = byID[invitations[i].WorkspaceID]
+	}
+	return invitations, nil
+}
+
+// Update saves all fields of an invitation
+func (r *GormInvitationRepository) Update(invitation *models.WorkspaceInvitation) error {
+	return r.db.Save(invitation).Error
+}
+
+// Delete removes an invitation
+func (r *GormInvitationRepository) Delete(invitation *models.WorkspaceInvitation) error {
+	return r.db.Delete(invitation).Error
+}
+
+// Accept sets AcceptedAt on a pending invitation and adds the member
+func (r *GormInvitationRepository) Accept(invitation *models.WorkspaceInvitation, member *models.WorkspaceMember, at time.Time) (bool, error) {
+	accepted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.WorkspaceInvitation{}).
+			Where("id = ? AND accepted_at IS NULL AND declined_at IS NULL AND expires_at > ?", invitation.ID, at).
+			Updates(map[string]interface{}{"accepted_at": at, "updated_at": at})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		// A member already keeps their role
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(member).Error; err != nil {
+			return err
+		}
+		accepted = true
+		return nil
+	})
+	if err != nil || !accepted {
+		return false, err
+	}
+
+	invitation.AcceptedAt = &at
+	return true, nil
+}
+
+// Decline sets DeclinedAt on a pending invitation
+func (r *GormInvitationRepository) Decline(id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.Model(&models.WorkspaceInvitation{}).
+		Where("id = ? AND accepted_at IS NULL AND declined_at IS NULL AND expires_at > ?", id, at).
+		Updates(map[string]interface{}{"declined_at": at, "updated_at": at})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GormShareRepository is a ShareRepository backed by GORM
+type GormShareRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new share, returning ErrDuplicate if the task or project
+// is already shared with the user
+func (r *GormShareRepository) Create(share *models.Share) error {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(share)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// FindByTask retrieves the share of a task with a user
+func (r *GormShareRepository) FindByTask(taskID, userID uuid.UUID) (*models.Share, error) {
+	var share models.Share
+	if err := r.db.Where("task_id = ? AND user_id = ?", taskID, userID).First(&share).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &share, nil
+}
+
+// FindByProject retrieves the share of a project with a user
+func (r *GormShareRepository) FindByProject(projectID, userID uuid.UUID) (*models.Share, error) {
+	var share models.Share
+	if err := r.db.Where("project_id = ? AND user_id = ?", projectID, userID).First(&share).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &share, nil
+}
+
+// ListByTask retrieves the shares of a task with their users, oldest first
+func (r *GormShareRepository) ListByTask(taskID uuid.UUID) ([]models.Share, error) {
+	return r.list(r.db.Where("task_id = ?", taskID))
+}
+
+// ListByProject retrieves the shares of a project with their users, oldest first
+func (r *GormShareRepository) ListByProject(projectID uuid.UUID) ([]models.Share, error) {
+	return r.list(r.db.Where("project_id = ?", projectID))
+}
+
+// list retrieves the shares matching a query with their users, oldest first
+func (r *GormShareRepository) list(query *gorm.DB) ([]models.Share, error) {
+	var shares []models.Share
+	if err := query.Order("created_at").Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	if len(shares) == 0 {
+		return shares, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(shares))
+	for _, share := range shares {
+		ids = append(ids, share.UserID)
+	}
+
+	var users []models.User
+	if err := r.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	for i := range shares {
+		shares[i].User = byID[shares[i].UserID]
+	}
+	return shares, nil
+}
+
+// ListForTask retrieves the shares giving a user access to a task, directly
+// or through its project
+func (r *GormShareRepository) ListForTask(taskID uuid.UUID, projectID *uuid.UUID, userID uuid.UUID) ([]models.Share, error) {
+	query := r.db.Where("user_id = ?", userID)
+	if projectID != nil {
+		query = query.Where("(task_id = ? OR project_id = ?)", taskID, *projectID)
+	} else {
+		query = query.Where("task_id = ?", taskID)
+	}
+
+	var shares []models.Share
+	if err := query.Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// Update saves the permission of a share
+func (r *GormShareRepository) Update(share *models.Share) error {
+	result := r.db.Model(&models.Share{}).
+		Where("id = ?", share.ID).
+		Updates(map[string]interface{}{"permission": share.Permission, "updated_at": share.UpdatedAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a share
+func (r *GormShareRepository) Delete(share *models.Share) error {
+	return r.db.Delete(share).Error
+}
+
+// GormCommentRepository is a CommentRepository backed by GORM
+type GormCommentRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new comment
+func (r *GormCommentRepository) Create(comment *models.Comment) error {
+	return r.db.Create(comment).Error
+}
+
+// FindByID retrieves a comment by ID
+func (r *GormCommentRepository) FindByID(id uuid.UUID) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.Where("id = ?", id).First(&comment).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &comment, nil
+}
+
+// ListByTask retrieves up to limit comments of a task with their authors,
+// oldest first, starting after the cursor when one is given
+func (r *GormCommentRepository) ListByTask(taskID uuid.UUID, after *CommentCursor, limit int) ([]models.Comment, error) {
+	query := r.db.Where("task_id = ?", taskID)
+	if after != nil {
+		query = query.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	var comments []models.Comment
+	if err := query.Order("created_at, id").Limit(limit).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return comments, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.UserID)
+	}
+
+	var users []models.User
+	if err := r.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	for i := range comments {
+		comments[i].User = byID[comments[i].UserID]
+	}
+	return comments, nil
+}
+
+// Update saves all fields of a comment
+func (r *GormCommentRepository) Update(comment *models.Comment) error {
+	return r.db.Save(comment).Error
+}
+
+// Delete soft deletes a comment
+func (r *GormCommentRepository) Delete(comment *models.Comment) error {
+	return r.db.Delete(comment).Error
+}
+
+// GormAttachmentRepository is an AttachmentRepository backed by GORM
+type GormAttachmentRepository struct {
+	db *gorm.DB
+}
+
+// Create inserts a new attachment
+func (r *GormAttachmentRepository) Create(attachment *models.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+// FindByID retrieves an attachment by ID
+func (r *GormAttachmentRepository) FindByID(id uuid.UUID) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := r.db.Where("id = ?", id).First(&attachment).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &attachment, nil
+}
+
+// ListByTask retrieves the attachments of a task, oldest first, whether or
+// not the task still exists
+func (r *GormAttachmentRepository) ListByTask(taskID uuid.UUID) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	if err := r.db.Where("task_id = ?", taskID).Order("created_at, id").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// ListOrphaned retrieves up to limit attachments whose task was deleted,
+// softly or for good
+func (r *GormAttachmentRepository) ListOrphaned(limit int) ([]models.Attachment, error) {
+	liveTasks := r.db.Model(&models.Task{}).Select("id")
+
+	var attachments []models.Attachment
+	if err := r.db.Where("task_id NOT IN (?)", liveTasks).Order("created_at, id").Limit(limit).Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// Delete removes an attachment
+func (r *GormAttachmentRepository) Delete(attachment *models.Attachment) error {
+	return r.db.Delete(attachment).Error
+}
@@ -0,0 +1,2010 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"gorm.io/gorm"
+)
+
+// memoryStore holds the shared state of the in-memory repositories
+type memoryStore struct {
+	mu         sync.RWMutex
+	users      map[uuid.UUID]models.User
+	tasks      map[uuid.UUID]models.Task
+	activities []models.Activity
+	tags       map[uuid.UUID]models.Tag
+	taskTags   map[uuid.UUID]map[uuid.UUID]bool // task ID -> set of tag IDs
+	projects   map[uuid.UUID]models.Project
+	blockers   map[uuid.UUID]map[uuid.UUID]bool // task ID -> set of blocking task IDs
+	tokens     map[uuid.UUID]models.RefreshToken
+	userTokens map[uuid.UUID]models.UserToken
+	recovery   map[uuid.UUID]models.RecoveryCode
+	apiTokens  map[uuid.UUID]models.APIToken
+	roles      map[string]models.Role
+
+	workspaces  map[uuid.UUID]models.Workspace
+	members     map[uuid.UUID]map[uuid.UUID]models.WorkspaceMember // workspace ID -> user ID -> member
+	invitations map[uuid.UUID]models.WorkspaceInvitation
+	shares      map[uuid.UUID]models.Share
+	comments    map[uuid.UUID]models.Comment
+	attachments map[uuid.UUID]models.Attachment
+}
+
+// NewMemoryRepositories creates repositories that keep all data in memory.
+// They are intended for tests and local development without Postgres.
+func NewMemoryRepositories() *Repositories {
+	store := &memoryStore{
+		users:      make(map[uuid.UUID]models.User),
+		tasks:      make(map[uuid.UUID]models.Task),
+		tags:       make(map[uuid.UUID]models.Tag),
+		taskTags:   make(map[uuid.UUID]map[uuid.UUID]bool),
+		projects:   make(map[uuid.UUID]models.Project),
+		blockers:   make(map[uuid.UUID]map[uuid.UUID]bool),
+		tokens:     make(map[uuid.UUID]models.RefreshToken),
+		userTokens: make(map[uuid.UUID]models.UserToken),
+		recovery:   make(map[uuid.UUID]models.RecoveryCode),
+		apiTokens:  make(map[uuid.UUID]models.APIToken),
+		roles:      make(map[string]models.Role),
+
+		workspaces:  make(map[uuid.UUID]models.Workspace),
+		members:     make(map[uuid.UUID]map[uuid.UUID]models.WorkspaceMember),
+		invitations: make(map[uuid.UUID]models.WorkspaceInvitation),
+		shares:      make(map[uuid.UUID]models.Share),
+		comments:    make(map[uuid.UUID]models.Comment),
+		attachments: make(map[uuid.UUID]models.Attachment),
+	}
+
+	return &Repositories{
+		Tasks:        &MemoryTaskRepository{store: store},
+		Users:        &MemoryUserRepository{store: store},
+		Activities:   &MemoryActivityRepository{store: store},
+		Tags:         &MemoryTagRepository{store: store},
+		Projects:     &MemoryProjectRepository{store: store},
+		Dependencies: &MemoryDependencyRepository{store: store},
+		Tokens:       &MemoryRefreshTokenRepository{store: store},
+		UserTokens:   &MemoryUserTokenRepository{store: store},
+		Recovery:     &MemoryRecoveryCodeRepository{store: store},
+		APITokens:    &MemoryAPITokenRepository{store: store},
+		Roles:        &MemoryRoleRepository{store: store},
+		Workspaces:   &MemoryWorkspaceRepository{store: store},
+		Invitations:  &MemoryInvitationRepository{store: store},
+		Shares:       &MemoryShareRepository{store: store},
+		Comments:     &MemoryCommentRepository{store: store},
+		Attachments:  &MemoryAttachmentRepository{store: store},
+	}
+}
+
+// paginate applies limit and offset to a slice
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset > 0 {
+		if offset >= len(items) {
+			return []T{}
+		}
+		items = items[offset:]
+	}
+
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+
+	return items
+}
+
+// stamp fills in the ID and timestamps the database would otherwise set
+func stamp(id *uuid.UUID, createdAt, updatedAt *time.Time) {
+	if *id == uuid.Nil {
+		*id = uuid.New()
+	}
+
+	now := time.Now()
+	if createdAt != nil && createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt != nil && updatedAt.IsZero() {
+		*updatedAt = now
+	}
+}
+
+// sortByCreatedAt orders tasks oldest first
+func sortByCreatedAt(tasks []models.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+}
+
+// MemoryTaskRepository is an in-memory TaskRepository
+type MemoryTaskRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new task
+func (r *MemoryTaskRepository) Create(task *models.Task) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stamp(&task.ID, &task.CreatedAt, &task.UpdatedAt)
+	if _, exists := r.store.tasks[task.ID]; exists {
+		return ErrDuplicate
+	}
+
+	r.store.tasks[task.ID] = *task
+	return nil
+}
+
+// FindByID retrieves a task by ID
+func (r *MemoryTaskRepository) FindByID(id uuid.UUID) (*models.Task, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	task, exists := r.store.tasks[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return r.store.withTags(task), nil
+}
+
+// ListChildren retrieves the direct subtasks of a task, oldest first
+func (r *MemoryTaskRepository) ListChildren(parentID uuid.UUID) ([]models.Task, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tasks := []models.Task{}
+	for _, task := range r.store.tasks {
+		if task.ParentID != nil && *task.ParentID == parentID {
+			tasks = append(tasks, *r.store.withTags(task))
+		}
+	}
+	sortByCreatedAt(tasks)
+
+	return tasks, nil
+}
+
+// List retrieves tasks matching the filter, ordered by priority (high to low) and created_at (newest first)
+func (r *MemoryTaskRepository) List(filter TaskFilter) ([]models.Task, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tasks := r.filtered(filter)
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].Priority != tasks[j].Priority {
+			return tasks[i].Priority > tasks[j].Priority
+		}
+		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+	})
+
+	tasks = paginate(tasks, filter.Limit, filter.Offset)
+	for i := range tasks {
+		tasks[i] = *r.store.withTags(tasks[i])
+	}
+
+	return tasks, nil
+}
+
+// Count counts tasks matching the filter, ignoring pagination
+func (r *MemoryTaskRepository) Count(filter TaskFilter) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return int64(len(r.filtered(filter))), nil
+}
+
+// Search retrieves the tasks matching the filter and its Search query, best
+// match first. The rank approximates Postgres' ts_rank, with title matches
+// weighing more than description matches.
+func (r *MemoryTaskRepository) Search(filter TaskFilter) ([]TaskMatch, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	terms := SearchTerms(filter.Search)
+	matches := []TaskMatch{}
+	for _, task := range r.filtered(filter) {
+		titleHits := countMatches(task.Title, terms)
+		descriptionHits := countMatches(task.Description, terms)
+		score := float64(titleHits) + 0.4*float64(descriptionHits)
+
+		match := TaskMatch{
+			Task:           *r.store.withTags(task),
+			Rank:           score / (score + 1),
+			TitleHighlight: highlight(task.Title, terms, 0),
+		}
+		if descriptionHits > 0 {
+			match.Snippet = highlight(task.Description, terms, snippetWords)
+		}
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Rank != matches[j].Rank {
+			return matches[i].Rank > matches[j].Rank
+		}
+		if matches[i].Task.Priority != matches[j].Task.Priority {
+			return matches[i].Task.Priority > matches[j].Task.Priority
+		}
+		return matches[i].Task.CreatedAt.After(matches[j].Task.CreatedAt)
+	})
+	return paginate(matches, filter.Limit, filter.Offset), nil
+}
+
+// snippetWords is how many words of a description a search snippet shows
+const snippetWords = 30
+
+// wordSpans returns the byte ranges of the words of a text, split the way
+// SearchTerms splits queries
+func wordSpans(text string) [][2]int {
+	spans := [][2]int{}
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
+
+// matchesTerm reports whether a word starts with one of the search terms
+func matchesTerm(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// countMatches counts the words of a text that start with a search term
+func countMatches(text string, terms []string) int {
+	count := 0
+	for _, span := range wordSpans(text) {
+		if matchesTerm(text[span[0]:span[1]], terms) {
+			count++
+		}
+	}
+	return count
+}
+
+// matchesSearch reports whether every search term starts a word of the
+// task's title or description
+func matchesSearch(task models.Task, terms []string) bool {
+	for _, term := range terms {
+		if countMatches(task.Title, []string{term}) == 0 && countMatches(task.Description, []string{term}) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// highlight marks the words of a text that start with a search term. With a
+// word limit, only that many words around the first match are kept.
+func highlight(text string, terms []string, limit int) string {
+	spans := wordSpans(text)
+	from, to := 0, len(spans)
+	if limit > 0 && len(spans) > limit {
+		for i, span := range spans {
+			if matchesTerm(text[span[0]:span[1]], terms) {
+				from = max(0, min(i-limit/6, len(spans)-limit))
+				break
+			}
+		}
+		to = from + limit
+	}
+
+	var marked strings.Builder
+	start, end := 0, len(text)
+	if from > 0 {
+		start = spans[from][0]
+		marked.WriteString("... ")
+	}
+	if to < len(spans) {
+		end = spans[to-1][1]
+	}
+
+	position := start
+	for _, span := range spans[from:to] {
+		word := text[span[0]:span[1]]
+		if !matchesTerm(word, terms) {
+			continue
+		}
+		marked.WriteString(text[position:span[0]])
+		marked.WriteString("<mark>" + word + "</mark>")
+		position = span[1]
+	}
+	marked.WriteString(text[position:end])
+	if to < len(spans) {
+		marked.WriteString(" ...")
+	}
+	return marked.String()
+}
+
+// Update saves all fields of a task
+func (r *MemoryTaskRepository) Update(task *models.Task) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.tasks[task.ID]; !exists {
+		return ErrNotFound
+	}
+
+	r.store.tasks[task.ID] = *task
+	return nil
+}
+
+// Delete removes a task
+func (r *MemoryTaskRepository) Delete(task *models.Task) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.removeTask(task.ID)
+	return nil
+}
+
+// removeTask removes a task with its tag links, dependencies, shares and
+// comments; the caller must hold the lock
+func (s *memoryStore) removeTask(id uuid.UUID) {
+	delete(s.tasks, id)
+	delete(s.taskTags, id)
+	delete(s.blockers, id)
+	for _, blockerIDs := range s.blockers {
+		delete(blockerIDs, id)
+	}
+	for shareID, share := range s.shares {
+		if share.TaskID != nil && *share.TaskID == id {
+			delete(s.shares, shareID)
+		}
+	}
+	for commentID, comment := range s.comments {
+		if comment.TaskID == id {
+			delete(s.comments, commentID)
+		}
+	}
+}
+
+// filtered returns the tasks matching a filter; the caller must hold the lock
+func (r *MemoryTaskRepository) filtered(filter TaskFilter) []models.Task {
+	tasks := []models.Task{}
+	for _, task := range r.store.tasks {
+		switch {
+		case filter.SharedWithMe:
+			if task.WorkspaceID != nil || !r.store.sharedWith(task, filter.UserID) {
+				continue
+			}
+		case filter.WorkspaceID != nil:
+			if task.WorkspaceID == nil || *task.WorkspaceID != *filter.WorkspaceID {
+				continue
+			}
+		case filter.AssigneeID == nil:
+			if task.UserID != filter.UserID || task.WorkspaceID != nil {
+				continue
+			}
+		}
+		if filter.AssigneeID != nil && (task.AssigneeID == nil || *task.AssigneeID != *filter.AssigneeID) {
+			continue
+		}
+		if filter.Status != "" && task.Status != filter.Status {
+			continue
+		}
+		if filter.Priority >= 0 && task.Priority != filter.Priority {
+			continue
+		}
+		if len(filter.Tags) > 0 && !r.store.matchesTags(task.ID, filter.Tags, filter.MatchAllTags) {
+			continue
+		}
+		if filter.ProjectID != nil {
+			if task.ProjectID == nil || *task.ProjectID != *filter.ProjectID {
+				continue
+			}
+		} else if !filter.IncludeArchived && task.ProjectID != nil && r.store.projects[*task.ProjectID].Archived {
+			continue
+		}
+		if filter.Blocked != nil && r.store.isBlocked(task.ID) != *filter.Blocked {
+			continue
+		}
+		if filter.Search != "" && !matchesSearch(task, SearchTerms(filter.Search)) {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// withTags returns a copy of a task with its tags loaded; the caller must hold the lock
+func (s *memoryStore) withTags(task models.Task) *models.Task {
+	task.Tags = []models.Tag{}
+	for tagID := range s.taskTags[task.ID] {
+		task.Tags = append(task.Tags, s.tags[tagID])
+	}
+	sort.Slice(task.Tags, func(i, j int) bool {
+		return task.Tags[i].Name < task.Tags[j].Name
+	})
+	return &task
+}
+
+// matchesTags reports whether a task carries any (or all) of the named tags;
+// the caller must hold the lock
+func (s *memoryStore) matchesTags(taskID uuid.UUID, names []string, matchAll bool) bool {
+	attached := make(map[string]bool)
+	for tagID := range s.taskTags[taskID] {
+		attached[s.tags[tagID].Name] = true
+	}
+
+	for _, name := range uniqueStrings(names) {
+		if attached[name] && !matchAll {
+			return true
+		}
+		if !attached[name] && matchAll {
+			return false
+		}
+	}
+
+	return matchAll
+}
+
+// isBlocked reports whether a task has a blocker that is not completed;
+// the caller must hold the lock
+func (s *memoryStore) isBlocked(taskID uuid.UUID) bool {
+	for blockerID := range s.blockers[taskID] {
+		if blocker, exists := s.tasks[blockerID]; exists && blocker.Status != "completed" {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryUserRepository is an in-memory UserRepository
+type MemoryUserRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new user, returning ErrDuplicate if the email is taken
+func (r *MemoryUserRepository) Create(user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.users {
+		if existing.Email == user.Email {
+			return ErrDuplicate
+		}
+	}
+
+	stamp(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	r.store.users[user.ID] = *user
+	return nil
+}
+
+// FindByID retrieves a user by ID
+func (r *MemoryUserRepository) FindByID(id uuid.UUID) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, exists := r.store.users[id]
+	if !exists || user.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+// FindByEmail retrieves a user by email, ignoring case. An exact match is
+// preferred over addresses that only differ in case.
+func (r *MemoryUserRepository) FindByEmail(email string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var found *models.User
+	for _, user := range r.store.users {
+		if !strings.EqualFold(user.Email, email) || user.DeletedAt.Valid {
+			continue
+		}
+		if user.Email == email {
+			return &user, nil
+		}
+		found = &user
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
+
+// EmailTaken reports whether any user, deleted or not, has the email,
+// ignoring case
+func (r *MemoryUserRepository) EmailTaken(email string) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if strings.EqualFold(user.Email, email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// List retrieves users matching the filter, newest first
+func (r *MemoryUserRepository) List(filter UserFilter) ([]models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := r.filtered(filter)
+	sort.SliceStable(users, func(i, j int) bool {
+		return users[i].CreatedAt.After(users[j].CreatedAt)
+	})
+	return paginate(users, filter.Limit, filter.Offset), nil
+}
+
+// Count counts users matching the filter, ignoring pagination
+func (r *MemoryUserRepository) Count(filter UserFilter) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return int64(len(r.filtered(filter))), nil
+}
+
+// filtered returns the users that are not deleted and match a filter
+func (r *MemoryUserRepository) filtered(filter UserFilter) []models.User {
+	query := strings.ToLower(filter.Query)
+	users := []models.User{}
+	for _, user := range r.store.users {
+		if user.DeletedAt.Valid {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(user.Email), query) &&
+			!strings.Contains(strings.ToLower(user.FirstName), query) &&
+			!strings.Contains(strings.ToLower(user.LastName), query) {
+			continue
+		}
+		if filter.Role != "" && user.Role != filter.Role {
+			continue
+		}
+		if filter.Active != nil && user.IsActive != *filter.Active {
+			continue
+		}
+		users = append(users, user)
+	}
+	return users
+}
+
+// Update saves all fields of a user
+func (r *MemoryUserRepository) Update(user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if existing, exists := r.store.users[user.ID]; !exists || existing.DeletedAt.Valid {
+		return ErrNotFound
+	}
+
+	r.store.users[user.ID] = *user
+	return nil
+}
+
+// AdvanceTOTPCounter raises the last accepted TOTP counter of a user and
+// reports whether it did
+func (r *MemoryUserRepository) AdvanceTOTPCounter(id uuid.UUID, counter int64, at time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, exists := r.store.users[id]
+	if !exists || user.DeletedAt.Valid || user.TOTPLastCounter >= counter {
+		return false, nil
+	}
+
+	user.TOTPLastCounter = counter
+	user.UpdatedAt = at
+	r.store.users[id] = user
+	return true, nil
+}
+
+// Delete soft-deletes a user together with their tasks and projects
+func (r *MemoryUserRepository) Delete(user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, exists := r.store.users[user.ID]
+	if !exists || existing.DeletedAt.Valid {
+		return ErrNotFound
+	}
+
+	for id, task := range r.store.tasks {
+		if task.UserID == user.ID && task.WorkspaceID == nil {
+			r.store.removeTask(id)
+		}
+	}
+	for id, project := range r.store.projects {
+		if project.UserID == user.ID {
+			delete(r.store.projects, id)
+		}
+	}
+
+	existing.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.store.users[user.ID] = existing
+	user.DeletedAt = existing.DeletedAt
+	return nil
+}
+
+// Purge permanently removes the data of a soft-deleted user and anonymizes
+// the user record
+func (r *MemoryUserRepository) Purge(id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, exists := r.store.users[id]
+	if !exists || !user.DeletedAt.Valid {
+		return ErrNotFound
+	}
+
+	// Workspaces the user is the last member of go with them
+	for workspaceID, members := range r.store.members {
+		if _, member := members[id]; !member {
+			continue
+		}
+		delete(members, id)
+		if len(members) == 0 {
+			r.store.removeWorkspace(workspaceID)
+		}
+	}
+	for taskID, task := range r.store.tasks {
+		if task.AssigneeID != nil && *task.AssigneeID == id {
+			task.AssigneeID = nil
+			r.store.tasks[taskID] = task
+		}
+	}
+	for shareID, share := range r.store.shares {
+		if share.OwnerID == id || share.UserID == id {
+			delete(r.store.shares, shareID)
+		}
+	}
+	for commentID, comment := range r.store.comments {
+		if comment.UserID == id {
+			delete(r.store.comments, commentID)
+		}
+	}
+
+	for tagID, tag := range r.store.tags {
+		if tag.UserID == id {
+			delete(r.store.tags, tagID)
+			for _, tagIDs := range r.store.taskTags {
+				delete(tagIDs, tagID)
+			}
+		}
+	}
+
+	activities := r.store.activities[:0]
+	for _, activity := range r.store.activities {
+		if activity.UserID != id {
+			activities = append(activities, activity)
+		}
+	}
+	r.store.activities = activities
+
+	for tokenID, token := range r.store.tokens {
+		if token.UserID == id {
+			delete(r.store.tokens, tokenID)
+		}
+	}
+	for tokenID, token := range r.store.userTokens {
+		if token.UserID == id {
+			delete(r.store.userTokens, tokenID)
+		}
+	}
+	for codeID, code := range r.store.recovery {
+		if code.UserID == id {
+			delete(r.store.recovery, codeID)
+		}
+	}
+	for tokenID, token := range r.store.apiTokens {
+		if token.UserID == id {
+			delete(r.store.apiTokens, tokenID)
+		}
+	}
+
+	user.Email = AnonymizedEmail(id)
+	user.Password = ""
+	user.FirstName = ""
+	user.LastName = ""
+	user.IsActive = false
+	user.Role = models.RoleUser
+	user.EmailVerifiedAt = nil
+	user.PendingEmail = nil
+	user.TOTPSecret = nil
+	user.TOTPEnabledAt = nil
+	user.TOTPLastCounter = 0
+	r.store.users[id] = user
+	return nil
+}
+
+// MemoryActivityRepository is an in-memory ActivityRepository
+type MemoryActivityRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new activity
+func (r *MemoryActivityRepository) Create(activity *models.Activity) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stamp(&activity.ID, &activity.CreatedAt, nil)
+	r.store.activities = append(r.store.activities, *activity)
+	return nil
+}
+
+// ListByUser retrieves a user's activities, newest first
+func (r *MemoryActivityRepository) ListByUser(userID uuid.UUID, limit, offset int) ([]models.Activity, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	activities := []models.Activity{}
+	for i := len(r.store.activities) - 1; i >= 0; i-- {
+		if r.store.activities[i].UserID == userID {
+			activities = append(activities, r.store.activities[i])
+		}
+	}
+
+	return paginate(activities, limit, offset), nil
+}
+
+// MemoryTagRepository is an in-memory TagRepository
+type MemoryTagRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new tag
+func (r *MemoryTagRepository) Create(tag *models.Tag) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.tags {
+		if existing.UserID == tag.UserID && existing.Name == tag.Name {
+			return ErrDuplicate
+		}
+	}
+
+	stamp(&tag.ID, &tag.CreatedAt, &tag.UpdatedAt)
+	r.store.tags[tag.ID] = *tag
+	return nil
+}
+
+// FindByID retrieves a tag by ID
+func (r *MemoryTagRepository) FindByID(id uuid.UUID) (*models.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tag, exists := r.store.tags[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &tag, nil
+}
+
+// FindByName retrieves a user's tag by name
+func (r *MemoryTagRepository) FindByName(userID uuid.UUID, name string) (*models.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, tag := range r.store.tags {
+		if tag.UserID == userID && tag.Name == name {
+			return &tag, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListByUser retrieves a user's tags ordered by name
+func (r *MemoryTagRepository) ListByUser(userID uuid.UUID) ([]models.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tags := []models.Tag{}
+	for _, tag := range r.store.tags {
+		if tag.UserID == userID {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// Update saves all fields of a tag
+func (r *MemoryTagRepository) Update(tag *models.Tag) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.tags[tag.ID]; !exists {
+		return ErrNotFound
+	}
+	for _, existing := range r.store.tags {
+		if existing.ID != tag.ID && existing.UserID == tag.UserID && existing.Name == tag.Name {
+			return ErrDuplicate
+		}
+	}
+
+	r.store.tags[tag.ID] = *tag
+	return nil
+}
+
+// Delete removes a tag and detaches it from every task
+func (r *MemoryTagRepository) Delete(tag *models.Tag) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.tags, tag.ID)
+	for _, tagIDs := range r.store.taskTags {
+		delete(tagIDs, tag.ID)
+	}
+	return nil
+}
+
+// Attach attaches a tag to a task; attaching twice is a no-op
+func (r *MemoryTagRepository) Attach(taskID, tagID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.taskTags[taskID]; !exists {
+		r.store.taskTags[taskID] = make(map[uuid.UUID]bool)
+	}
+	r.store.taskTags[taskID][tagID] = true
+	return nil
+}
+
+// Detach removes a tag from a task
+func (r *MemoryTagRepository) Detach(taskID, tagID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.taskTags[taskID], tagID)
+	return nil
+}
+
+// MemoryProjectRepository is an in-memory ProjectRepository
+type MemoryProjectRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new project
+func (r *MemoryProjectRepository) Create(project *models.Project) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stamp(&project.ID, &project.CreatedAt, &project.UpdatedAt)
+	r.store.projects[project.ID] = *project
+	return nil
+}
+
+// FindByID retrieves a project by ID
+func (r *MemoryProjectRepository) FindByID(id uuid.UUID) (*models.Project, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	project, exists := r.store.projects[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &project, nil
+}
+
+// ListByUser retrieves a user's projects in display order
+func (r *MemoryProjectRepository) ListByUser(userID uuid.UUID, includeArchived bool) ([]models.Project, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	projects := []models.Project{}
+	for _, project := range r.store.projects {
+		if project.UserID == userID && (includeArchived || !project.Archived) {
+			projects = append(projects, project)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Position != projects[j].Position {
+			return projects[i].Position < projects[j].Position
+		}
+		return projects[i].CreatedAt.Before(projects[j].CreatedAt)
+	})
+	return projects, nil
+}
+
+// Update saves all fields of a project
+func (r *MemoryProjectRepository) Update(project *models.Project) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.projects[project.ID]; !exists {
+		return ErrNotFound
+	}
+
+	r.store.projects[project.ID] = *project
+	return nil
+}
+
+// Delete removes a project and moves its tasks out of it
+func (r *MemoryProjectRepository) Delete(project *models.Project) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, task := range r.store.tasks {
+		if task.ProjectID != nil && *task.ProjectID == project.ID {
+			task.ProjectID = nil
+			r.store.tasks[id] = task
+		}
+	}
+	for id, share := range r.store.shares {
+		if share.ProjectID != nil && *share.ProjectID == project.ID {
+			delete(r.store.shares, id)
+		}
+	}
+
+	delete(r.store.projects, project.ID)
+	return nil
+}
+
+// MemoryDependencyRepository is an in-memory DependencyRepository
+type MemoryDependencyRepository struct {
+	store *memoryStore
+}
+
+// Add inserts a dependency edge, returning ErrDuplicate if it already exists
+func (r *MemoryDependencyRepository) Add(dependency *models.TaskDependency) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.blockers[dependency.TaskID][dependency.BlockedByID] {
+		return ErrDuplicate
+	}
+
+	if _, exists := r.store.blockers[dependency.TaskID]; !exists {
+		r.store.blockers[dependency.TaskID] = make(map[uuid.UUID]bool)
+	}
+	r.store.blockers[dependency.TaskID][dependency.BlockedByID] = true
+	return nil
+}
+
+// Remove deletes a dependency edge
+func (r *MemoryDependencyRepository) Remove(taskID, blockedByID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if !r.store.blockers[taskID][blockedByID] {
+		return ErrNotFound
+	}
+
+	delete(r.store.blockers[taskID], blockedByID)
+	return nil
+}
+
+// ListBlockers retrieves the tasks a task is blocked by, oldest first
+func (r *MemoryDependencyRepository) ListBlockers(taskID uuid.UUID) ([]models.Task, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tasks := []models.Task{}
+	for blockerID := range r.store.blockers[taskID] {
+		if task, exists := r.store.tasks[blockerID]; exists {
+			tasks = append(tasks, task)
+		}
+	}
+	sortByCreatedAt(tasks)
+	return tasks, nil
+}
+
+// ListDependents retrieves the tasks blocked by a task, oldest first
+func (r *MemoryDependencyRepository) ListDependents(taskID uuid.UUID) ([]models.Task, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tasks := []models.Task{}
+	for dependentID, blockerIDs := range r.store.blockers {
+		if task, exists := r.store.tasks[dependentID]; exists && blockerIDs[taskID] {
+			tasks = append(tasks, task)
+		}
+	}
+	sortByCreatedAt(tasks)
+	return tasks, nil
+}
+
+// MemoryRefreshTokenRepository is an in-memory RefreshTokenRepository
+type MemoryRefreshTokenRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new refresh token
+func (r *MemoryRefreshTokenRepository) Create(token *models.RefreshToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stamp(&token.ID, &token.CreatedAt, nil)
+	for _, existing := range r.store.tokens {
+		if existing.TokenHash == token.TokenHash {
+			return ErrDuplicate
+		}
+	}
+
+	r.store.tokens[token.ID] = *token
+	return nil
+}
+
+// FindByHash retrieves a refresh token by the hash of its value
+func (r *MemoryRefreshTokenRepository) FindByHash(hash string) (*models.RefreshToken, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, token := range r.store.tokens {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// MarkUsed sets UsedAt on an unused, unrevoked token and reports whether it did
+func (r *MemoryRefreshTokenRepository) MarkUsed(id uuid.UUID, at time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token, exists := r.store.tokens[id]
+	if !exists || token.UsedAt != nil || token.RevokedAt != nil {
+		return false, nil
+	}
+
+	token.UsedAt = &at
+	r.store.tokens[id] = token
+	return true, nil
+}
+
+// RevokeFamily revokes every token of a family
+func (r *MemoryRefreshTokenRepository) RevokeFamily(familyID uuid.UUID, at time.Time) error {
+	return r.revoke(at, func(token models.RefreshToken) bool { return token.FamilyID == familyID })
+}
+
+// RevokeUser revokes every token of a user
+func (r *MemoryRefreshTokenRepository) RevokeUser(userID uuid.UUID, at time.Time) error {
+	return r.revoke(at, func(token models.RefreshToken) bool { return token.UserID == userID })
+}
+
+// revoke sets RevokedAt on the unrevoked tokens matching a predicate
+func (r *MemoryRefreshTokenRepository) revoke(at time.Time, match func(models.RefreshToken) bool) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, token := range r.store.tokens {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &at
+			r.store.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+// MemoryUserTokenRepository is an in-memory UserTokenRepository
+type MemoryUserTokenRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new user token
+func (r *MemoryUserTokenRepository) Create(token *models.UserToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stamp(&token.ID, &token.CreatedAt, nil)
+	for _, existing := range r.store.userTokens {
+		if existing.TokenHash == token.TokenHash {
+			return ErrDuplicate
+		}
+	}
+
+	r.store.userTokens[token.ID] = *token
+	return nil
+}
+
+// FindByHash retrieves a user token by the hash of its value
+func (r *MemoryUserTokenRepository) FindByHash(hash string) (*models.UserToken, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, token := range r.store.userTokens {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// MarkUsed sets UsedAt on an unused token and reports whether it did
+func (r *MemoryUserTokenRepository) MarkUsed(id uuid.UUID, at time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token, exists := r.store.userTokens[id]
+	if !exists || token.UsedAt != nil {
+		return false, nil
+	}
+
+	token.UsedAt = &at
+	r.store.userTokens[id] = token
+	return true, nil
+}
+
+// ExpireUser marks the unused tokens of a user with the given purpose as used
+func (r *MemoryUserTokenRepository) ExpireUser(userID uuid.UUID, purpose string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, token := range r.store.userTokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &at
+			r.store.userTokens[id] = token
+		}
+	}
+	return nil
+}
+
+// MemoryRecoveryCodeRepository is an in-memory RecoveryCodeRepository
+type MemoryRecoveryCodeRepository struct {
+	store *memoryStore
+}
+
+// Replace deletes the codes of a user and stores new ones in their place
+func (r *MemoryRecoveryCodeRepository) Replace(userID uuid.UUID, codes []models.RecoveryCode) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.deleteUser(userID)
+	for i := range codes {
+		codes[i].UserID = userID
+		stamp(&codes[i].ID, &codes[i].CreatedAt, nil)
+		r.store.recovery[codes[i].ID] = codes[i]
+	}
+	return nil
+}
+
+// Redeem sets UsedAt on an unused code of a user and reports whether it did
+func (r *MemoryRecoveryCodeRepository) Redeem(userID uuid.UUID, hash string, at time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, code := range r.store.recovery {
+		if code.UserID == userID && code.CodeHash == hash && code.UsedAt == nil {
+			code.UsedAt = &at
+			r.store.recovery[id] = code
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CountUnused counts the codes of a user that can still be redeemed
+func (r *MemoryRecoveryCodeRepository) CountUnused(userID uuid.UUID) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var count int64
+	for _, code := range r.store.recovery {
+		if code.UserID == userID && code.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// DeleteUser deletes every code of a user
+func (r *MemoryRecoveryCodeRepository) DeleteUser(userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.deleteUser(userID)
+	return nil
+}
+
+// deleteUser deletes every code of a user; the caller holds the lock
+func (r *MemoryRecoveryCodeRepository) deleteUser(userID uuid.UUID) {
+	for id, code := range r.store.recovery {
+		if code.UserID == userID {
+			delete(r.store.recovery, id)
+		}
+	}
+}
+
+// MemoryAPITokenRepository is an in-memory APITokenRepository
+type MemoryAPITokenRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new API token
+func (r *MemoryAPITokenRepository) Create(token *models.APIToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stamp(&token.ID, &token.CreatedAt, &token.UpdatedAt)
+	for _, existing := range r.store.apiTokens {
+		if existing.TokenHash == token.TokenHash {
+			return ErrDuplicate
+		}
+	}
+
+	r.store.apiTokens[token.ID] = *token
+	return nil
+}
+
+// FindByID retrieves an API token by ID
+func (r *MemoryAPITokenRepository) FindByID(id uuid.UUID) (*models.APIToken, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	token, exists := r.store.apiTokens[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &token, nil
+}
+
+// FindByHash retrieves an API token by the hash of its value
+func (r *MemoryAPITokenRepository) FindByHash(hash string) (*models.APIToken, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, token := range r.store.apiTokens {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListByUser retrieves a user's API tokens, newest first
+func (r *MemoryAPITokenRepository) ListByUser(userID uuid.UUID) ([]models.APIToken, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tokens := []models.APIToken{}
+	for _, token := range r.store.apiTokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.SliceStable(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+	})
+	return tokens, nil
+}
+
+// Update saves all fields of an API token
+func (r *MemoryAPITokenRepository) Update(token *models.APIToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.apiTokens[token.ID]; !exists {
+		return ErrNotFound
+	}
+	r.store.apiTokens[token.ID] = *token
+	return nil
+}
+
+// Delete removes an API token
+func (r *MemoryAPITokenRepository) Delete(token *models.APIToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.apiTokens, token.ID)
+	return nil
+}
+
+// Touch sets LastUsedAt without changing UpdatedAt
+func (r *MemoryAPITokenRepository) Touch(id uuid.UUID, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token, exists := r.store.apiTokens[id]
+	if !exists {
+		return ErrNotFound
+	}
+	token.LastUsedAt = &at
+	r.store.apiTokens[id] = token
+	return nil
+}
+
+// MemoryRoleRepository is an in-memory RoleRepository
+type MemoryRoleRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new role, returning ErrDuplicate if the name is taken
+func (r *MemoryRoleRepository) Create(role *models.Role) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stamp(&role.ID, &role.CreatedAt, &role.UpdatedAt)
+	if _, exists := r.store.roles[role.Name]; exists {
+		return ErrDuplicate
+	}
+
+	r.store.roles[role.Name] = *role
+	return nil
+}
+
+// FindByName retrieves a role by name
+func (r *MemoryRoleRepository) FindByName(name string) (*models.Role, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	role, exists := r.store.roles[name]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &role, nil
+}
+
+// List retrieves every role ordered by name
+func (r *MemoryRoleRepository) List() ([]models.Role, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	roles := []models.Role{}
+	for _, role := range r.store.roles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	return roles, nil
+}
+
+// Update saves all fields of a role
+func (r *MemoryRoleRepository) Update(role *models.Role) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.roles[role.Name]; !exists {
+		return ErrNotFound
+	}
+	r.store.roles[role.Name] = *role
+	return nil
+}
+
+// Delete removes a role
+func (r *MemoryRoleRepository) Delete(role *models.Role) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.roles, role.Name)
+	return nil
+}
+
+// MemoryWorkspaceRepository is an in-memory WorkspaceRepository
+type MemoryWorkspaceRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a workspace together with its owner
+func (r *MemoryWorkspaceRepository) Create(workspace *models.Workspace, owner *models.WorkspaceMember) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stamp(&workspace.ID, &workspace.CreatedAt, &workspace.UpdatedAt)
+	if _, exists := r.store.workspaces[workspace.ID]; exists {
+		return ErrDuplicate
+	}
+
+	owner.WorkspaceID = workspace.ID
+	stamp(&owner.WorkspaceID, &owner.CreatedAt, &owner.UpdatedAt)
+
+	r.store.workspaces[workspace.ID] = *workspace
+	r.store.members[workspace.ID] = map[uuid.UUID]models.WorkspaceMember{owner.UserID: *owner}
+	return nil
+}
+
+// FindByID retrieves a workspace by ID
+func (r *MemoryWorkspaceRepository) FindByID(id uuid.UUID) (*models.Workspace, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	workspace, exists := r.store.workspaces[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &workspace, nil
+}
+
+// ListByUser retrieves the workspaces a user is a member of, ordered by
+// name, with the user's role
+func (r *MemoryWorkspaceRepository) ListByUser(userID uuid.UUID) ([]models.Workspace, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	workspaces := []models.Workspace{}
+	for workspaceID, members := range r.store.members {
+		if member, exists := members[userID]; exists {
+			workspace := r.store.workspaces[workspaceID]
+			workspace.Role = member.Role
+			workspaces = append(workspaces, workspace)
+		}
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].Name < workspaces[j].Name
+	})
+	return workspaces, nil
+}
+
+// Update saves all fields of a workspace
+func (r *MemoryWorkspaceRepository) Update(workspace *models.Workspace) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.workspaces[workspace.ID]; !exists {
+		return ErrNotFound
+	}
+
+	stored := *workspace
+	stored.Role = ""
+	r.store.workspaces[workspace.ID] = stored
+	return nil
+}
+
+// Delete removes a workspace with its tasks, members and invitations
+func (r *MemoryWorkspaceRepository) Delete(workspace *models.Workspace) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.removeWorkspace(workspace.ID)
+	return nil
+}
+
+// removeWorkspace removes a workspace with its tasks, members and
+// invitations; the caller must hold the lock
+func (s *memoryStore) removeWorkspace(id uuid.UUID) {
+	for taskID, task := range s.tasks {
+		if task.WorkspaceID != nil && *task.WorkspaceID == id {
+			s.removeTask(taskID)
+		}
+	}
+	for invitationID, invitation := range s.invitations {
+		if invitation.WorkspaceID == id {
+			delete(s.invitations, invitationID)
+		}
+	}
+	delete(s.members, id)
+	delete(s.workspaces, id)
+}
+
+// FindMember retrieves the membership of a user in a workspace
+func (r *MemoryWorkspaceRepository) FindMember(workspaceID, userID uuid.UUID) (*models.WorkspaceMember, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	member, exists := r.store.members[workspaceID][userID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &member, nil
+}
+
+// ListMembers retrieves the members of a workspace with their users, oldest first
+func (r *MemoryWorkspaceRepository) ListMembers(workspaceID uuid.UUID) ([]models.WorkspaceMember, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	members := []models.WorkspaceMember{}
+	for _, member := range r.store.members[workspaceID] {
+		if user, exists := r.store.users[member.UserID]; exists && !user.DeletedAt.Valid {
+			member.User = &user
+		}
+		members = append(members, member)
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].CreatedAt.Before(members[j].CreatedAt)
+	})
+	return members, nil
+}
+
+// UpdateMember saves the role of a member
+func (r *MemoryWorkspaceRepository) UpdateMember(member *models.WorkspaceMember) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.members[member.WorkspaceID][member.UserID]; !exists {
+		return ErrNotFound
+	}
+
+	stored := *member
+	stored.User = nil
+	r.store.members[member.WorkspaceID][member.UserID] = stored
+	return nil
+}
+
+// TransferOwnership makes a member the owner and the owner an admin
+func (r *MemoryWorkspaceRepository) TransferOwnership(workspaceID, ownerID, memberID uuid.UUID, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	owner, exists := r.store.members[workspaceID][ownerID]
+	if !exists || owner.Role != models.WorkspaceRoleOwner {
+		return ErrNotFound
+	}
+	member, exists := r.store.members[workspaceID][memberID]
+	if !exists {
+		return ErrNotFound
+	}
+
+	owner.Role, owner.UpdatedAt = models.WorkspaceRoleAdmin, at
+	member.Role, member.UpdatedAt = models.WorkspaceRoleOwner, at
+	r.store.members[workspaceID][ownerID] = owner
+	r.store.members[workspaceID][memberID] = member
+	return nil
+}
+
+// RemoveMember removes a member and unassigns them from the tasks of the
+// workspace
+func (r *MemoryWorkspaceRepository) RemoveMember(workspaceID, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.members[workspaceID][userID]; !exists {
+		return ErrNotFound
+	}
+	delete(r.store.members[workspaceID], userID)
+
+	for id, task := range r.store.tasks {
+		if task.WorkspaceID != nil && *task.WorkspaceID == workspaceID && task.AssigneeID != nil && *task.AssigneeID == userID {
+			task.AssigneeID = nil
+			r.store.tasks[id] = task
+		}
+	}
+	return nil
+}
+
+// MemoryInvitationRepository is an in-memory InvitationRepository
+type MemoryInvitationRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new invitation
+func (r *MemoryInvitationRepository) Create(invitation *models.WorkspaceInvitation) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stamp(&invitation.ID, &invitation.CreatedAt, &invitation.UpdatedAt)
+	if _, exists := r.store.invitations[invitation.ID]; exists {
+		return ErrDuplicate
+	}
+
+	r.store.invitations[invitation.ID] = *invitation
+	return nil
+}
+
+// FindByID retrieves an invitation by ID
+func (r *MemoryInvitationRepository) FindByID(id uuid.UUID) (*models.WorkspaceInvitation, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	invitation, exists := r.store.invitations[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &invitation, nil
+}
+
+// ListByWorkspace retrieves the pending invitations of a workspace, newest first
+func (r *MemoryInvitationRepository) ListByWorkspace(workspaceID uuid.UUID, now time.Time) ([]models.WorkspaceInvitation, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	invitations := []models.WorkspaceInvitation{}
+	for _, invitation := range r.store.invitations {
+		if invitation.WorkspaceID == workspaceID && invitation.Pending(now) {
+			invitations = append(invitations, invitation)
+		}
+	}
+	sortInvitations(invitations)
+	return invitations, nil
+}
+
+// ListByEmail retrieves the pending invitations of an email address with
+// their workspaces, newest first
+func (r *MemoryInvitationRepository) ListByEmail(email string, now time.Time) ([]models.WorkspaceInvitation, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	invitations := []models.WorkspaceInvitation{}
+	for _, invitation := range r.store.invitations {
+		if invitation.Email == email && invitation.Pending(now) {
+			workspace := r.store.workspaces[invitation.WorkspaceID]
+			invitation.Workspace = &workspace
+			invitations = append(invitations, invitation)
+		}
+	}
+	sortInvitations(invitations)
+	return invitations, nil
+}
+
+// sortInvitations orders invitations newest first
+func sortInvitations(invitations []models.WorkspaceInvitation) {
+	sort.SliceStable(invitations, func(i, j int) bool {
+		return invitations[i].CreatedAt.After(invitations[j].CreatedAt)
+	})
+}
+
+// Update saves all fields of an invitation
+func (r *MemoryInvitationRepository) Update(invitation *models.WorkspaceInvitation) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.invitations[invitation.ID]; !exists {
+		return ErrNotFound
+	}
+
+	stored := *invitation
+	stored.Workspace = nil
+	r.store.invitations[invitation.ID] = stored
+	return nil
+}
+
+// Delete removes an invitation
+func (r *MemoryInvitationRepository) Delete(invitation *models.WorkspaceInvitation) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.invitations, invitation.ID)
+	return nil
+}
+
+// Accept sets AcceptedAt on a pending invitation and adds the member
+func (r *MemoryInvitationRepository) Accept(invitation *models.WorkspaceInvitation, member *models.WorkspaceMember, at time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, exists := r.store.invitations[invitation.ID]
+	if !exists || !stored.Pending(at) {
+		return false, nil
+	}
+	stored.AcceptedAt = &at
+	stored.UpdatedAt = at
+	r.store.invitations[invitation.ID] = stored
+
+	members, exists := r.store.members[stored.WorkspaceID]
+	if !exists {
+		return false, ErrNotFound
+	}
+	if _, isMember := members[member.UserID]; !isMember {
+		stamp(&member.WorkspaceID, &member.CreatedAt, &member.UpdatedAt)
+		members[member.UserID] = *member
+	}
+
+	invitation.AcceptedAt = &at
+	return true, nil
+}
+
+// Decline sets DeclinedAt on a pending invitation
+func (r *MemoryInvitationRepository) Decline(id uuid.UUID, at time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	invitation, exists := r.store.invitations[id]
+	if !exists || !invitation.Pending(at) {
+		return false, nil
+	}
+	invitation.DeclinedAt = &at
+	invitation.UpdatedAt = at
+	r.store.invitations[id] = invitation
+	return true, nil
+}
+
+// MemoryShareRepository is an in-memory ShareRepository
+type MemoryShareRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new share, returning ErrDuplicate if the task or project
+// is already shared with the user
+func (r *MemoryShareRepository) Create(share *models.Share) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.findShare(share.TaskID, share.ProjectID, share.UserID); exists {
+		return ErrDuplicate
+	}
+
+	stamp(&share.ID, &share.CreatedAt, &share.UpdatedAt)
+	stored := *share
+	stored.User = nil
+	r.store.shares[share.ID] = stored
+	return nil
+}
+
+// FindByTask retrieves the share of a task with a user
+func (r *MemoryShareRepository) FindByTask(taskID, userID uuid.UUID) (*models.Share, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	share, exists := r.store.findShare(&taskID, nil, userID)
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &share, nil
+}
+
+// FindByProject retrieves the share of a project with a user
+func (r *MemoryShareRepository) FindByProject(projectID, userID uuid.UUID) (*models.Share, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	share, exists := r.store.findShare(nil, &projectID, userID)
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &share, nil
+}
+
+// ListByTask retrieves the shares of a task with their users, oldest first
+func (r *MemoryShareRepository) ListByTask(taskID uuid.UUID) ([]models.Share, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.listShares(func(share models.Share) bool {
+		return share.TaskID != nil && *share.TaskID == taskID
+	}), nil
+}
+
+// ListByProject retrieves the shares of a project with their users, oldest first
+func (r *MemoryShareRepository) ListByProject(projectID uuid.UUID) ([]models.Share, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.listShares(func(share models.Share) bool {
+		return share.ProjectID != nil && *share.ProjectID == projectID
+	}), nil
+}
+
+// ListForTask retrieves the shares giving a user access to a task, directly
+// or through its project
+func (r *MemoryShareRepository) ListForTask(taskID uuid.UUID, projectID *uuid.UUID, userID uuid.UUID) ([]models.Share, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	shares := []models.Share{}
+	if share, exists := r.store.findShare(&taskID, nil, userID); exists {
+		shares = append(shares, share)
+	}
+	if projectID != nil {
+		if share, exists := r.store.findShare(nil, projectID, userID); exists {
+			shares = append(shares, share)
+		}
+	}
+	return shares, nil
+}
+
+// Update saves the permission of a share
+func (r *MemoryShareRepository) Update(share *models.Share) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.shares[share.ID]; !exists {
+		return ErrNotFound
+	}
+
+	stored := *share
+	stored.User = nil
+	r.store.shares[share.ID] = stored
+	return nil
+}
+
+// Delete removes a share
+func (r *MemoryShareRepository) Delete(share *models.Share) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.shares, share.ID)
+	return nil
+}
+
+// findShare retrieves the share of a task or a project with a user; the
+// caller must hold the lock
+func (s *memoryStore) findShare(taskID, projectID *uuid.UUID, userID uuid.UUID) (models.Share, bool) {
+	for _, share := range s.shares {
+		if share.UserID != userID {
+			continue
+		}
+		if taskID != nil && share.TaskID != nil && *share.TaskID == *taskID {
+			return share, true
+		}
+		if projectID != nil && share.ProjectID != nil && *share.ProjectID == *projectID {
+			return share, true
+		}
+	}
+	return models.Share{}, false
+}
+
+// listShares retrieves the shares matching a predicate with their users,
+// oldest first; the caller must hold the lock
+func (s *memoryStore) listShares(match func(models.Share) bool) []models.Share {
+	shares := []models.Share{}
+	for _, share := range s.shares {
+		if !match(share) {
+			continue
+		}
+		if user, exists := s.users[share.UserID]; exists && !user.DeletedAt.Valid {
+			share.User = &user
+		}
+		shares = append(shares, share)
+	}
+	sort.SliceStable(shares, func(i, j int) bool {
+		return shares[i].CreatedAt.Before(shares[j].CreatedAt)
+	})
+	return shares
+}
+
+// sharedWith reports whether a task was shared with a user, directly or
+// through its project; the caller must hold the lock
+func (s *memoryStore) sharedWith(task models.Task, userID uuid.UUID) bool {
+	_, exists := s.findShare(&task.ID, task.ProjectID, userID)
+	return exists
+}
+
+// MemoryCommentRepository is an in-memory CommentRepository
+type MemoryCommentRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new comment
+func (r *MemoryCommentRepository) Create(comment *models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stamp(&comment.ID, &comment.CreatedAt, &comment.UpdatedAt)
+	stored := *comment
+	stored.User = nil
+	r.store.comments[comment.ID] = stored
+	return nil
+}
+
+// FindByID retrieves a comment by ID
+func (r *MemoryCommentRepository) FindByID(id uuid.UUID) (*models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	comment, exists := r.store.comments[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &comment, nil
+}
+
+// ListByTask retrieves up to limit comments of a task with their authors,
+// oldest first, starting after the cursor when one is given
+func (r *MemoryCommentRepository) ListByTask(taskID uuid.UUID, after *CommentCursor, limit int) ([]models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	comments := []models.Comment{}
+	for _, comment := range r.store.comments {
+		if comment.TaskID != taskID {
+			continue
+		}
+		if after != nil && !commentAfter(comment, *after) {
+			continue
+		}
+		if user, exists := r.store.users[comment.UserID]; exists && !user.DeletedAt.Valid {
+			comment.User = &user
+		}
+		comments = append(comments, comment)
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return commentAfter(comments[j], CommentCursor{CreatedAt: comments[i].CreatedAt, ID: comments[i].ID})
+	})
+	return paginate(comments, limit, 0), nil
+}
+
+// commentAfter reports whether a comment comes after a cursor in creation
+// order, with the ID breaking ties
+func commentAfter(comment models.Comment, cursor CommentCursor) bool {
+	if !comment.CreatedAt.Equal(cursor.CreatedAt) {
+		return comment.CreatedAt.After(cursor.CreatedAt)
+	}
+	return strings.Compare(comment.ID.String(), cursor.ID.String()) > 0
+}
+
+// Update saves all fields of a comment
+func (r *MemoryCommentRepository) Update(comment *models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.comments[comment.ID]; !exists {
+		return ErrNotFound
+	}
+
+	stored := *comment
+	stored.User = nil
+	r.store.comments[comment.ID] = stored
+	return nil
+}
+
+// Delete removes a comment
+func (r *MemoryCommentRepository) Delete(comment *models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.comments, comment.ID)
+	return nil
+}
+
+// MemoryAttachmentRepository is an in-memory AttachmentRepository
+type MemoryAttachmentRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new attachment
+func (r *MemoryAttachmentRepository) Create(attachment *models.Attachment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stamp(&attachment.ID, &attachment.CreatedAt, nil)
+	r.store.attachments[attachment.ID] = *attachment
+	return nil
+}
+
+// FindByID retrieves an attachment by ID
+func (r *MemoryAttachmentRepository) FindByID(id uuid.UUID) (*models.Attachment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	attachment, exists := r.store.attachments[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &attachment, nil
+}
+
+// ListByTask retrieves the attachments of a task, oldest first, whether or
+// not the task still exists
+func (r *MemoryAttachmentRepository) ListByTask(taskID uuid.UUID) ([]models.Attachment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.listAttachments(func(attachment models.Attachment) bool {
+		return attachment.TaskID == taskID
+	}, 0), nil
+}
+
+// ListOrphaned retrieves up to limit attachments whose task was deleted
+func (r *MemoryAttachmentRepository) ListOrphaned(limit int) ([]models.Attachment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.listAttachments(func(attachment models.Attachment) bool {
+		_, exists := r.store.tasks[attachment.TaskID]
+		return !exists
+	}, limit), nil
+}
+
+// Delete removes an attachment
+func (r *MemoryAttachmentRepository) Delete(attachment *models.Attachment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.attachments, attachment.ID)
+	return nil
+}
+
+// listAttachments returns up to limit matching attachments, oldest first;
+// the caller must hold the lock
+func (s *memoryStore) listAttachments(match func(models.Attachment) bool, limit int) []models.Attachment {
+	attachments := []models.Attachment{}
+	for _, attachment := range s.attachments {
+		if match(attachment) {
+			attachments = append(attachments, attachment)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].CreatedAt.Before(attachments[j].CreatedAt)
+	})
+	return paginate(attachments, limit, 0)
+}
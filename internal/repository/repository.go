@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when a record does not exist
+	ErrNotFound = errors.New("record not found")
+
+	// ErrDuplicate is returned when a record violates a uniqueness constraint
+	ErrDuplicate = errors.New("duplicate record")
+)
+
+// TaskFilter holds the filters and pagination applied when listing tasks
+type TaskFilter struct {
+	UserID   uuid.UUID
+	Status   string
+	Priority int // -1 disables the priority filter
+	Limit    int
+	Offset   int
+
+	// Tags restricts the result to tasks carrying the named tags: any of
+	// them by default, or all of them when MatchAllTags is set
+	Tags         []string
+	MatchAllTags bool
+
+	// ProjectID restricts the result to the tasks of one project. Tasks of
+	// archived projects are hidden unless IncludeArchived is set or the
+	// archived project is requested explicitly.
+	ProjectID       *uuid.UUID
+	IncludeArchived bool
+
+	// Blocked restricts the result to tasks that have (true) or do not
+	// have (false) an open blocker
+	Blocked *bool
+
+	// WorkspaceID lists the tasks of a workspace instead of the personal
+	// tasks of UserID
+	WorkspaceID *uuid.UUID
+
+	// AssigneeID restricts the result to the tasks assigned to a user.
+	// Without WorkspaceID it covers that user's tasks in every workspace
+	// instead of the personal tasks of UserID.
+	AssigneeID *uuid.UUID
+
+	// SharedWithMe lists the tasks other users shared with UserID, directly
+	// or through a project, instead of UserID's own tasks
+	SharedWithMe bool
+
+	// Search restricts the result to tasks whose title or description
+	// contains every word of the query, in full or as the start of a word
+	Search string
+}
+
+// maxSearchTerms is how many words of a search query are used
+const maxSearchTerms = 16
+
+// SearchTerms splits a search query into its distinct lowercased words,
+// ignoring punctuation
+func SearchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := uniqueStrings(words)
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	return terms
+}
+
+// TaskMatch is a task found by a search, with its relevance and the parts of
+// its text that matched. Matching words are wrapped in <mark> and </mark>;
+// the rest of the text is returned as stored.
+type TaskMatch struct {
+	Task models.Task
+	Rank float64
+
+	// TitleHighlight is the title with the matching words marked
+	TitleHighlight string
+
+	// Snippet is the part of the description around the matching words, with
+	// those words marked; it is empty when only the title matched
+	Snippet string
+}
+
+// TaskRepository persists tasks
+type TaskRepository interface {
+	Create(task *models.Task) error
+	FindByID(id uuid.UUID) (*models.Task, error)
+	ListChildren(parentID uuid.UUID) ([]models.Task, error)
+	List(filter TaskFilter) ([]models.Task, error)
+	Count(filter TaskFilter) (int64, error)
+	// Search retrieves the tasks matching the filter and its Search query,
+	// best match first
+	Search(filter TaskFilter) ([]TaskMatch, error)
+	Update(task *models.Task) error
+	Delete(task *models.Task) error
+}
+
+// UserFilter holds the filters and pagination applied when listing users
+type UserFilter struct {
+	// Query matches part of the email, first or last name, ignoring case
+	Query  string
+	Role   string
+	Active *bool
+	Limit  int
+	Offset int
+}
+
+// UserRepository persists users
+type UserRepository interface {
+	// Create inserts a user, returning ErrDuplicate if the email is taken
+	Create(user *models.User) error
+	FindByID(id uuid.UUID) (*models.User, error)
+	// FindByEmail retrieves a user by email, ignoring case
+	FindByEmail(email string) (*models.User, error)
+	// EmailTaken reports whether any user has the email, ignoring case and
+	// including deleted users whose data is not purged yet
+	EmailTaken(email string) (bool, error)
+	// List retrieves users matching the filter, newest first
+	List(filter UserFilter) ([]models.User, error)
+	Count(filter UserFilter) (int64, error)
+	Update(user *models.User) error
+	// AdvanceTOTPCounter records counter as the time step of the last TOTP
+	// code a user had accepted, and reports whether it did: it does not if
+	// that counter was already reached
+	AdvanceTOTPCounter(id uuid.UUID, counter int64, at time.Time) (bool, error)
+	// Delete soft-deletes a user together with their personal tasks and
+	// projects
+	Delete(user *models.User) error
+	// Purge permanently removes the data of a soft-deleted user and
+	// anonymizes the user record; ErrNotFound if the user is not deleted.
+	// The user leaves their workspaces, and workspaces without other
+	// members are removed with their tasks. Shares by and with the user
+	// and their comments are removed.
+	Purge(id uuid.UUID) error
+}
+
+// ActivityRepository persists user activity logs
+type ActivityRepository interface {
+	Create(activity *models.Activity) error
+	ListByUser(userID uuid.UUID, limit, offset int) ([]models.Activity, error)
+}
+
+// TagRepository persists tags and their attachment to tasks
+type TagRepository interface {
+	Create(tag *models.Tag) error
+	FindByID(id uuid.UUID) (*models.Tag, error)
+	FindByName(userID uuid.UUID, name string) (*models.Tag, error)
+	ListByUser(userID uuid.UUID) ([]models.Tag, error)
+	Update(tag *models.Tag) error
+	Delete(tag *models.Tag) error
+	Attach(taskID, tagID uuid.UUID) error
+	Detach(taskID, tagID uuid.UUID) error
+}
+
+// ProjectRepository persists projects
+type ProjectRepository interface {
+	Create(project *models.Project) error
+	FindByID(id uuid.UUID) (*models.Project, error)
+	ListByUser(userID uuid.UUID, includeArchived bool) ([]models.Project, error)
+	Update(project *models.Project) error
+	Delete(project *models.Project) error
+}
+
+// DependencyRepository persists blocked-by edges between tasks
+type DependencyRepository interface {
+	Add(dependency *models.TaskDependency) error
+	Remove(taskID, blockedByID uuid.UUID) error
+	ListBlockers(taskID uuid.UUID) ([]models.Task, error)
+	ListDependents(taskID uuid.UUID) ([]models.Task, error)
+}
+
+// RefreshTokenRepository persists refresh tokens
+type RefreshTokenRepository interface {
+	Create(token *models.RefreshToken) error
+	FindByHash(hash string) (*models.RefreshToken, error)
+	// MarkUsed sets UsedAt on a token that is neither used nor revoked and
+	// reports whether it did, so only one of concurrent rotations wins
+	MarkUsed(id uuid.UUID, at time.Time) (bool, error)
+	RevokeFamily(familyID uuid.UUID, at time.Time) error
+	RevokeUser(userID uuid.UUID, at time.Time) error
+}
+
+// UserTokenRepository persists email verification and password reset tokens
+type UserTokenRepository interface {
+	Create(token *models.UserToken) error
+	FindByHash(hash string) (*models.UserToken, error)
+	// MarkUsed sets UsedAt on an unused token and reports whether it did, so
+	// a token can only be redeemed once
+	MarkUsed(id uuid.UUID, at time.Time) (bool, error)
+	// ExpireUser marks the unused tokens of a user with the given purpose as used
+	ExpireUser(userID uuid.UUID, purpose string, at time.Time) error
+}
+
+// RecoveryCodeRepository persists two-factor recovery codes
+type RecoveryCodeRepository interface {
+	// Replace deletes the codes of a user and stores new ones in their place
+	Replace(userID uuid.UUID, codes []models.RecoveryCode) error
+	// Redeem sets UsedAt on an unused code of a user and reports whether it
+	// did, so a code can only be used once
+	Redeem(userID uuid.UUID, hash string, at time.Time) (bool, error)
+	CountUnused(userID uuid.UUID) (int64, error)
+	DeleteUser(userID uuid.UUID) error
+}
+
+// APITokenRepository persists personal access tokens
+type APITokenRepository interface {
+	Create(token *models.APIToken) error
+	FindByID(id uuid.UUID) (*models.APIToken, error)
+	FindByHash(hash string) (*models.APIToken, error)
+	ListByUser(userID uuid.UUID) ([]models.APIToken, error)
+	Update(token *models.APIToken) error
+	Delete(token *models.APIToken) error
+	// Touch sets LastUsedAt without changing UpdatedAt
+	Touch(id uuid.UUID, at time.Time) error
+}
+
+// RoleRepository persists custom roles
+type RoleRepository interface {
+	Create(role *models.Role) error
+	FindByName(name string) (*models.Role, error)
+	List() ([]models.Role, error)
+	Update(role *models.Role) error
+	Delete(role *models.Role) error
+}
+
+// WorkspaceRepository persists workspaces and their members
+type WorkspaceRepository interface {
+	// Create inserts a workspace together with its owner
+	Create(workspace *models.Workspace, owner *models.WorkspaceMember) error
+	FindByID(id uuid.UUID) (*models.Workspace, error)
+	// ListByUser retrieves the workspaces a user is a member of, ordered by
+	// name, with the user's role
+	ListByUser(userID uuid.UUID) ([]models.Workspace, error)
+	Update(workspace *models.Workspace) error
+	// Delete soft-deletes a workspace with its tasks and removes its
+	// members and invitations
+	Delete(workspace *models.Workspace) error
+
+	FindMember(workspaceID, userID uuid.UUID) (*models.WorkspaceMember, error)
+	// ListMembers retrieves the members of a workspace with their users,
+	// oldest first
+	ListMembers(workspaceID uuid.UUID) ([]models.WorkspaceMember, error)
+	UpdateMember(member *models.WorkspaceMember) error
+	// TransferOwnership makes a member the owner of a workspace and the
+	// owner an admin in one step. It returns ErrNotFound, changing nothing,
+	// if ownerID is no longer the owner or memberID no longer a member.
+	TransferOwnership(workspaceID, ownerID, memberID uuid.UUID, at time.Time) error
+	// RemoveMember removes a member and unassigns them from the tasks of
+	// the workspace
+	RemoveMember(workspaceID, userID uuid.UUID) error
+}
+
+// InvitationRepository persists workspace invitations
+type InvitationRepository interface {
+	Create(invitation *models.WorkspaceInvitation) error
+	FindByID(id uuid.UUID) (*models.WorkspaceInvitation, error)
+	// ListByWorkspace retrieves the pending invitations of a workspace,
+	// newest first
+	ListByWorkspace(workspaceID uuid.UUID, now time.Time) ([]models.WorkspaceInvitation, error)
+	// ListByEmail retrieves the pending invitations of an email address
+	// with their workspaces, newest first
+	ListByEmail(email string, now time.Time) ([]models.WorkspaceInvitation, error)
+	Update(invitation *models.WorkspaceInvitation) error
+	Delete(invitation *models.WorkspaceInvitation) error
+	// Accept sets AcceptedAt on a pending invitation and adds the member,
+	// reporting whether the invitation was still pending. A user who is a
+	// member already keeps their role.
+	Accept(invitation *models.WorkspaceInvitation, member *models.WorkspaceMember, at time.Time) (bool, error)
+	// Decline sets DeclinedAt on a pending invitation and reports whether it
+	// was still pending
+	Decline(id uuid.UUID, at time.Time) (bool, error)
+}
+
+// ShareRepository persists the shares of tasks and projects with other users
+type ShareRepository interface {
+	// Create inserts a share, returning ErrDuplicate if the task or project
+	// is already shared with the user
+	Create(share *models.Share) error
+	FindByTask(taskID, userID uuid.UUID) (*models.Share, error)
+	FindByProject(projectID, userID uuid.UUID) (*models.Share, error)
+	// ListByTask and ListByProject retrieve the shares of a task or project
+	// with their users, oldest first
+	ListByTask(taskID uuid.UUID) ([]models.Share, error)
+	ListByProject(projectID uuid.UUID) ([]models.Share, error)
+	// ListForTask retrieves the shares giving a user access to a task,
+	// directly or through the project it belongs to
+	ListForTask(taskID uuid.UUID, projectID *uuid.UUID, userID uuid.UUID) ([]models.Share, error)
+	Update(share *models.Share) error
+	Delete(share *models.Share) error
+}
+
+// CommentCursor marks the last comment of a page; the next page starts
+// after it
+type CommentCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// CommentRepository persists task comments
+type CommentRepository interface {
+	Create(comment *models.Comment) error
+	FindByID(id uuid.UUID) (*models.Comment, error)
+	// ListByTask retrieves up to limit comments of a task with their
+	// authors, oldest first, starting after the cursor when one is given
+	ListByTask(taskID uuid.UUID, after *CommentCursor, limit int) ([]models.Comment, error)
+	Update(comment *models.Comment) error
+	// Delete soft-deletes a comment
+	Delete(comment *models.Comment) error
+}
+
+// AttachmentRepository persists the files attached to tasks
+type AttachmentRepository interface {
+	Create(attachment *models.Attachment) error
+	FindByID(id uuid.UUID) (*models.Attachment, error)
+	// ListByTask retrieves the attachments of a task, oldest first, whether
+	// or not the task still exists
+	ListByTask(taskID uuid.UUID) ([]models.Attachment, error)
+	// ListOrphaned retrieves up to limit attachments whose task was deleted
+	ListOrphaned(limit int) ([]models.Attachment, error)
+	Delete(attachment *models.Attachment) error
+}
+
+// Repositories bundles every repository used by the services
+type Repositories struct {
+	Tasks        TaskRepository
+	Users        UserRepository
+	Activities   ActivityRepository
+	Tags         TagRepository
+	Projects     ProjectRepository
+	Dependencies DependencyRepository
+	Tokens       RefreshTokenRepository
+	UserTokens   UserTokenRepository
+	Recovery     RecoveryCodeRepository
+	APITokens    APITokenRepository
+	Roles        RoleRepository
+	Workspaces   WorkspaceRepository
+	Invitations  InvitationRepository
+	Shares       ShareRepository
+	Comments     CommentRepository
+	Attachments  AttachmentRepository
+}
+
+// AnonymizedEmail is the email a purged user record is left with
+func AnonymizedEmail(id uuid.UUID) string {
+	return "deleted-" + id.String() + "@deleted.invalid"
+}
+
+// uniqueStrings returns the distinct values of a slice, preserving order
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
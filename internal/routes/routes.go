@@ -4,30 +4,81 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jaimesHub/golang-todo-app/internal/config"
 	"github.com/jaimesHub/golang-todo-app/internal/handlers"
+	"github.com/jaimesHub/golang-todo-app/internal/logger"
 	"github.com/jaimesHub/golang-todo-app/internal/middleware"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
 	"github.com/jaimesHub/golang-todo-app/internal/services"
 	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
+	"github.com/jaimesHub/golang-todo-app/internal/services/queue"
 	redisService "github.com/jaimesHub/golang-todo-app/internal/services/redis"
-	"gorm.io/gorm"
+	"github.com/jaimesHub/golang-todo-app/internal/services/storage"
 )
 
-// Register sets up all API routes
-func Register(router *gin.Engine, db *gorm.DB, redisClient *redisService.Client, cfg *config.Config) {
+// Register sets up all API routes. taskQueue may be nil, in which case no
+// due-date reminders are scheduled. Task attachments are kept in fileStorage.
+func Register(router *gin.Engine, repos *repository.Repositories, redisClient *redisService.Client, taskQueue *queue.Queue, fileStorage storage.Storage, jwtService *auth.JWTService, cfg *config.Config, log *logger.Logger) {
 	// Create services
-	userService := services.NewUserService(db)
-	jwtService := auth.NewJWTService(&cfg.JWT)
-	taskService := services.NewTaskService(db)
+	userService := services.NewUserService(repos)
+	var denylist auth.Denylist
+	var attempts auth.AttemptStore
+	if redisClient != nil {
+		denylist = auth.NewRedisDenylist(redisClient)
+		attempts = auth.NewRedisAttemptStore(redisClient)
+	} else {
+		log.Warn("Redis unavailable, revoked access tokens and failed logins are tracked in memory by this instance only")
+		denylist = auth.NewMemoryDenylist()
+		attempts = auth.NewMemoryAttemptStore()
+	}
+	authService := services.NewAuthService(repos, jwtService, denylist)
+	authService.SetLoginLimiter(auth.NewLoginLimiter(attempts, cfg.Login))
+	accountService := services.NewAccountService(repos, authService, cfg.Account)
+	if taskQueue != nil {
+		accountService.SetQueue(taskQueue, cfg.Queue.Name)
+	} else {
+		log.Warn("Task queue unavailable, account emails are not sent and deleted accounts are not purged")
+	}
+	twoFactorService := services.NewTwoFactorService(repos, authService, cfg.TwoFactor)
+	taskService := services.NewTaskService(repos)
+	if taskQueue != nil {
+		taskService.SetScheduler(taskQueue, cfg.Queue.Name)
+		taskService.SetQueue(taskQueue, cfg.Queue.Name)
+	}
+	tagService := services.NewTagService(repos)
+	projectService := services.NewProjectService(repos)
+	apiTokenService := services.NewAPITokenService(repos)
+	roleService := services.NewRoleService(repos)
+	adminService := services.NewAdminService(repos, authService, roleService)
+	workspaceService := services.NewWorkspaceService(repos, cfg.Workspace)
+	shareService := services.NewShareService(repos)
+	commentService := services.NewCommentService(repos, taskService)
+	attachmentService := services.NewAttachmentService(repos, taskService, fileStorage, cfg.Attachment)
+	if taskQueue != nil {
+		workspaceService.SetQueue(taskQueue, cfg.Queue.Name)
+		commentService.SetQueue(taskQueue, cfg.Queue.Name)
+	}
 
 	// Create handlers with dependencies
-	userHandler := handlers.NewUserHandler(userService)
-	authHandler := handlers.NewAuthHandler(userService, jwtService)
-	taskHandler := handlers.NewTaskHandler(taskService, userService)
+	userHandler := handlers.NewUserHandler(userService, accountService)
+	authHandler := handlers.NewAuthHandler(userService, authService, accountService, twoFactorService)
+	accountHandler := handlers.NewAccountHandler(userService, accountService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService, userService)
+	taskHandler := handlers.NewTaskHandler(taskService, userService, tagService, workspaceService)
+	tagHandler := handlers.NewTagHandler(tagService, userService)
+	projectHandler := handlers.NewProjectHandler(projectService, taskService, userService)
+	apiTokenHandler := handlers.NewAPITokenHandler(apiTokenService, userService)
+	adminHandler := handlers.NewAdminHandler(adminService, roleService, userService)
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService, userService)
+	shareHandler := handlers.NewShareHandler(shareService, userService)
+	commentHandler := handlers.NewCommentHandler(commentService, userService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, userService, cfg.Attachment.MaxSize)
 
-	// Setup middleware
-	middleware.Setup(router, cfg, jwtService)
+	// Admin permission checks
+	canReadUsers := middleware.RequirePermission(roleService, auth.PermissionUsersRead, log)
+	canManageUsers := middleware.RequirePermission(roleService, auth.PermissionUsersManage, log)
+	canManageRoles := middleware.RequirePermission(roleService, auth.PermissionRolesManage, log)
 
-	// Health check route
-	router.GET("/health", handlers.HealthCheck)
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
@@ -37,29 +88,142 @@ func Register(router *gin.Engine, db *gorm.DB, redisClient *redisService.Client,
 		{
 			auth.POST("/register", userHandler.Register)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/2fa", authHandler.LoginTwoFactor)
 			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/verify-email", accountHandler.VerifyEmail)
+			auth.POST("/resend-verification", accountHandler.ResendVerification)
+			auth.POST("/forgot-password", accountHandler.ForgotPassword)
+			auth.POST("/reset-password", accountHandler.ResetPassword)
 		}
 
-		// Protected routes - authentication required
+		// Protected routes - authentication required, by session or by API
+		// token, for active users. API tokens are limited to the scope of
+		// each group and cannot manage credentials or sessions.
 		protected := v1.Group("/")
-		protected.Use(middleware.AuthMiddleware(jwtService))
+		protected.Use(middleware.AuthMiddleware(jwtService, denylist, apiTokenService, userService, log))
+		sessionOnly := middleware.RequireSession()
 		{
+			// Session routes
+			protected.POST("/auth/logout", sessionOnly, authHandler.Logout)
+			protected.POST("/auth/logout-all", sessionOnly, authHandler.LogoutAll)
+
 			// User routes
-			users := protected.Group("/users")
+			users := protected.Group("/users", middleware.RequireScope("user"))
 			{
 				users.GET("/me", userHandler.GetProfile)
 				users.PUT("/me", userHandler.UpdateProfile)
+				users.DELETE("/me", sessionOnly, userHandler.DeleteAccount)
+				users.PUT("/me/password", sessionOnly, userHandler.ChangePassword)
+				users.PUT("/me/email", sessionOnly, userHandler.ChangeEmail)
+				users.GET("/me/2fa", sessionOnly, twoFactorHandler.Status)
+				users.POST("/me/2fa/enroll", sessionOnly, twoFactorHandler.Enroll)
+				users.POST("/me/2fa/confirm", sessionOnly, twoFactorHandler.Confirm)
+				users.POST("/me/2fa/recovery-codes", sessionOnly, twoFactorHandler.RegenerateRecoveryCodes)
+				users.DELETE("/me/2fa", sessionOnly, twoFactorHandler.Disable)
 				users.GET("/activities", userHandler.GetActivities)
 			}
 
+			// Personal access token routes
+			tokens := users.Group("/me/tokens", sessionOnly)
+			{
+				tokens.POST("/", apiTokenHandler.Create)
+				tokens.GET("/", apiTokenHandler.List)
+				tokens.GET("/:id", apiTokenHandler.GetByID)
+				tokens.PUT("/:id", apiTokenHandler.Update)
+				tokens.DELETE("/:id", apiTokenHandler.Delete)
+			}
+
 			// Task routes
-			tasks := protected.Group("/tasks")
+			tasks := protected.Group("/tasks", middleware.RequireScope("tasks"))
 			{
 				tasks.POST("/", taskHandler.Create)
 				tasks.GET("/", taskHandler.List)
+				tasks.GET("/search", taskHandler.Search)
 				tasks.GET("/:id", taskHandler.GetByID)
 				tasks.PUT("/:id", taskHandler.Update)
 				tasks.DELETE("/:id", taskHandler.Delete)
+				tasks.POST("/:id/tags", taskHandler.AttachTag)
+				tasks.DELETE("/:id/tags/:tagId", taskHandler.DetachTag)
+				tasks.GET("/:id/occurrences", taskHandler.ListOccurrences)
+				tasks.GET("/:id/dependencies", taskHandler.ListDependencies)
+				tasks.POST("/:id/dependencies", taskHandler.AddDependency)
+				tasks.DELETE("/:id/dependencies/:blockerId", taskHandler.RemoveDependency)
+				tasks.GET("/:id/shares", shareHandler.ListTaskShares)
+				tasks.POST("/:id/shares", shareHandler.ShareTask)
+				tasks.DELETE("/:id/shares/:userId", shareHandler.UnshareTask)
+				tasks.GET("/:id/comments", commentHandler.List)
+				tasks.POST("/:id/comments", commentHandler.Create)
+				tasks.PUT("/:id/comments/:commentId", commentHandler.Update)
+				tasks.DELETE("/:id/comments/:commentId", commentHandler.Delete)
+				tasks.GET("/:id/attachments", attachmentHandler.List)
+				tasks.POST("/:id/attachments", attachmentHandler.Upload)
+				tasks.GET("/:id/attachments/:attachmentId", attachmentHandler.Download)
+				tasks.DELETE("/:id/attachments/:attachmentId", attachmentHandler.Delete)
+			}
+
+			// Tag routes
+			tags := protected.Group("/tags", middleware.RequireScope("tags"))
+			{
+				tags.POST("/", tagHandler.Create)
+				tags.GET("/", tagHandler.List)
+				tags.GET("/:id", tagHandler.GetByID)
+				tags.PUT("/:id", tagHandler.Update)
+				tags.DELETE("/:id", tagHandler.Delete)
+			}
+
+			// Project routes
+			projects := protected.Group("/projects", middleware.RequireScope("projects"))
+			{
+				projects.POST("/", projectHandler.Create)
+				projects.GET("/", projectHandler.List)
+				projects.GET("/:id", projectHandler.GetByID)
+				projects.PUT("/:id", projectHandler.Update)
+				projects.DELETE("/:id", projectHandler.Delete)
+				projects.GET("/:id/tasks", projectHandler.ListTasks)
+				projects.GET("/:id/shares", shareHandler.ListProjectShares)
+				projects.POST("/:id/shares", shareHandler.ShareProject)
+				projects.DELETE("/:id/shares/:userId", shareHandler.UnshareProject)
+			}
+
+			// Workspace routes
+			workspaces := protected.Group("/workspaces", middleware.RequireScope("workspaces"))
+			{
+				workspaces.POST("/", workspaceHandler.Create)
+				workspaces.GET("/", workspaceHandler.List)
+				workspaces.GET("/:id", workspaceHandler.GetByID)
+				workspaces.PUT("/:id", workspaceHandler.Update)
+				workspaces.DELETE("/:id", workspaceHandler.Delete)
+				workspaces.GET("/:id/members", workspaceHandler.ListMembers)
+				workspaces.PUT("/:id/members/:userId", workspaceHandler.UpdateMember)
+				workspaces.DELETE("/:id/members/:userId", workspaceHandler.RemoveMember)
+				workspaces.POST("/:id/invitations", workspaceHandler.Invite)
+				workspaces.GET("/:id/invitations", workspaceHandler.ListInvitations)
+				workspaces.DELETE("/:id/invitations/:invitationId", workspaceHandler.RevokeInvitation)
+			}
+
+			// Invitation routes - invitations sent to the current user
+			invitations := protected.Group("/invitations", middleware.RequireScope("workspaces"))
+			{
+				invitations.GET("/", workspaceHandler.ListMyInvitations)
+				invitations.POST("/:id/accept", workspaceHandler.AcceptInvitation)
+				invitations.POST("/:id/decline", workspaceHandler.DeclineInvitation)
+			}
+
+			// Admin routes - sessions only, each guarded by a permission of
+			// the user's role
+			admin := protected.Group("/admin", sessionOnly)
+			{
+				admin.GET("/users", canReadUsers, adminHandler.ListUsers)
+				admin.GET("/users/:id", canReadUsers, adminHandler.GetUser)
+				admin.GET("/users/:id/activities", canReadUsers, adminHandler.GetUserActivities)
+				admin.POST("/users/:id/deactivate", canManageUsers, adminHandler.Deactivate)
+				admin.POST("/users/:id/reactivate", canManageUsers, adminHandler.Reactivate)
+				admin.POST("/users/:id/logout", canManageUsers, adminHandler.ForceLogout)
+				admin.PUT("/users/:id/role", canManageRoles, adminHandler.SetRole)
+				admin.GET("/roles", canManageRoles, adminHandler.ListRoles)
+				admin.POST("/roles", canManageRoles, adminHandler.CreateRole)
+				admin.PUT("/roles/:name", canManageRoles, adminHandler.UpdateRole)
+				admin.DELETE("/roles/:name", canManageRoles, adminHandler.DeleteRole)
 			}
 		}
 	}
@@ -0,0 +1,479 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidUserToken is returned for unknown, expired or used email links
+	ErrInvalidUserToken = errors.New("invalid or expired token")
+
+	// ErrAccountDisabled is returned when a deactivated user logs in
+	ErrAccountDisabled = errors.New("account is disabled")
+
+	// ErrEmailNotVerified is returned when an unverified user logs in while
+	// verification is required
+	ErrEmailNotVerified = errors.New("email address not verified")
+
+	// ErrMailUnavailable is returned when no queue is configured to send emails
+	ErrMailUnavailable = errors.New("email delivery is unavailable")
+
+	// ErrWrongPassword is returned when the current password does not match
+	ErrWrongPassword = errors.New("current password is incorrect")
+
+	// ErrEmailUnchanged is returned when changing the email to the current one
+	ErrEmailUnchanged = errors.New("email address is unchanged")
+
+	// ErrOwnsWorkspaces is returned when deleting an account that still owns
+	// workspaces with other members
+	ErrOwnsWorkspaces = errors.New("transfer or delete the workspaces you own first")
+)
+
+// AccountQueue enqueues account emails and schedules account purges;
+// *queue.Queue implements it
+type AccountQueue interface {
+	jobs.Enqueuer
+	jobs.Scheduler
+}
+
+// AccountService handles email verification, password recovery and changes
+// to credentials and accounts
+type AccountService struct {
+	repos     *repository.Repositories
+	auth      *AuthService
+	cfg       config.AccountConfig
+	queue     AccountQueue
+	queueName string
+}
+
+// NewAccountService creates a new account service
+func NewAccountService(repos *repository.Repositories, authService *AuthService, cfg config.AccountConfig) *AccountService {
+	return &AccountService{repos: repos, auth: authService, cfg: cfg}
+}
+
+// SetQueue enables sending emails and purging deleted accounts through jobs
+// on the named queue. Without a queue, no emails are sent and deleted
+// accounts are not purged.
+func (s *AccountService) SetQueue(q AccountQueue, queueName string) {
+	s.queue = q
+	s.queueName = queueName
+}
+
+// CheckLogin reports whether a user whose password matched may log in
+func (s *AccountService) CheckLogin(user *models.User) error {
+	if !user.IsActive {
+		return ErrAccountDisabled
+	}
+	if s.cfg.RequireEmailVerification && user.EmailVerifiedAt == nil {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// SendVerification emails a verification link to an unverified user. Links
+// sent earlier stop working.
+func (s *AccountService) SendVerification(ctx context.Context, user *models.User) error {
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	if s.queue == nil {
+		return ErrMailUnavailable
+	}
+
+	token, err := s.issueToken(user.ID, models.TokenPurposeVerifyEmail, s.cfg.VerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening this link:\n\n%s/verify-email?token=%s\n\nThe link expires in %s.\n",
+		greetingName(user), s.cfg.AppURL, token, formatTTL(s.cfg.VerificationTokenTTL))
+	return s.sendEmail(ctx, user, user.Email, "Verify your email address", body)
+}
+
+// ResendVerification emails a new verification link to the unverified
+// account with the given email. Unknown emails are ignored so callers cannot
+// tell which accounts exist.
+func (s *AccountService) ResendVerification(ctx context.Context, email string) error {
+	if s.queue == nil {
+		return ErrMailUnavailable
+	}
+
+	user, err := s.repos.Users.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return s.SendVerification(ctx, user)
+}
+
+// VerifyEmail redeems an email verification token. Tokens sent for an email
+// change also switch the account to the new address.
+func (s *AccountService) VerifyEmail(token string) (*models.User, error) {
+	userToken, err := s.redeemToken(token, models.TokenPurposeVerifyEmail, models.TokenPurposeChangeEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repos.Users.FindByID(userToken.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if userToken.Purpose == models.TokenPurposeChangeEmail {
+		if user.PendingEmail == nil {
+			return nil, ErrInvalidUserToken
+		}
+
+		// The address may have been taken since the change was requested
+		if taken, err := s.repos.Users.EmailTaken(*user.PendingEmail); err != nil {
+			return nil, err
+		} else if taken {
+			return nil, ErrEmailTaken
+		}
+
+		user.Email = *user.PendingEmail
+		user.PendingEmail = nil
+		user.EmailVerifiedAt = &now
+	} else if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	} else {
+		return user, nil
+	}
+
+	user.UpdatedAt = now
+	if err := s.repos.Users.Update(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ChangePassword sets a new password after checking the current one. Every
+// session is ended and a new one is started for the caller.
+func (s *AccountService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) (*TokenPair, error) {
+	user, err := s.authenticate(userID, currentPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.Password = string(hashedPassword)
+	user.UpdatedAt = now
+	if err := s.repos.Users.Update(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.UserTokens.ExpireUser(user.ID, models.TokenPurposeResetPassword, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.RevokeSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	return s.auth.IssueTokens(user.ID)
+}
+
+// ChangeEmail starts changing a user's email address after checking their
+// password. The address changes once the link emailed to it is opened.
+func (s *AccountService) ChangeEmail(ctx context.Context, userID uuid.UUID, password, email string) (*models.User, error) {
+	user, err := s.authenticate(userID, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if email == user.Email {
+		return nil, ErrEmailUnchanged
+	}
+
+	if taken, err := s.repos.Users.EmailTaken(email); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, ErrEmailTaken
+	}
+
+	if s.queue == nil {
+		return nil, ErrMailUnavailable
+	}
+
+	user.PendingEmail = &email
+	user.UpdatedAt = time.Now()
+	if err := s.repos.Users.Update(user); err != nil {
+		return nil, err
+	}
+
+	token, err := s.issueToken(user.ID, models.TokenPurposeChangeEmail, s.cfg.VerificationTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nConfirm your new email address by opening this link:\n\n%s/verify-email?token=%s\n\nThe link expires in %s.\n",
+		greetingName(user), s.cfg.AppURL, token, formatTTL(s.cfg.VerificationTokenTTL))
+	if err := s.sendEmail(ctx, user, email, "Confirm your new email address", body); err != nil {
+		return nil, err
+	}
+
+	// Let the current address know in case the change was not requested by its owner
+	notice := fmt.Sprintf("Hi %s,\n\nA change of your account's email address to %s was requested. If this was not you, reset your password.\n",
+		greetingName(user), email)
+	if err := s.sendEmail(ctx, user, user.Email, "Your email address is being changed", notice); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// DeleteAccount soft-deletes a user after checking their password and ends
+// every session. The account's data is purged once the grace period ends.
+// Owners of workspaces with other members must hand them over first.
+func (s *AccountService) DeleteAccount(ctx context.Context, userID uuid.UUID, password string) error {
+	user, err := s.authenticate(userID, password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkNoSharedWorkspaces(user.ID); err != nil {
+		return err
+	}
+
+	if err := s.repos.Users.Delete(user); err != nil {
+		return err
+	}
+
+	if err := s.auth.RevokeSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if s.queue == nil {
+		return nil
+	}
+
+	purgeAt := time.Now().Add(s.cfg.DeletionGracePeriod)
+	if err := jobs.AccountPurge.Schedule(ctx, s.queue, s.queueName, accountPurgeJobID(user.ID), jobs.AccountPurgePayload{UserID: user.ID}, purgeAt); err != nil {
+		return fmt.Errorf("failed to schedule account purge: %w", err)
+	}
+	return nil
+}
+
+// checkNoSharedWorkspaces returns ErrOwnsWorkspaces if the user owns a
+// workspace that has other members
+func (s *AccountService) checkNoSharedWorkspaces(userID uuid.UUID) error {
+	workspaces, err := s.repos.Workspaces.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, workspace := range workspaces {
+		if workspace.Role != models.WorkspaceRoleOwner {
+			continue
+		}
+		members, err := s.repos.Workspaces.ListMembers(workspace.ID)
+		if err != nil {
+			return err
+		}
+		if len(members) > 1 {
+			return ErrOwnsWorkspaces
+		}
+	}
+	return nil
+}
+
+// accountPurgeJobID is the scheduled job ID of an account's purge
+func accountPurgeJobID(userID uuid.UUID) string {
+	return jobs.AccountPurge.Type + ":" + userID.String()
+}
+
+// authenticate returns a user after checking their password
+func (s *AccountService) authenticate(userID uuid.UUID, password string) (*models.User, error) {
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrWrongPassword
+	}
+	return user, nil
+}
+
+// RequestPasswordReset emails a password reset link to the account with the
+// given email. Unknown emails are ignored so callers cannot tell which
+// accounts exist. Links sent earlier stop working.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	if s.queue == nil {
+		return ErrMailUnavailable
+	}
+
+	user, err := s.repos.Users.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := s.issueToken(user.ID, models.TokenPurposeResetPassword, s.cfg.ResetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nReset your password by opening this link:\n\n%s/reset-password?token=%s\n\nThe link expires in %s. If you did not ask to reset your password, you can ignore this email.\n",
+		greetingName(user), s.cfg.AppURL, token, formatTTL(s.cfg.ResetTokenTTL))
+	return s.sendEmail(ctx, user, user.Email, "Reset your password", body)
+}
+
+// ResetPassword redeems a password reset token, sets the new password and
+// ends every session of the user. Receiving the link proves ownership of
+// the email address, so the account is also verified.
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) (*models.User, error) {
+	userToken, err := s.redeemToken(token, models.TokenPurposeResetPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repos.Users.FindByID(userToken.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.Password = string(hashedPassword)
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+	user.UpdatedAt = now
+	if err := s.repos.Users.Update(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.UserTokens.ExpireUser(user.ID, models.TokenPurposeResetPassword, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.RevokeSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// issueToken creates a single-use token, expiring the user's earlier unused
+// tokens of the same purpose
+func (s *AccountService) issueToken(userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	if err := s.repos.UserTokens.ExpireUser(userID, purpose, now); err != nil {
+		return "", err
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repos.UserTokens.Create(&models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(ttl),
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// redeemToken marks a token of one of the given purposes used and returns it
+func (s *AccountService) redeemToken(token string, purposes ...string) (*models.UserToken, error) {
+	userToken, err := s.repos.UserTokens.FindByHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	if !slices.Contains(purposes, userToken.Purpose) || userToken.UsedAt != nil || !time.Now().Before(userToken.ExpiresAt) {
+		return nil, ErrInvalidUserToken
+	}
+
+	// A concurrent request may have redeemed the token since it was read
+	used, err := s.repos.UserTokens.MarkUsed(userToken.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidUserToken
+	}
+
+	return userToken, nil
+}
+
+// sendEmail enqueues an email to a user at the given address
+func (s *AccountService) sendEmail(ctx context.Context, user *models.User, to, subject, body string) error {
+	if _, err := jobs.EmailNotification.Enqueue(ctx, s.queue, s.queueName, jobs.EmailNotificationPayload{
+		UserID:  user.ID,
+		To:      to,
+		Subject: subject,
+		Body:    body,
+	}); err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
+
+// greetingName returns the name emails address a user by
+func greetingName(user *models.User) string {
+	if user.FirstName != "" {
+		return user.FirstName
+	}
+	return user.Email
+}
+
+// formatTTL renders a link lifetime for humans, e.g. "48 hours"
+func formatTTL(ttl time.Duration) string {
+	switch {
+	case ttl >= time.Hour && ttl%time.Hour == 0:
+		if ttl == time.Hour {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", ttl/time.Hour)
+	case ttl >= time.Minute && ttl%time.Minute == 0:
+		return fmt.Sprintf("%d minutes", ttl/time.Minute)
+	default:
+		return ttl.String()
+	}
+}
@@ -0,0 +1,252 @@
+package services_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+	"github.com/jaimesHub/golang-todo-app/internal/services/queue"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeAccountQueue records enqueued email jobs and scheduled jobs
+type fakeAccountQueue struct {
+	tasks     []*queue.Task
+	scheduled map[string]time.Time
+}
+
+func (f *fakeAccountQueue) EnqueueTask(ctx context.Context, queueName string, task *queue.Task) error {
+	f.tasks = append(f.tasks, task)
+	return nil
+}
+
+func (f *fakeAccountQueue) ScheduleTaskAt(ctx context.Context, queueName string, task *queue.Task, executeAt time.Time) error {
+	f.scheduled[task.ID] = executeAt
+	return nil
+}
+
+// lastLinkToken returns the token in the link of the last enqueued email
+func (f *fakeAccountQueue) lastLinkToken(t *testing.T) string {
+	if !assert.NotEmpty(t, f.tasks) {
+		return ""
+	}
+	match := regexp.MustCompile(`token=(\S+)`).FindStringSubmatch(f.tasks[len(f.tasks)-1].Data["body"].(string))
+	if !assert.Len(t, match, 2) {
+		return ""
+	}
+	return match[1]
+}
+
+func newAccountService(t *testing.T, repos *repository.Repositories, requireVerification bool) (*services.AccountService, *services.AuthService, *fakeAccountQueue) {
+	jwtService, err := auth.NewJWTService(&config.JWTConfig{Secret: "test-secret-key", AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour})
+	assert.NoError(t, err)
+	authService := services.NewAuthService(repos, jwtService, auth.NewMemoryDenylist())
+
+	accountService := services.NewAccountService(repos, authService, config.AccountConfig{
+		AppURL:                   "https://todo.example.com",
+		RequireEmailVerification: requireVerification,
+		VerificationTokenTTL:     time.Hour,
+		ResetTokenTTL:            time.Hour,
+		DeletionGracePeriod:      24 * time.Hour,
+	})
+	mailQueue := &fakeAccountQueue{scheduled: make(map[string]time.Time)}
+	accountService.SetQueue(mailQueue, "tasks")
+	return accountService, authService, mailQueue
+}
+
+func TestEmailVerification(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	accountService, _, mailQueue := newAccountService(t, repos, true)
+	user, err := services.NewUserService(repos).CreateUser("verify@example.com", "password123", "Vera", "")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, accountService.CheckLogin(user), services.ErrEmailNotVerified)
+
+	assert.NoError(t, accountService.SendVerification(context.Background(), user))
+	assert.Len(t, mailQueue.tasks, 1)
+	assert.Equal(t, "email_notification", mailQueue.tasks[0].Type)
+	assert.Equal(t, "verify@example.com", mailQueue.tasks[0].Data["to"])
+	assert.Contains(t, mailQueue.tasks[0].Data["body"], "https://todo.example.com/verify-email?token=")
+	first := mailQueue.lastLinkToken(t)
+
+	// Resending replaces the earlier link
+	assert.NoError(t, accountService.ResendVerification(context.Background(), "verify@example.com"))
+	second := mailQueue.lastLinkToken(t)
+	_, err = accountService.VerifyEmail(first)
+	assert.ErrorIs(t, err, services.ErrInvalidUserToken)
+
+	verified, err := accountService.VerifyEmail(second)
+	assert.NoError(t, err)
+	assert.NotNil(t, verified.EmailVerifiedAt)
+	assert.NoError(t, accountService.CheckLogin(verified))
+
+	// Tokens are single-use
+	_, err = accountService.VerifyEmail(second)
+	assert.ErrorIs(t, err, services.ErrInvalidUserToken)
+
+	// Verified accounts and unknown emails get no email
+	assert.NoError(t, accountService.ResendVerification(context.Background(), "verify@example.com"))
+	assert.NoError(t, accountService.ResendVerification(context.Background(), "nobody@example.com"))
+	assert.Len(t, mailQueue.tasks, 2)
+}
+
+func TestPasswordReset(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	accountService, authService, mailQueue := newAccountService(t, repos, false)
+	user, err := services.NewUserService(repos).CreateUser("reset@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	session, err := authService.IssueTokens(user.ID)
+	assert.NoError(t, err)
+
+	// Unknown emails are accepted silently
+	assert.NoError(t, accountService.RequestPasswordReset(context.Background(), "nobody@example.com"))
+	assert.Empty(t, mailQueue.tasks)
+
+	assert.NoError(t, accountService.RequestPasswordReset(context.Background(), "reset@example.com"))
+	token := mailQueue.lastLinkToken(t)
+	assert.Contains(t, mailQueue.tasks[0].Data["body"], "/reset-password?token=")
+
+	// A verification token cannot reset the password
+	assert.NoError(t, accountService.SendVerification(context.Background(), user))
+	_, err = accountService.ResetPassword(context.Background(), mailQueue.lastLinkToken(t), "new-password")
+	assert.ErrorIs(t, err, services.ErrInvalidUserToken)
+
+	updated, err := accountService.ResetPassword(context.Background(), token, "new-password")
+	assert.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(updated.Password), []byte("new-password")))
+	assert.NotNil(t, updated.EmailVerifiedAt)
+
+	// The link is single-use and earlier sessions are ended
+	_, err = accountService.ResetPassword(context.Background(), token, "another-password")
+	assert.ErrorIs(t, err, services.ErrInvalidUserToken)
+	_, err = authService.Refresh(session.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+}
+
+func TestAccountEmailsWithoutQueue(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	accountService := services.NewAccountService(repos, nil, config.AccountConfig{})
+
+	err := accountService.RequestPasswordReset(context.Background(), "reset@example.com")
+	assert.ErrorIs(t, err, services.ErrMailUnavailable)
+}
+
+func TestChangePassword(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	accountService, authService, _ := newAccountService(t, repos, false)
+	user, err := services.NewUserService(repos).CreateUser("change@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	other, err := authService.IssueTokens(user.ID)
+	assert.NoError(t, err)
+
+	_, err = accountService.ChangePassword(context.Background(), user.ID, "wrong-password", "new-password")
+	assert.ErrorIs(t, err, services.ErrWrongPassword)
+
+	tokens, err := accountService.ChangePassword(context.Background(), user.ID, "password123", "new-password")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	// Other sessions end, the new one works
+	_, err = authService.Refresh(other.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+	_, err = authService.Refresh(tokens.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestChangeEmail(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	accountService, _, mailQueue := newAccountService(t, repos, false)
+	userService := services.NewUserService(repos)
+	user, err := userService.CreateUser("old@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	_, err = userService.CreateUser("taken@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	_, err = accountService.ChangeEmail(context.Background(), user.ID, "wrong-password", "new@example.com")
+	assert.ErrorIs(t, err, services.ErrWrongPassword)
+	_, err = accountService.ChangeEmail(context.Background(), user.ID, "password123", "taken@example.com")
+	assert.ErrorIs(t, err, services.ErrEmailTaken)
+	_, err = accountService.ChangeEmail(context.Background(), user.ID, "password123", "old@example.com")
+	assert.ErrorIs(t, err, services.ErrEmailUnchanged)
+
+	pending, err := accountService.ChangeEmail(context.Background(), user.ID, "password123", "new@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "old@example.com", pending.Email)
+	assert.Equal(t, "new@example.com", *pending.PendingEmail)
+
+	// The link goes to the new address and the old one is notified
+	assert.Len(t, mailQueue.tasks, 2)
+	assert.Equal(t, "new@example.com", mailQueue.tasks[0].Data["to"])
+	assert.Equal(t, "old@example.com", mailQueue.tasks[1].Data["to"])
+
+	match := regexp.MustCompile(`token=(\S+)`).FindStringSubmatch(mailQueue.tasks[0].Data["body"].(string))
+	changed, err := accountService.VerifyEmail(match[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "new@example.com", changed.Email)
+	assert.Nil(t, changed.PendingEmail)
+	assert.NotNil(t, changed.EmailVerifiedAt)
+
+	_, err = userService.GetUserByEmail("old@example.com")
+	assert.Error(t, err)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	accountService, authService, mailQueue := newAccountService(t, repos, false)
+	userService := services.NewUserService(repos)
+	taskService := services.NewTaskService(repos)
+	user, err := userService.CreateUser("delete@example.com", "password123", "Del", "Eted")
+	assert.NoError(t, err)
+	session, err := authService.IssueTokens(user.ID)
+	assert.NoError(t, err)
+	task, err := taskService.CreateTask(user.ID, services.TaskInput{Title: "Secret plans"})
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, accountService.DeleteAccount(context.Background(), user.ID, "wrong-password"), services.ErrWrongPassword)
+	assert.NoError(t, accountService.DeleteAccount(context.Background(), user.ID, "password123"))
+
+	// The account and its tasks are gone and its sessions ended
+	_, err = userService.GetUserByEmail("delete@example.com")
+	assert.Error(t, err)
+	_, err = repos.Tasks.FindByID(task.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = authService.Refresh(session.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	// The purge is scheduled after the grace period
+	purgeAt, scheduled := mailQueue.scheduled["account_purge:"+user.ID.String()]
+	assert.True(t, scheduled)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), purgeAt, time.Minute)
+
+	activities, err := userService.GetUserActivities(user.ID, 0, 0)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, activities)
+
+	// The email stays taken until the account is purged
+	_, err = userService.CreateUser("delete@example.com", "password123", "", "")
+	assert.ErrorIs(t, err, services.ErrEmailTaken)
+	other, err := userService.CreateUser("other@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	_, err = accountService.ChangeEmail(context.Background(), other.ID, "password123", "delete@example.com")
+	assert.ErrorIs(t, err, services.ErrEmailTaken)
+
+	assert.NoError(t, userService.HandleAccountPurge(context.Background(), jobs.AccountPurgePayload{UserID: user.ID}))
+	activities, err = userService.GetUserActivities(user.ID, 0, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, activities)
+
+	// Purging twice is harmless
+	assert.NoError(t, userService.HandleAccountPurge(context.Background(), jobs.AccountPurgePayload{UserID: user.ID}))
+
+	_, err = userService.CreateUser("delete@example.com", "password123", "", "")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+)
+
+// ErrCannotModifySelf is returned when an admin deactivates, logs out or
+// changes the role of their own account, which could lock every admin out
+var ErrCannotModifySelf = errors.New("admins cannot deactivate, log out or change the role of their own account")
+
+// AdminService handles managing users through the admin API
+type AdminService struct {
+	repos *repository.Repositories
+	auth  *AuthService
+	roles *RoleService
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(repos *repository.Repositories, authService *AuthService, roleService *RoleService) *AdminService {
+	return &AdminService{repos: repos, auth: authService, roles: roleService}
+}
+
+// ListUsers retrieves users matching a filter and the number of matches
+// regardless of pagination
+func (s *AdminService) ListUsers(filter repository.UserFilter) ([]models.User, int64, error) {
+	users, err := s.repos.Users.List(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repos.Users.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// GetUser retrieves any user by ID
+func (s *AdminService) GetUser(userID uuid.UUID) (*models.User, error) {
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// checkCanManage checks that the actor holds every permission of a user's
+// role, so that users with more permissions cannot be managed
+func (s *AdminService) checkCanManage(actorID uuid.UUID, user *models.User) error {
+	// Users of deleted roles have no permissions to check
+	role, err := s.roles.GetRole(user.Role)
+	if err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.roles.CanGrant(actorID, role)
+}
+
+// SetActive deactivates or reactivates a user. Deactivated users cannot log
+// in and their sessions are ended; their API tokens stop working until they
+// are reactivated. The actor must hold every permission of the user's role.
+func (s *AdminService) SetActive(ctx context.Context, actorID, userID uuid.UUID, active bool) (*models.User, error) {
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCanManage(actorID, user); err != nil {
+		return nil, err
+	}
+
+	if user.IsActive != active {
+		user.IsActive = active
+		user.UpdatedAt = time.Now()
+		if err := s.repos.Users.Update(user); err != nil {
+			return nil, err
+		}
+	}
+
+	if !active {
+		if err := s.auth.RevokeSessions(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// ForceLogout ends every session of another user. The actor must hold every
+// permission of the user's role.
+func (s *AdminService) ForceLogout(ctx context.Context, actorID, userID uuid.UUID) error {
+	if actorID == userID {
+		return ErrCannotModifySelf
+	}
+
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkCanManage(actorID, user); err != nil {
+		return err
+	}
+	return s.auth.RevokeSessions(ctx, user.ID)
+}
+
+// SetRole gives a user a built-in or custom role. The actor must hold every
+// permission of both the user's current role and the new one.
+func (s *AdminService) SetRole(actorID, userID uuid.UUID, role string) (*models.User, error) {
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+
+	newRole, err := s.roles.GetRole(role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.roles.CanGrant(actorID, newRole); err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCanManage(actorID, user); err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	if err := s.repos.Users.Update(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserActivities retrieves the activities of any user
+func (s *AdminService) GetUserActivities(userID uuid.UUID, limit, offset int) ([]models.Activity, error) {
+	if _, err := s.GetUser(userID); err != nil {
+		return nil, err
+	}
+	return s.repos.Activities.ListByUser(userID, limit, offset)
+}
@@ -0,0 +1,208 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolePermissions(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	roleService := services.NewRoleService(repos)
+	userService := services.NewUserService(repos)
+
+	admin, err := userService.CreateUser("admin@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	admin.Role = models.RoleAdmin
+	assert.NoError(t, repos.Users.Update(admin))
+
+	allowed, err := roleService.HasPermission(models.RoleAdmin, auth.PermissionRolesManage)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, err = roleService.HasPermission(models.RoleUser, auth.PermissionUsersRead)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+
+	_, err = roleService.CreateRole(admin.ID, "Support Team", "", nil)
+	assert.ErrorIs(t, err, services.ErrInvalidRoleName)
+	_, err = roleService.CreateRole(admin.ID, "admin", "", nil)
+	assert.ErrorIs(t, err, services.ErrRoleExists)
+	_, err = roleService.CreateRole(admin.ID, "support", "", []string{"users:delete"})
+	assert.ErrorIs(t, err, services.ErrInvalidPermissions)
+
+	role, err := roleService.CreateRole(admin.ID, "support", " Helpdesk ", []string{"users:read", " USERS:READ"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Helpdesk", role.Description)
+	assert.Equal(t, []string{auth.PermissionUsersRead}, []string(role.Permissions))
+	_, err = roleService.CreateRole(admin.ID, "support", "", nil)
+	assert.ErrorIs(t, err, services.ErrRoleExists)
+
+	allowed, _ = roleService.HasPermission("support", auth.PermissionUsersRead)
+	assert.True(t, allowed)
+	allowed, _ = roleService.HasPermission("support", auth.PermissionUsersManage)
+	assert.False(t, allowed)
+	allowed, _ = roleService.HasPermission("deleted-role", auth.PermissionUsersRead)
+	assert.False(t, allowed)
+
+	_, err = roleService.UpdateRole(admin.ID, "support", nil, []string{"users:read", "users:manage"})
+	assert.NoError(t, err)
+	allowed, _ = roleService.HasPermission("support", auth.PermissionUsersManage)
+	assert.True(t, allowed)
+
+	_, err = roleService.UpdateRole(admin.ID, models.RoleAdmin, nil, []string{})
+	assert.ErrorIs(t, err, services.ErrBuiltinRole)
+	assert.ErrorIs(t, roleService.DeleteRole(models.RoleUser), services.ErrBuiltinRole)
+	assert.ErrorIs(t, roleService.DeleteRole("missing"), services.ErrRoleNotFound)
+
+	roles, err := roleService.ListRoles()
+	assert.NoError(t, err)
+	assert.Len(t, roles, 3)
+	assert.True(t, roles[0].Builtin)
+	assert.Equal(t, "support", roles[2].Name)
+}
+
+func TestAdminManagesUsers(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	jwtService, err := auth.NewJWTService(&config.JWTConfig{Secret: "test-secret-key", AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour})
+	assert.NoError(t, err)
+	authService := services.NewAuthService(repos, jwtService, auth.NewMemoryDenylist())
+	roleService := services.NewRoleService(repos)
+	adminService := services.NewAdminService(repos, authService, roleService)
+	userService := services.NewUserService(repos)
+	ctx := context.Background()
+
+	admin, err := userService.CreateUser("admin@example.com", "password123", "Ada", "Admin")
+	assert.NoError(t, err)
+	admin.Role = models.RoleAdmin
+	assert.NoError(t, repos.Users.Update(admin))
+	user, err := userService.CreateUser("bob@example.com", "password123", "Bob", "Builder")
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleUser, user.Role)
+
+	// Searching matches names and emails, ignoring case
+	users, total, err := adminService.ListUsers(repository.UserFilter{Query: "BUILD"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, user.ID, users[0].ID)
+
+	// Admins cannot lock themselves out
+	_, err = adminService.SetActive(ctx, admin.ID, admin.ID, false)
+	assert.ErrorIs(t, err, services.ErrCannotModifySelf)
+	_, err = adminService.SetRole(admin.ID, admin.ID, models.RoleUser)
+	assert.ErrorIs(t, err, services.ErrCannotModifySelf)
+	assert.ErrorIs(t, adminService.ForceLogout(ctx, admin.ID, admin.ID), services.ErrCannotModifySelf)
+
+	// Deactivating ends the user's sessions
+	tokens, err := authService.IssueTokens(user.ID)
+	assert.NoError(t, err)
+	deactivated, err := adminService.SetActive(ctx, admin.ID, user.ID, false)
+	assert.NoError(t, err)
+	assert.False(t, deactivated.IsActive)
+	_, err = authService.Refresh(tokens.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	active := false
+	_, total, _ = adminService.ListUsers(repository.UserFilter{Active: &active})
+	assert.Equal(t, int64(1), total)
+
+	reactivated, err := adminService.SetActive(ctx, admin.ID, user.ID, true)
+	assert.NoError(t, err)
+	assert.True(t, reactivated.IsActive)
+
+	// Inactive users cannot refresh even sessions that were not revoked, and
+	// those sessions stay ended once they are active again
+	tokens, err = authService.IssueTokens(user.ID)
+	assert.NoError(t, err)
+	reactivated.IsActive = false
+	assert.NoError(t, repos.Users.Update(reactivated))
+	_, err = authService.Refresh(tokens.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+	reactivated.IsActive = true
+	assert.NoError(t, repos.Users.Update(reactivated))
+	_, err = authService.Refresh(tokens.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	// Roles must exist, and custom roles in use cannot be deleted
+	_, err = adminService.SetRole(admin.ID, user.ID, "support")
+	assert.ErrorIs(t, err, services.ErrRoleNotFound)
+	_, err = roleService.CreateRole(admin.ID, "support", "", []string{auth.PermissionUsersRead})
+	assert.NoError(t, err)
+	updated, err := adminService.SetRole(admin.ID, user.ID, "support")
+	assert.NoError(t, err)
+	assert.Equal(t, "support", updated.Role)
+	assert.ErrorIs(t, roleService.DeleteRole("support"), services.ErrRoleInUse)
+
+	activities, err := adminService.GetUserActivities(user.ID, 10, 0)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, activities)
+}
+
+func TestRoleManagersCannotEscalate(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	roleService := services.NewRoleService(repos)
+	adminService := services.NewAdminService(repos, nil, roleService)
+	userService := services.NewUserService(repos)
+
+	admin, err := userService.CreateUser("admin@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	_, err = adminService.SetRole(uuid.Nil, admin.ID, models.RoleAdmin)
+	assert.NoError(t, err)
+	manager, err := userService.CreateUser("manager@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	user, err := userService.CreateUser("bob@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	_, err = roleService.CreateRole(admin.ID, "role-manager", "", []string{auth.PermissionRolesManage})
+	assert.NoError(t, err)
+	_, err = adminService.SetRole(admin.ID, manager.ID, "role-manager")
+	assert.NoError(t, err)
+
+	// Only admins give the admin role
+	_, err = adminService.SetRole(manager.ID, user.ID, models.RoleAdmin)
+	assert.ErrorIs(t, err, services.ErrPermissionEscalation)
+
+	// Roles cannot carry permissions the manager does not have
+	_, err = roleService.UpdateRole(manager.ID, "role-manager", nil, []string{auth.PermissionRolesManage, auth.PermissionUsersManage})
+	assert.ErrorIs(t, err, services.ErrPermissionEscalation)
+	allowed, _ := roleService.HasPermission("role-manager", auth.PermissionUsersManage)
+	assert.False(t, allowed)
+	_, err = roleService.CreateRole(manager.ID, "support", "", []string{auth.PermissionUsersRead})
+	assert.ErrorIs(t, err, services.ErrPermissionEscalation)
+
+	// Nor can they be given by the manager, or change the role of an admin
+	_, err = roleService.CreateRole(admin.ID, "support", "", []string{auth.PermissionUsersRead})
+	assert.NoError(t, err)
+	_, err = adminService.SetRole(manager.ID, user.ID, "support")
+	assert.ErrorIs(t, err, services.ErrPermissionEscalation)
+	_, err = adminService.SetRole(manager.ID, admin.ID, models.RoleUser)
+	assert.ErrorIs(t, err, services.ErrPermissionEscalation)
+
+	// Roles within the manager's permissions can be made and given
+	_, err = roleService.CreateRole(manager.ID, "helper", "", []string{auth.PermissionRolesManage})
+	assert.NoError(t, err)
+	updated, err := adminService.SetRole(manager.ID, user.ID, "helper")
+	assert.NoError(t, err)
+	assert.Equal(t, "helper", updated.Role)
+
+	// User managers cannot deactivate or log out users with more permissions
+	_, err = roleService.CreateRole(admin.ID, "user-manager", "", []string{auth.PermissionUsersManage})
+	assert.NoError(t, err)
+	_, err = adminService.SetRole(admin.ID, user.ID, "user-manager")
+	assert.NoError(t, err)
+	_, err = adminService.SetActive(context.Background(), user.ID, admin.ID, false)
+	assert.ErrorIs(t, err, services.ErrPermissionEscalation)
+	assert.ErrorIs(t, adminService.ForceLogout(context.Background(), user.ID, admin.ID), services.ErrPermissionEscalation)
+	_, err = adminService.SetActive(context.Background(), user.ID, manager.ID, false)
+	assert.ErrorIs(t, err, services.ErrPermissionEscalation)
+	found, err := adminService.GetUser(admin.ID)
+	assert.NoError(t, err)
+	assert.True(t, found.IsActive)
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
+)
+
+// APITokenPrefix starts every personal access token, so that they can be
+// told apart from JWTs and spotted by secret scanners
+const APITokenPrefix = "tdp_"
+
+// apiTokenTouchInterval limits how often LastUsedAt is written for a token
+// that is used over and over
+const apiTokenTouchInterval = time.Minute
+
+var (
+	// ErrInvalidAPIToken is returned for unknown or expired personal access tokens
+	ErrInvalidAPIToken = errors.New("invalid or expired API token")
+
+	// ErrAPITokenNotFound is returned when a token does not exist or belongs to another user
+	ErrAPITokenNotFound = errors.New("API token not found")
+
+	// ErrInvalidScopes is returned for empty or unknown scopes
+	ErrInvalidScopes = errors.New("invalid scopes")
+
+	// ErrInvalidExpiry is returned for an expiry in the past
+	ErrInvalidExpiry = errors.New("expiry must be in the future")
+)
+
+// APITokenService manages personal access tokens
+type APITokenService struct {
+	repos *repository.Repositories
+}
+
+// NewAPITokenService creates a new API token service
+func NewAPITokenService(repos *repository.Repositories) *APITokenService {
+	return &APITokenService{repos: repos}
+}
+
+// CreateToken creates a personal access token and returns it with its
+// value. Only the hash is stored, so this is the one time the value can be
+// shown. A nil expiresAt creates a token that does not expire.
+func (s *APITokenService) CreateToken(userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*models.APIToken, string, error) {
+	normalized, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	secret, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	value := APITokenPrefix + secret
+
+	token := &models.APIToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    value[:len(APITokenPrefix)+6],
+		TokenHash: hashToken(value),
+		Scopes:    normalized,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repos.APITokens.Create(token); err != nil {
+		return nil, "", err
+	}
+
+	return token, value, nil
+}
+
+// GetTokens retrieves a user's personal access tokens, newest first
+func (s *APITokenService) GetTokens(userID uuid.UUID) ([]models.APIToken, error) {
+	return s.repos.APITokens.ListByUser(userID)
+}
+
+// GetToken retrieves a personal access token of a user
+func (s *APITokenService) GetToken(id, userID uuid.UUID) (*models.APIToken, error) {
+	token, err := s.repos.APITokens.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrAPITokenNotFound
+		}
+		return nil, err
+	}
+
+	if token.UserID != userID {
+		return nil, ErrAPITokenNotFound
+	}
+	return token, nil
+}
+
+// UpdateToken renames a personal access token and replaces its scopes. Nil
+// arguments are left unchanged.
+func (s *APITokenService) UpdateToken(id, userID uuid.UUID, name *string, scopes []string) (*models.APIToken, error) {
+	token, err := s.GetToken(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != nil {
+		token.Name = strings.TrimSpace(*name)
+	}
+	if scopes != nil {
+		if token.Scopes, err = normalizeScopes(scopes); err != nil {
+			return nil, err
+		}
+	}
+
+	token.UpdatedAt = time.Now()
+	if err := s.repos.APITokens.Update(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// DeleteToken revokes a personal access token
+func (s *APITokenService) DeleteToken(id, userID uuid.UUID) error {
+	token, err := s.GetToken(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.repos.APITokens.Delete(token)
+}
+
+// AuthenticateToken resolves a personal access token presented to the API
+// and records its use. Tokens of deleted users are refused.
+func (s *APITokenService) AuthenticateToken(ctx context.Context, value string) (*models.APIToken, error) {
+	if !strings.HasPrefix(value, APITokenPrefix) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	token, err := s.repos.APITokens.FindByHash(hashToken(value))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidAPIToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && !now.Before(*token.ExpiresAt) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	if _, err := s.repos.Users.FindByID(token.UserID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidAPIToken
+		}
+		return nil, err
+	}
+
+	// Failing to record the use does not fail the request
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval {
+		if err := s.repos.APITokens.Touch(token.ID, now); err == nil {
+			token.LastUsedAt = &now
+		}
+	}
+
+	return token, nil
+}
+
+// normalizeScopes checks scopes and removes duplicates
+func normalizeScopes(scopes []string) (models.StringList, error) {
+	normalized := models.StringList{}
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !auth.ValidScope(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidScopes, scope)
+		}
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidScopes)
+	}
+	return normalized, nil
+}
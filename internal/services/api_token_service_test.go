@@ -0,0 +1,93 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPITokenLifecycle(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	apiTokenService := services.NewAPITokenService(repos)
+	userService := services.NewUserService(repos)
+	ctx := context.Background()
+	user, err := userService.CreateUser("ci@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	_, _, err = apiTokenService.CreateToken(user.ID, "CI", nil, nil)
+	assert.ErrorIs(t, err, services.ErrInvalidScopes)
+	_, _, err = apiTokenService.CreateToken(user.ID, "CI", []string{"tasks:delete"}, nil)
+	assert.ErrorIs(t, err, services.ErrInvalidScopes)
+	past := time.Now().Add(-time.Hour)
+	_, _, err = apiTokenService.CreateToken(user.ID, "CI", []string{"tasks:read"}, &past)
+	assert.ErrorIs(t, err, services.ErrInvalidExpiry)
+
+	token, value, err := apiTokenService.CreateToken(user.ID, " CI ", []string{"tasks:read", " TASKS:WRITE", "tasks:read"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "CI", token.Name)
+	assert.Equal(t, []string{"tasks:read", "tasks:write"}, []string(token.Scopes))
+	assert.True(t, strings.HasPrefix(value, services.APITokenPrefix))
+	assert.True(t, strings.HasPrefix(value, token.Prefix))
+	assert.NotContains(t, token.TokenHash, value)
+	assert.Nil(t, token.LastUsedAt)
+
+	// Authenticating records the use
+	authenticated, err := apiTokenService.AuthenticateToken(ctx, value)
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID, authenticated.ID)
+	stored, _ := apiTokenService.GetToken(token.ID, user.ID)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	_, err = apiTokenService.AuthenticateToken(ctx, value+"x")
+	assert.ErrorIs(t, err, services.ErrInvalidAPIToken)
+	_, err = apiTokenService.AuthenticateToken(ctx, "not-a-token")
+	assert.ErrorIs(t, err, services.ErrInvalidAPIToken)
+
+	// Tokens are private to their owner
+	_, err = apiTokenService.GetToken(token.ID, uuid.New())
+	assert.ErrorIs(t, err, services.ErrAPITokenNotFound)
+
+	name := "Deploy"
+	updated, err := apiTokenService.UpdateToken(token.ID, user.ID, &name, []string{"projects:read"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Deploy", updated.Name)
+	assert.Equal(t, []string{"projects:read"}, []string(updated.Scopes))
+
+	tokens, err := apiTokenService.GetTokens(user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 1)
+
+	assert.NoError(t, apiTokenService.DeleteToken(token.ID, user.ID))
+	_, err = apiTokenService.AuthenticateToken(ctx, value)
+	assert.ErrorIs(t, err, services.ErrInvalidAPIToken)
+}
+
+func TestAPITokenExpiryAndDeletedUser(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	apiTokenService := services.NewAPITokenService(repos)
+	ctx := context.Background()
+	user, err := services.NewUserService(repos).CreateUser("expiry@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	soon := time.Now().Add(50 * time.Millisecond)
+	_, expiring, err := apiTokenService.CreateToken(user.ID, "Short", []string{"tasks:read"}, &soon)
+	assert.NoError(t, err)
+	_, err = apiTokenService.AuthenticateToken(ctx, expiring)
+	assert.NoError(t, err)
+	time.Sleep(60 * time.Millisecond)
+	_, err = apiTokenService.AuthenticateToken(ctx, expiring)
+	assert.ErrorIs(t, err, services.ErrInvalidAPIToken)
+
+	// Tokens stop working with their user's account
+	_, value, err := apiTokenService.CreateToken(user.ID, "Long", []string{"tasks:read"}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, repos.Users.Delete(user))
+	_, err = apiTokenService.AuthenticateToken(ctx, value)
+	assert.ErrorIs(t, err, services.ErrInvalidAPIToken)
+}
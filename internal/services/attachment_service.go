@@ -0,0 +1,267 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+	"github.com/jaimesHub/golang-todo-app/internal/services/storage"
+)
+
+var (
+	// ErrAttachmentNotFound is returned when an attachment does not exist or
+	// belongs to another task
+	ErrAttachmentNotFound = errors.New("attachment not found")
+
+	// ErrAttachmentEmpty is returned for empty files
+	ErrAttachmentEmpty = errors.New("file is empty")
+
+	// ErrAttachmentTooLarge is returned for files above the size limit
+	ErrAttachmentTooLarge = errors.New("file is too large")
+
+	// ErrAttachmentType is returned for files of a type that is not allowed
+	ErrAttachmentType = errors.New("file type is not allowed")
+)
+
+// orphanBatchSize is how many attachments of deleted tasks are removed at a time
+const orphanBatchSize = 100
+
+// AttachmentService manages the files attached to tasks
+type AttachmentService struct {
+	repos       *repository.Repositories
+	taskService *TaskService
+	storage     storage.Storage
+	cfg         config.AttachmentConfig
+}
+
+// NewAttachmentService creates a new attachment service storing files in store
+func NewAttachmentService(repos *repository.Repositories, taskService *TaskService, store storage.Storage, cfg config.AttachmentConfig) *AttachmentService {
+	return &AttachmentService{repos: repos, taskService: taskService, storage: store, cfg: cfg}
+}
+
+// AttachmentUpload is a file uploaded to a task
+type AttachmentUpload struct {
+	FileName    string
+	ContentType string // as declared by the client
+	Size        int64
+	Body        io.Reader
+}
+
+// UploadAttachment stores a file and attaches it to a task the user can
+// change. The type is detected from the content; the declared type is only
+// used for content that is not recognized.
+func (s *AttachmentService) UploadAttachment(ctx context.Context, taskID, userID uuid.UUID, upload AttachmentUpload) (*models.Attachment, error) {
+	if upload.Size <= 0 {
+		return nil, ErrAttachmentEmpty
+	}
+	if upload.Size > s.cfg.MaxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	if _, err := s.taskService.getTaskForEdit(taskID, userID); err != nil {
+		return nil, err
+	}
+
+	body := bufio.NewReaderSize(io.LimitReader(upload.Body, upload.Size), 512)
+	head, err := body.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	contentType := detectContentType(head, upload.ContentType)
+	if !s.allowedType(contentType) {
+		return nil, ErrAttachmentType
+	}
+
+	attachment := &models.Attachment{
+		ID:          uuid.New(),
+		TaskID:      taskID,
+		UserID:      userID,
+		FileName:    cleanFileName(upload.FileName),
+		ContentType: contentType,
+		Size:        upload.Size,
+		CreatedAt:   time.Now(),
+	}
+	attachment.StorageKey = "tasks/" + taskID.String() + "/" + attachment.ID.String()
+
+	if err := s.storage.Put(ctx, attachment.StorageKey, body, upload.Size, contentType); err != nil {
+		return nil, err
+	}
+	if err := s.repos.Attachments.Create(attachment); err != nil {
+		s.storage.Delete(ctx, attachment.StorageKey)
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// GetAttachments lists the attachments of a task the user can read, oldest first
+func (s *AttachmentService) GetAttachments(taskID, userID uuid.UUID) ([]models.Attachment, error) {
+	if _, err := s.taskService.GetTaskByID(taskID, userID); err != nil {
+		return nil, err
+	}
+	return s.repos.Attachments.ListByTask(taskID)
+}
+
+// OpenAttachment opens the file of an attachment of a task the user can
+// read; the caller must close it
+func (s *AttachmentService) OpenAttachment(ctx context.Context, taskID, attachmentID, userID uuid.UUID) (*models.Attachment, io.ReadCloser, error) {
+	if _, err := s.taskService.GetTaskByID(taskID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	attachment, err := s.findAttachment(taskID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := s.storage.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	return attachment, file, nil
+}
+
+// DeleteAttachment removes an attachment from a task the user can change
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, taskID, attachmentID, userID uuid.UUID) (*models.Attachment, error) {
+	if _, err := s.taskService.getTaskForEdit(taskID, userID); err != nil {
+		return nil, err
+	}
+
+	attachment, err := s.findAttachment(taskID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.remove(ctx, attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// HandleCleanup processes an attachment_cleanup job. The attachments of a
+// task are only removed once the task is gone, so a retried job cannot touch
+// a live task.
+func (s *AttachmentService) HandleCleanup(ctx context.Context, payload jobs.AttachmentCleanupPayload) error {
+	if payload.TaskID == uuid.Nil {
+		return s.RemoveOrphaned(ctx)
+	}
+
+	if _, err := s.repos.Tasks.FindByID(payload.TaskID); !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	attachments, err := s.repos.Attachments.ListByTask(payload.TaskID)
+	if err != nil {
+		return err
+	}
+	for i := range attachments {
+		if err := s.remove(ctx, &attachments[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveOrphaned removes the attachments of every deleted task
+func (s *AttachmentService) RemoveOrphaned(ctx context.Context) error {
+	for {
+		attachments, err := s.repos.Attachments.ListOrphaned(orphanBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(attachments) == 0 {
+			return nil
+		}
+
+		for i := range attachments {
+			if err := s.remove(ctx, &attachments[i]); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// findAttachment retrieves an attachment of a task
+func (s *AttachmentService) findAttachment(taskID, attachmentID uuid.UUID) (*models.Attachment, error) {
+	attachment, err := s.repos.Attachments.FindByID(attachmentID)
+	if err != nil || attachment.TaskID != taskID {
+		if err == nil || errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// remove deletes the file of an attachment, then its record, so a failure
+// leaves the record to retry with
+func (s *AttachmentService) remove(ctx context.Context, attachment *models.Attachment) error {
+	if err := s.storage.Delete(ctx, attachment.StorageKey); err != nil {
+		return err
+	}
+	return s.repos.Attachments.Delete(attachment)
+}
+
+// allowedType reports whether a content type matches one of the allowed
+// types, where "image/*" matches every image type
+func (s *AttachmentService) allowedType(contentType string) bool {
+	for _, allowed := range s.cfg.AllowedTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == contentType {
+			return true
+		}
+		if prefix, found := strings.CutSuffix(allowed, "/*"); found && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// detectContentType returns the media type of a file, without parameters,
+// detected from its first bytes. Content that is not recognized gets the
+// declared type.
+func detectContentType(head []byte, declared string) string {
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if detected != "application/octet-stream" {
+		return detected
+	}
+
+	if declaredType, _, err := mime.ParseMediaType(declared); err == nil {
+		return declaredType
+	}
+	return detected
+}
+
+// cleanFileName strips directories and control characters from a file name
+func cleanFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	return name
+}
@@ -0,0 +1,174 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+	"github.com/jaimesHub/golang-todo-app/internal/services/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// pngHeader is enough of a PNG file for its type to be detected
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newAttachmentService(t *testing.T, repos *repository.Repositories, taskService *services.TaskService) (*services.AttachmentService, storage.Storage) {
+	store, err := storage.NewLocalStorage(t.TempDir())
+	assert.NoError(t, err)
+
+	return services.NewAttachmentService(repos, taskService, store, config.AttachmentConfig{
+		MaxSize:      1024,
+		AllowedTypes: []string{"image/*", "application/pdf"},
+	}), store
+}
+
+// upload builds an attachment upload of a byte slice
+func upload(name, contentType string, data []byte) services.AttachmentUpload {
+	return services.AttachmentUpload{FileName: name, ContentType: contentType, Size: int64(len(data)), Body: bytes.NewReader(data)}
+}
+
+func TestAttachments(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	taskService := services.NewTaskService(repos)
+	attachmentService, store := newAttachmentService(t, repos, taskService)
+	shareService := services.NewShareService(repos)
+	userService := services.NewUserService(repos)
+	ctx := context.Background()
+
+	owner, err := userService.CreateUser("owner@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	friend, err := userService.CreateUser("friend@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	stranger, err := userService.CreateUser("stranger@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	task, err := taskService.CreateTask(owner.ID, services.TaskInput{Title: "Fix login page"})
+	assert.NoError(t, err)
+
+	// Size and type limits
+	_, err = attachmentService.UploadAttachment(ctx, task.ID, owner.ID, upload("empty.png", "image/png", nil))
+	assert.ErrorIs(t, err, services.ErrAttachmentEmpty)
+	_, err = attachmentService.UploadAttachment(ctx, task.ID, owner.ID, upload("big.png", "image/png", bytes.Repeat([]byte("a"), 1025)))
+	assert.ErrorIs(t, err, services.ErrAttachmentTooLarge)
+	_, err = attachmentService.UploadAttachment(ctx, task.ID, owner.ID, upload("page.html", "image/png", []byte("<html><body>hi</body></html>")))
+	assert.ErrorIs(t, err, services.ErrAttachmentType)
+	_, err = attachmentService.UploadAttachment(ctx, task.ID, stranger.ID, upload("shot.png", "image/png", pngHeader))
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+
+	// The type comes from the content, the name is stripped of directories
+	attachment, err := attachmentService.UploadAttachment(ctx, task.ID, owner.ID, upload("../../screens/shot.png", "application/octet-stream", pngHeader))
+	assert.NoError(t, err)
+	assert.Equal(t, "shot.png", attachment.FileName)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, int64(len(pngHeader)), attachment.Size)
+
+	// Readers of the task download, editors upload and delete
+	_, err = shareService.ShareTask(task.ID, owner.ID, "friend@example.com", models.SharePermissionRead)
+	assert.NoError(t, err)
+	opened, file, err := attachmentService.OpenAttachment(ctx, task.ID, attachment.ID, friend.ID)
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(file)
+		file.Close()
+		assert.Equal(t, pngHeader, data)
+		assert.Equal(t, attachment.ID, opened.ID)
+	}
+	_, err = attachmentService.UploadAttachment(ctx, task.ID, friend.ID, upload("mine.png", "image/png", pngHeader))
+	assert.ErrorIs(t, err, services.ErrShareReadOnly)
+	_, err = attachmentService.DeleteAttachment(ctx, task.ID, attachment.ID, friend.ID)
+	assert.ErrorIs(t, err, services.ErrShareReadOnly)
+	_, _, err = attachmentService.OpenAttachment(ctx, task.ID, attachment.ID, stranger.ID)
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+
+	// Attachments are reached through their own task only
+	other, err := taskService.CreateTask(owner.ID, services.TaskInput{Title: "Other"})
+	assert.NoError(t, err)
+	_, _, err = attachmentService.OpenAttachment(ctx, other.ID, attachment.ID, owner.ID)
+	assert.ErrorIs(t, err, services.ErrAttachmentNotFound)
+
+	attachments, err := attachmentService.GetAttachments(task.ID, friend.ID)
+	assert.NoError(t, err)
+	assert.Len(t, attachments, 1)
+
+	_, err = attachmentService.DeleteAttachment(ctx, task.ID, attachment.ID, owner.ID)
+	assert.NoError(t, err)
+	_, err = store.Get(ctx, attachment.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	attachments, err = attachmentService.GetAttachments(task.ID, owner.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, attachments)
+}
+
+func TestAttachmentCleanup(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	taskService := services.NewTaskService(repos)
+	cleanupQueue := &fakeAccountQueue{scheduled: make(map[string]time.Time)}
+	taskService.SetQueue(cleanupQueue, "tasks")
+	attachmentService, store := newAttachmentService(t, repos, taskService)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	parent, err := taskService.CreateTask(userID, services.TaskInput{Title: "Release"})
+	assert.NoError(t, err)
+	child, err := taskService.CreateTask(userID, services.TaskInput{Title: "Changelog", ParentID: &parent.ID})
+	assert.NoError(t, err)
+	kept, err := taskService.CreateTask(userID, services.TaskInput{Title: "Unrelated"})
+	assert.NoError(t, err)
+
+	keys := []string{}
+	for _, taskID := range []uuid.UUID{parent.ID, child.ID, kept.ID} {
+		attachment, err := attachmentService.UploadAttachment(ctx, taskID, userID, upload("doc.pdf", "application/pdf", []byte("%PDF-1.4 test")))
+		assert.NoError(t, err)
+		keys = append(keys, attachment.StorageKey)
+	}
+
+	// A cleanup for a live task leaves its attachments alone
+	assert.NoError(t, attachmentService.HandleCleanup(ctx, jobs.AttachmentCleanupPayload{TaskID: kept.ID}))
+	_, err = store.Get(ctx, keys[2])
+	assert.NoError(t, err)
+
+	// Deleting a task enqueues a cleanup for it and for each of its subtasks
+	assert.NoError(t, taskService.DeleteTask(parent.ID, userID))
+	if !assert.Len(t, cleanupQueue.tasks, 2) {
+		return
+	}
+	for _, job := range cleanupQueue.tasks {
+		assert.Equal(t, jobs.AttachmentCleanup.Type, job.Type)
+		payload, err := jobs.AttachmentCleanup.Decode(job)
+		assert.NoError(t, err)
+		assert.NoError(t, attachmentService.HandleCleanup(ctx, payload))
+	}
+	for i, key := range keys {
+		_, err = store.Get(ctx, key)
+		if i < 2 {
+			assert.ErrorIs(t, err, storage.ErrNotFound)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+
+	// Deleting a task without attachments enqueues nothing
+	assert.NoError(t, taskService.DeleteTask(kept.ID, userID))
+	assert.Len(t, cleanupQueue.tasks, 3)
+	empty, err := taskService.CreateTask(userID, services.TaskInput{Title: "Empty"})
+	assert.NoError(t, err)
+	assert.NoError(t, taskService.DeleteTask(empty.ID, userID))
+	assert.Len(t, cleanupQueue.tasks, 3)
+
+	// The sweep removes the attachments of every deleted task
+	assert.NoError(t, attachmentService.HandleCleanup(ctx, jobs.AttachmentCleanupPayload{}))
+	_, err = store.Get(ctx, keys[2])
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	orphaned, err := repos.Attachments.ListOrphaned(10)
+	assert.NoError(t, err)
+	assert.Empty(t, orphaned)
+	assert.True(t, strings.HasPrefix(keys[2], "tasks/"+kept.ID.String()+"/"))
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	redisService "github.com/jaimesHub/golang-todo-app/internal/services/redis"
+)
+
+// Denylist records revoked access tokens until they would have expired
+type Denylist interface {
+	// DenyToken revokes the access token with the given jti
+	DenyToken(ctx context.Context, jti string, ttl time.Duration) error
+	// DenyUser revokes every access token of a user issued before a time
+	DenyUser(ctx context.Context, userID uuid.UUID, issuedBefore time.Time, ttl time.Duration) error
+	// IsDenied reports whether an access token was revoked
+	IsDenied(ctx context.Context, claims *TokenClaims) (bool, error)
+}
+
+// RedisDenylist is a Denylist shared by every API instance through Redis
+type RedisDenylist struct {
+	client *redisService.Client
+}
+
+// NewRedisDenylist creates a denylist stored in Redis
+func NewRedisDenylist(client *redisService.Client) *RedisDenylist {
+	return &RedisDenylist{client: client}
+}
+
+// deniedTokenKey holds a revoked jti
+func deniedTokenKey(jti string) string {
+	return "auth:denied:" + jti
+}
+
+// deniedUserKey holds the Unix time in milliseconds before which a user's
+// tokens are revoked
+func deniedUserKey(userID uuid.UUID) string {
+	return "auth:denied_user:" + userID.String()
+}
+
+// DenyToken revokes the access token with the given jti
+func (d *RedisDenylist) DenyToken(ctx context.Context, jti string, ttl time.Duration) error {
+	return d.client.SetWithTTL(ctx, deniedTokenKey(jti), 1, ttl)
+}
+
+// DenyUser revokes every access token of a user issued before a time
+func (d *RedisDenylist) DenyUser(ctx context.Context, userID uuid.UUID, issuedBefore time.Time, ttl time.Duration) error {
+	return d.client.SetWithTTL(ctx, deniedUserKey(userID), issuedBefore.UnixMilli(), ttl)
+}
+
+// IsDenied reports whether an access token was revoked
+func (d *RedisDenylist) IsDenied(ctx context.Context, claims *TokenClaims) (bool, error) {
+	values, err := d.client.MGet(ctx, deniedTokenKey(claims.Id), deniedUserKey(claims.UserID))
+	if err != nil {
+		return false, err
+	}
+
+	if values[0] != nil {
+		return true, nil
+	}
+
+	if value, ok := values[1].(string); ok {
+		issuedBefore, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false, err
+		}
+		return claims.IssuedAtMillis() < issuedBefore, nil
+	}
+
+	return false, nil
+}
+
+// MemoryDenylist is a Denylist for a single process, used in tests and when
+// Redis is unavailable
+type MemoryDenylist struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time    // jti -> expiry
+	users  map[uuid.UUID]time.Time // user ID -> tokens issued before are revoked
+}
+
+// NewMemoryDenylist creates an in-memory denylist
+func NewMemoryDenylist() *MemoryDenylist {
+	return &MemoryDenylist{
+		tokens: make(map[string]time.Time),
+		users:  make(map[uuid.UUID]time.Time),
+	}
+}
+
+// DenyToken revokes the access token with the given jti
+func (d *MemoryDenylist) DenyToken(ctx context.Context, jti string, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for id, expiresAt := range d.tokens {
+		if now.After(expiresAt) {
+			delete(d.tokens, id)
+		}
+	}
+
+	d.tokens[jti] = now.Add(ttl)
+	return nil
+}
+
+// DenyUser revokes every access token of a user issued before a time
+func (d *MemoryDenylist) DenyUser(ctx context.Context, userID uuid.UUID, issuedBefore time.Time, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.users[userID] = issuedBefore
+	return nil
+}
+
+// IsDenied reports whether an access token was revoked
+func (d *MemoryDenylist) IsDenied(ctx context.Context, claims *TokenClaims) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if expiresAt, exists := d.tokens[claims.Id]; exists && time.Now().Before(expiresAt) {
+		return true, nil
+	}
+
+	if issuedBefore, exists := d.users[claims.UserID]; exists && claims.IssuedAtMillis() < issuedBefore.UnixMilli() {
+		return true, nil
+	}
+
+	return false, nil
+}
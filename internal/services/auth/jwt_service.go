@@ -3,6 +3,7 @@ package auth
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -10,42 +11,70 @@ import (
 	"github.com/jaimesHub/golang-todo-app/internal/config"
 )
 
+// ErrUnknownKey is returned for tokens signed with a key that is not configured
+var ErrUnknownKey = errors.New("unknown signing key")
+
 // JWTService handles JWT token generation and validation
 type JWTService struct {
-	config *config.JWTConfig
+	config  *config.JWTConfig
+	keys    map[string]*signingKey
+	signing *signingKey
 }
 
 // TokenClaims represents the JWT claims
 type TokenClaims struct {
 	UserID uuid.UUID `json:"user_id"`
+
+	// IssuedAtMs is the issue time in milliseconds. iat only has second
+	// precision, too coarse to tell tokens issued just before revoking a
+	// user's sessions from those issued just after.
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
+
 	jwt.StandardClaims
 }
 
-// NewJWTService creates a new JWT service
-func NewJWTService(config *config.JWTConfig) *JWTService {
-	return &JWTService{config: config}
+// IssuedAtMillis returns the issue time of a token in Unix milliseconds
+func (c *TokenClaims) IssuedAtMillis() int64 {
+	if c.IssuedAtMs != 0 {
+		return c.IssuedAtMs
+	}
+	return c.IssuedAt * 1000
+}
+
+// NewJWTService creates a new JWT service, loading the signing keys from the
+// configuration
+func NewJWTService(config *config.JWTConfig) (*JWTService, error) {
+	keys, signing, err := loadKeys(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jwt keys: %w", err)
+	}
+	return &JWTService{config: config, keys: keys, signing: signing}, nil
 }
 
 // GenerateToken generates a new JWT token for a user
 func (s *JWTService) GenerateToken(userID uuid.UUID) (string, error) {
 	// Set expiration time
-	expirationTime := time.Now().Add(time.Hour * time.Duration(s.config.Expiration))
+	now := time.Now()
+	expirationTime := now.Add(s.config.AccessTokenTTL)
 
 	// Create claims
 	claims := &TokenClaims{
-		UserID: userID,
+		UserID:     userID,
+		IssuedAtMs: now.UnixMilli(),
 		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
 			ExpiresAt: expirationTime.Unix(),
-			IssuedAt:  time.Now().Unix(),
+			IssuedAt:  now.Unix(),
 			Issuer:    "todo-app",
 		},
 	}
 
 	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(s.signing.method, claims)
+	token.Header["kid"] = s.signing.id
 
-	// Sign token with secret key
-	tokenString, err := token.SignedString([]byte(s.config.Secret))
+	// Sign token with the current key
+	tokenString, err := token.SignedString(s.signing.sign)
 	if err != nil {
 		return "", err
 	}
@@ -57,11 +86,22 @@ func (s *JWTService) GenerateToken(userID uuid.UUID) (string, error) {
 func (s *JWTService) ValidateToken(tokenString string) (*TokenClaims, error) {
 	// Parse token
 	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+		// Choose the key by kid; tokens from before kids were set are
+		// checked against the current secret
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.keys[kid]
+		if kid == "" {
+			key, ok = s.keys[hmacKey(s.config.Secret).id]
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+		}
+
 		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(s.config.Secret), nil
+		return key.verify, nil
 	})
 
 	if err != nil {
@@ -76,14 +116,30 @@ func (s *JWTService) ValidateToken(tokenString string) (*TokenClaims, error) {
 	return nil, errors.New("invalid token")
 }
 
-// RefreshToken refreshes a JWT token
-func (s *JWTService) RefreshToken(tokenString string) (string, error) {
-	// Validate token
-	claims, err := s.ValidateToken(tokenString)
-	if err != nil {
-		return "", err
+// JWKS returns the public keys tokens may be signed with; HMAC secrets are
+// never published
+func (s *JWTService) JWKS() JWKSet {
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		ids = append(ids, id)
 	}
+	sort.Strings(ids)
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, id := range ids {
+		if jwk, ok := s.keys[id].jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+// AccessTokenTTL returns the lifetime of access tokens
+func (s *JWTService) AccessTokenTTL() time.Duration {
+	return s.config.AccessTokenTTL
+}
 
-	// Generate new token with same user ID
-	return s.GenerateToken(claims.UserID)
+// RefreshTokenTTL returns the lifetime of refresh tokens
+func (s *JWTService) RefreshTokenTTL() time.Duration {
+	return s.config.RefreshTokenTTL
 }
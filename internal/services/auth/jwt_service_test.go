@@ -1,6 +1,14 @@
-package services_test
+package auth_test
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -14,10 +22,11 @@ import (
 func TestGenerateAndValidateToken(t *testing.T) {
 	// Create a JWT service with test configuration
 	jwtConfig := &config.JWTConfig{
-		Secret:     "test-secret-key",
-		Expiration: 24,
+		Secret:         "test-secret-key",
+		AccessTokenTTL: 24 * time.Hour,
 	}
-	jwtService := auth.NewJWTService(jwtConfig)
+	jwtService, err := auth.NewJWTService(jwtConfig)
+	assert.NoError(t, err)
 
 	// Generate a token for a test user
 	userID := uuid.New()
@@ -43,13 +52,14 @@ func TestGenerateAndValidateToken(t *testing.T) {
 func TestInvalidToken(t *testing.T) {
 	// Create a JWT service with test configuration
 	jwtConfig := &config.JWTConfig{
-		Secret:     "test-secret-key",
-		Expiration: 24,
+		Secret:         "test-secret-key",
+		AccessTokenTTL: 24 * time.Hour,
 	}
-	jwtService := auth.NewJWTService(jwtConfig)
+	jwtService, err := auth.NewJWTService(jwtConfig)
+	assert.NoError(t, err)
 
 	// Test with invalid token
-	_, err := jwtService.ValidateToken("invalid-token")
+	_, err = jwtService.ValidateToken("invalid-token")
 	assert.Error(t, err)
 
 	// Test with expired token
@@ -71,32 +81,116 @@ func TestInvalidToken(t *testing.T) {
 	assert.Error(t, err)
 }
 
-func TestRefreshToken(t *testing.T) {
-	// Create a JWT service with test configuration
-	jwtConfig := &config.JWTConfig{
-		Secret:     "test-secret-key",
-		Expiration: 24,
-	}
-	jwtService := auth.NewJWTService(jwtConfig)
+func TestMemoryDenylist(t *testing.T) {
+	jwtService, err := auth.NewJWTService(&config.JWTConfig{Secret: "test-secret-key", AccessTokenTTL: 15 * time.Minute})
+	assert.NoError(t, err)
+	denylist := auth.NewMemoryDenylist()
+	ctx := context.Background()
 
-	// Generate a token for a test user
 	userID := uuid.New()
-	token, err := jwtService.GenerateToken(userID)
+	first, err := jwtService.GenerateToken(userID)
+	assert.NoError(t, err)
+	second, err := jwtService.GenerateToken(userID)
+	assert.NoError(t, err)
+
+	firstClaims, _ := jwtService.ValidateToken(first)
+	secondClaims, _ := jwtService.ValidateToken(second)
+	assert.NotEqual(t, firstClaims.Id, secondClaims.Id)
+
+	// Denying one jti leaves the user's other tokens valid
+	assert.NoError(t, denylist.DenyToken(ctx, firstClaims.Id, time.Minute))
+	denied, err := denylist.IsDenied(ctx, firstClaims)
 	assert.NoError(t, err)
+	assert.True(t, denied)
+	denied, _ = denylist.IsDenied(ctx, secondClaims)
+	assert.False(t, denied)
+
+	// Denying the user revokes every token issued before the cutoff
+	assert.NoError(t, denylist.DenyUser(ctx, userID, time.Now().Add(time.Second), time.Minute))
+	denied, _ = denylist.IsDenied(ctx, secondClaims)
+	assert.True(t, denied)
+}
 
-	// Refresh the token
-	newToken, err := jwtService.RefreshToken(token)
+// writeKey writes a PKCS#8 private key to dir as <kid>.pem
+func writeKey(t *testing.T, dir, kid string, key interface{}) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+}
 
-	// Assert token refresh
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
 	assert.NoError(t, err)
-	assert.NotEmpty(t, newToken)
-	assert.NotEqual(t, token, newToken) // New token should be different
+	writeKey(t, dir, "2026-01", oldKey)
 
-	// Validate the new token
-	claims, err := jwtService.ValidateToken(newToken)
+	// Tokens signed with the shared secret before the switch to keys
+	legacy, err := auth.NewJWTService(&config.JWTConfig{Secret: "test-secret-key", AccessTokenTTL: time.Hour})
+	assert.NoError(t, err)
+	legacyToken, err := legacy.GenerateToken(uuid.New())
+	assert.NoError(t, err)
 
-	// Assert new token validation
+	cfg := &config.JWTConfig{Secret: "test-secret-key", AccessTokenTTL: time.Hour, KeyDir: dir}
+	before, err := auth.NewJWTService(cfg)
 	assert.NoError(t, err)
-	assert.NotNil(t, claims)
-	assert.Equal(t, userID, claims.UserID)
+	oldToken, err := before.GenerateToken(uuid.New())
+	assert.NoError(t, err)
+
+	// Adding a newer key rotates signing to it
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	writeKey(t, dir, "2026-02", rsaKey)
+	after, err := auth.NewJWTService(cfg)
+	assert.NoError(t, err)
+
+	newToken, err := after.GenerateToken(uuid.New())
+	assert.NoError(t, err)
+	parsed, _, err := new(jwt.Parser).ParseUnverified(newToken, &auth.TokenClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-02", parsed.Header["kid"])
+	assert.Equal(t, "RS256", parsed.Header["alg"])
+
+	// Tokens signed with previous keys stay valid
+	for _, token := range []string{legacyToken, oldToken, newToken} {
+		_, err := after.ValidateToken(token)
+		assert.NoError(t, err)
+	}
+
+	// Only the public keys are published
+	jwks := after.JWKS()
+	assert.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "2026-01", jwks.Keys[0].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+	assert.Equal(t, "2026-02", jwks.Keys[1].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+
+	// Tokens from a key that is no longer configured are rejected
+	other, err := auth.NewJWTService(&config.JWTConfig{Secret: "another-secret", AccessTokenTTL: time.Hour})
+	assert.NoError(t, err)
+	otherToken, err := other.GenerateToken(uuid.New())
+	assert.NoError(t, err)
+	_, err = after.ValidateToken(otherToken)
+	assert.ErrorIs(t, err, auth.ErrUnknownKey)
+}
+
+func TestPlaceholderSecretNotTrustedWithKeys(t *testing.T) {
+	dir := t.TempDir()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	writeKey(t, dir, "current", key)
+
+	jwtService, err := auth.NewJWTService(&config.JWTConfig{Secret: config.DefaultJWTSecret, AccessTokenTTL: time.Hour, KeyDir: dir})
+	assert.NoError(t, err)
+
+	// A token forged with the placeholder secret must not validate
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.TokenClaims{
+		UserID:         uuid.New(),
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()},
+	})
+	forgedToken, err := forged.SignedString([]byte(config.DefaultJWTSecret))
+	assert.NoError(t, err)
+	_, err = jwtService.ValidateToken(forgedToken)
+	assert.Error(t, err)
 }
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+)
+
+// signingKey is a key tokens are signed or verified with
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	sign   interface{} // nil for keys that only verify
+	verify interface{}
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// loadKeys builds the key set described by the configuration and returns it
+// by kid together with the key new tokens are signed with
+func loadKeys(cfg *config.JWTConfig) (map[string]*signingKey, *signingKey, error) {
+	keys := make(map[string]*signingKey)
+
+	// Secrets sign only when no key directory is configured. The placeholder
+	// secret is never trusted next to real keys.
+	var current *signingKey
+	if cfg.KeyDir == "" || cfg.Secret != config.DefaultJWTSecret {
+		current = hmacKey(cfg.Secret)
+		keys[current.id] = current
+	}
+	for _, secret := range cfg.PreviousSecrets {
+		key := hmacKey(secret)
+		key.sign = nil
+		keys[key.id] = key
+	}
+
+	if cfg.KeyDir == "" {
+		return keys, current, nil
+	}
+
+	if current != nil {
+		current.sign = nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(cfg.KeyDir, "*.pem"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var privateIDs []string
+	for _, file := range files {
+		key, err := readKeyFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, exists := keys[key.id]; exists {
+			return nil, nil, fmt.Errorf("duplicate key id %q", key.id)
+		}
+		keys[key.id] = key
+		if key.sign != nil {
+			privateIDs = append(privateIDs, key.id)
+		}
+	}
+
+	signingID := cfg.SigningKeyID
+	if signingID == "" {
+		if len(privateIDs) == 0 {
+			return nil, nil, fmt.Errorf("no private key found in %s", cfg.KeyDir)
+		}
+		sort.Strings(privateIDs)
+		signingID = privateIDs[len(privateIDs)-1]
+	}
+
+	signing, ok := keys[signingID]
+	if !ok || signing.sign == nil {
+		return nil, nil, fmt.Errorf("signing key %q not found in %s", signingID, cfg.KeyDir)
+	}
+
+	return keys, signing, nil
+}
+
+// hmacKey returns an HS256 key. Its kid is derived from the secret so that
+// every instance sharing the secret agrees on it.
+func hmacKey(secret string) *signingKey {
+	sum := sha256.Sum256([]byte(secret))
+	return &signingKey{
+		id:     "hs-" + hex.EncodeToString(sum[:4]),
+		method: jwt.SigningMethodHS256,
+		sign:   []byte(secret),
+		verify: []byte(secret),
+	}
+}
+
+// readKeyFile reads an RSA or Ed25519 key from a PEM file named <kid>.pem.
+// Private keys sign and verify; public keys of retired keys only verify.
+func readKeyFile(file string) (*signingKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", file)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", file, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	key := &signingKey{id: strings.TrimSuffix(filepath.Base(file), ".pem")}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.sign, key.verify = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.method, key.verify = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.method, key.sign, key.verify = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.method, key.verify = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("%s: unsupported key type %T", file, parsed)
+	}
+
+	if key.method == jwt.SigningMethodRS256 && key.verify.(*rsa.PublicKey).N.BitLen() < 2048 {
+		return nil, fmt.Errorf("%s: RSA keys must be at least 2048 bits", file)
+	}
+
+	return key, nil
+}
+
+// jwk returns the public part of a key, or false for HMAC keys
+func (k *signingKey) jwk() (JWK, bool) {
+	switch pub := k.verify.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: k.method.Alg(),
+			Kid: k.id,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: k.method.Alg(),
+			Kid: k.id,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, true
+	}
+	return JWK{}, false
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	redisService "github.com/jaimesHub/golang-todo-app/internal/services/redis"
+)
+
+// Attempts are the recent failed logins for a key
+type Attempts struct {
+	Count int
+	Last  time.Time
+}
+
+// AttemptStore counts failed logins. Keys are forgotten ttl after their
+// last failure.
+type AttemptStore interface {
+	// Get returns the failures recorded for a key
+	Get(ctx context.Context, key string) (Attempts, error)
+	// Fail records a failure at a time and returns the updated attempts
+	Fail(ctx context.Context, key string, at time.Time, ttl time.Duration) (Attempts, error)
+	// Reset forgets the failures of a key
+	Reset(ctx context.Context, key string) error
+}
+
+// RedisAttemptStore is an AttemptStore shared by every API instance through Redis
+type RedisAttemptStore struct {
+	client *redisService.Client
+}
+
+// NewRedisAttemptStore creates an attempt store in Redis
+func NewRedisAttemptStore(client *redisService.Client) *RedisAttemptStore {
+	return &RedisAttemptStore{client: client}
+}
+
+// attemptKey holds a hash with the failure count and the Unix time in
+// milliseconds of the last failure
+func attemptKey(key string) string {
+	return "auth:login:" + key
+}
+
+// Get returns the failures recorded for a key
+func (s *RedisAttemptStore) Get(ctx context.Context, key string) (Attempts, error) {
+	values, err := s.client.HGetAll(ctx, attemptKey(key))
+	if err != nil {
+		return Attempts{}, err
+	}
+	if len(values) == 0 {
+		return Attempts{}, nil
+	}
+
+	count, err := strconv.Atoi(values["count"])
+	if err != nil {
+		return Attempts{}, err
+	}
+	last, err := strconv.ParseInt(values["last"], 10, 64)
+	if err != nil {
+		return Attempts{}, err
+	}
+	return Attempts{Count: count, Last: time.UnixMilli(last)}, nil
+}
+
+// Fail records a failure at a time and returns the updated attempts
+func (s *RedisAttemptStore) Fail(ctx context.Context, key string, at time.Time, ttl time.Duration) (Attempts, error) {
+	count, err := s.client.HIncrByWithTTL(ctx, attemptKey(key), "count", 1, map[string]interface{}{"last": at.UnixMilli()}, ttl)
+	if err != nil {
+		return Attempts{}, err
+	}
+	return Attempts{Count: int(count), Last: at}, nil
+}
+
+// Reset forgets the failures of a key
+func (s *RedisAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.client.Delete(ctx, attemptKey(key))
+}
+
+// MemoryAttemptStore is an AttemptStore for a single process, used in tests
+// and when Redis is unavailable
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]memoryAttempts
+}
+
+type memoryAttempts struct {
+	Attempts
+	expiresAt time.Time
+}
+
+// NewMemoryAttemptStore creates an in-memory attempt store
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{attempts: make(map[string]memoryAttempts)}
+}
+
+// Get returns the failures recorded for a key
+func (s *MemoryAttemptStore) Get(ctx context.Context, key string) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.attempts[key]
+	if !exists || !time.Now().Before(entry.expiresAt) {
+		return Attempts{}, nil
+	}
+	return entry.Attempts, nil
+}
+
+// Fail records a failure at a time and returns the updated attempts
+func (s *MemoryAttemptStore) Fail(ctx context.Context, key string, at time.Time, ttl time.Duration) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range s.attempts {
+		if !now.Before(entry.expiresAt) {
+			delete(s.attempts, k)
+		}
+	}
+
+	entry := s.attempts[key]
+	entry.Count++
+	entry.Last = at
+	entry.expiresAt = now.Add(ttl)
+	s.attempts[key] = entry
+	return entry.Attempts, nil
+}
+
+// Reset forgets the failures of a key
+func (s *MemoryAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+// LoginLimiter slows down repeated failed logins for an email or from an IP
+// address and locks them out once a limit is reached
+type LoginLimiter struct {
+	store AttemptStore
+	cfg   config.LoginConfig
+}
+
+// LoginFailure describes the failed login just recorded
+type LoginFailure struct {
+	// EmailLocked and IPLocked are set by the failure that reached a limit
+	EmailLocked bool
+	IPLocked    bool
+	// Attempts is the failure count of the email
+	Attempts int
+}
+
+// NewLoginLimiter creates a login limiter
+func NewLoginLimiter(store AttemptStore, cfg config.LoginConfig) *LoginLimiter {
+	return &LoginLimiter{store: store, cfg: cfg}
+}
+
+func emailAttemptKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns how long a login for an email from an IP address has to
+// wait, or zero when it may go ahead
+func (l *LoginLimiter) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := time.Now()
+
+	emailAttempts, err := l.store.Get(ctx, emailAttemptKey(email))
+	if err != nil {
+		return 0, err
+	}
+	wait := l.wait(emailAttempts, l.cfg.MaxAttemptsPerEmail, now)
+
+	if ip != "" {
+		ipAttempts, err := l.store.Get(ctx, ipAttemptKey(ip))
+		if err != nil {
+			return 0, err
+		}
+		if ipWait := l.wait(ipAttempts, l.cfg.MaxAttemptsPerIP, now); ipWait > wait {
+			wait = ipWait
+		}
+	}
+
+	return wait, nil
+}
+
+// Failure records a failed login for an email from an IP address
+func (l *LoginLimiter) Failure(ctx context.Context, email, ip string) (*LoginFailure, error) {
+	now := time.Now()
+	ttl := l.cfg.AttemptWindow
+	if l.cfg.LockoutDuration > ttl {
+		ttl = l.cfg.LockoutDuration
+	}
+
+	emailAttempts, err := l.store.Fail(ctx, emailAttemptKey(email), now, ttl)
+	if err != nil {
+		return nil, err
+	}
+	failure := &LoginFailure{
+		EmailLocked: l.cfg.MaxAttemptsPerEmail > 0 && emailAttempts.Count == l.cfg.MaxAttemptsPerEmail,
+		Attempts:    emailAttempts.Count,
+	}
+
+	if ip != "" {
+		ipAttempts, err := l.store.Fail(ctx, ipAttemptKey(ip), now, ttl)
+		if err != nil {
+			return nil, err
+		}
+		failure.IPLocked = l.cfg.MaxAttemptsPerIP > 0 && ipAttempts.Count == l.cfg.MaxAttemptsPerIP
+	}
+
+	return failure, nil
+}
+
+// Success forgets the failures of an email. Failures from the IP address
+// are kept so that one valid account does not unlock guessing at others.
+func (l *LoginLimiter) Success(ctx context.Context, email string) error {
+	return l.store.Reset(ctx, emailAttemptKey(email))
+}
+
+// LockoutDuration returns how long a lockout lasts
+func (l *LoginLimiter) LockoutDuration() time.Duration {
+	return l.cfg.LockoutDuration
+}
+
+// wait returns how long after now the next attempt for a key is allowed
+func (l *LoginLimiter) wait(attempts Attempts, limit int, now time.Time) time.Duration {
+	if attempts.Count == 0 {
+		return 0
+	}
+
+	var delay time.Duration
+	if limit > 0 && attempts.Count >= limit {
+		delay = l.cfg.LockoutDuration
+	} else {
+		delay = l.cfg.DelayBase
+		for i := 1; i < attempts.Count && delay < l.cfg.DelayMax; i++ {
+			delay *= 2
+		}
+		if delay > l.cfg.DelayMax {
+			delay = l.cfg.DelayMax
+		}
+	}
+
+	if wait := attempts.Last.Add(delay).Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package auth_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
+	redisService "github.com/jaimesHub/golang-todo-app/internal/services/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+var testLoginConfig = config.LoginConfig{
+	MaxAttemptsPerEmail: 3,
+	MaxAttemptsPerIP:    5,
+	AttemptWindow:       15 * time.Minute,
+	LockoutDuration:     15 * time.Minute,
+	DelayBase:           time.Second,
+	DelayMax:            time.Minute,
+}
+
+func testLoginLimiter(t *testing.T, store auth.AttemptStore) {
+	limiter := auth.NewLoginLimiter(store, testLoginConfig)
+	ctx := context.Background()
+
+	wait, err := limiter.Check(ctx, "user@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, wait)
+
+	// Delays double with every failure
+	failure, err := limiter.Failure(ctx, "user@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.False(t, failure.EmailLocked)
+	wait, _ = limiter.Check(ctx, "User@Example.com", "10.0.0.2")
+	assert.InDelta(t, time.Second, wait, float64(100*time.Millisecond))
+
+	failure, _ = limiter.Failure(ctx, "user@example.com", "10.0.0.1")
+	assert.False(t, failure.EmailLocked)
+	wait, _ = limiter.Check(ctx, "user@example.com", "10.0.0.2")
+	assert.InDelta(t, 2*time.Second, wait, float64(100*time.Millisecond))
+
+	// The third failure locks the email out
+	failure, _ = limiter.Failure(ctx, "user@example.com", "10.0.0.1")
+	assert.True(t, failure.EmailLocked)
+	assert.Equal(t, 3, failure.Attempts)
+	wait, _ = limiter.Check(ctx, "user@example.com", "10.0.0.2")
+	assert.InDelta(t, 15*time.Minute, wait, float64(time.Second))
+
+	// The IP address is only delayed, for other emails as well
+	wait, _ = limiter.Check(ctx, "other@example.com", "10.0.0.1")
+	assert.InDelta(t, 4*time.Second, wait, float64(100*time.Millisecond))
+
+	// A successful login clears the email but not the IP address
+	assert.NoError(t, limiter.Success(ctx, "user@example.com"))
+	wait, _ = limiter.Check(ctx, "user@example.com", "10.0.0.2")
+	assert.Zero(t, wait)
+	wait, _ = limiter.Check(ctx, "user@example.com", "10.0.0.1")
+	assert.NotZero(t, wait)
+}
+
+func TestLoginLimiterMemory(t *testing.T) {
+	testLoginLimiter(t, auth.NewMemoryAttemptStore())
+}
+
+func TestLoginLimiterRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	port, err := strconv.Atoi(server.Port())
+	assert.NoError(t, err)
+	client, err := redisService.NewClient(config.RedisConfig{Host: server.Host(), Port: port})
+	assert.NoError(t, err)
+
+	testLoginLimiter(t, auth.NewRedisAttemptStore(client))
+
+	// Failures are forgotten once the window has passed
+	assert.True(t, server.Exists("auth:login:ip:10.0.0.1"))
+	server.FastForward(16 * time.Minute)
+	assert.False(t, server.Exists("auth:login:ip:10.0.0.1"))
+}
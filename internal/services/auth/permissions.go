@@ -0,0 +1,27 @@
+package auth
+
+import "slices"
+
+// Permissions roles can grant. The built-in admin role has all of them and
+// the built-in user role has none.
+const (
+	// PermissionUsersRead allows listing users and viewing their activities
+	PermissionUsersRead = "users:read"
+	// PermissionUsersManage allows deactivating, reactivating and logging
+	// out users
+	PermissionUsersManage = "users:manage"
+	// PermissionRolesManage allows managing custom roles and assigning roles
+	PermissionRolesManage = "roles:manage"
+)
+
+// Permissions lists every permission in the order they are documented
+var Permissions = []string{
+	PermissionUsersRead,
+	PermissionUsersManage,
+	PermissionRolesManage,
+}
+
+// ValidPermission reports whether a permission exists
+func ValidPermission(permission string) bool {
+	return slices.Contains(Permissions, permission)
+}
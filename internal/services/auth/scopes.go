@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Scopes personal access tokens can be limited to. Each resource has a
+// read scope for safe methods and a write scope for everything else.
+const (
+	ScopeTasksRead       = "tasks:read"
+	ScopeTasksWrite      = "tasks:write"
+	ScopeProjectsRead    = "projects:read"
+	ScopeProjectsWrite   = "projects:write"
+	ScopeTagsRead        = "tags:read"
+	ScopeTagsWrite       = "tags:write"
+	ScopeWorkspacesRead  = "workspaces:read"
+	ScopeWorkspacesWrite = "workspaces:write"
+	ScopeUserRead        = "user:read"
+	ScopeUserWrite       = "user:write"
+)
+
+// Scopes lists every scope in the order they are documented
+var Scopes = []string{
+	ScopeTasksRead, ScopeTasksWrite,
+	ScopeProjectsRead, ScopeProjectsWrite,
+	ScopeTagsRead, ScopeTagsWrite,
+	ScopeWorkspacesRead, ScopeWorkspacesWrite,
+	ScopeUserRead, ScopeUserWrite,
+}
+
+// ValidScope reports whether a scope exists
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// ScopeFor returns the scope a request with the given method needs on a
+// resource such as "tasks"
+func ScopeFor(resource, method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return resource + ":read"
+	default:
+		return resource + ":write"
+	}
+}
+
+// HasScope reports whether granted scopes allow a scope. A write scope
+// implies the read scope of the same resource.
+func HasScope(granted []string, scope string) bool {
+	if slices.Contains(granted, scope) {
+		return true
+	}
+	resource, ok := strings.CutSuffix(scope, ":read")
+	return ok && slices.Contains(granted, resource+":write")
+}
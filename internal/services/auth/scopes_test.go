@@ -0,0 +1,22 @@
+package auth_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopes(t *testing.T) {
+	assert.True(t, auth.ValidScope("tasks:write"))
+	assert.False(t, auth.ValidScope("tasks:admin"))
+
+	assert.Equal(t, "tasks:read", auth.ScopeFor("tasks", http.MethodGet))
+	assert.Equal(t, "tasks:write", auth.ScopeFor("tasks", http.MethodDelete))
+
+	// Write implies read of the same resource only
+	assert.True(t, auth.HasScope([]string{"tasks:write"}, "tasks:read"))
+	assert.False(t, auth.HasScope([]string{"tasks:read"}, "tasks:write"))
+	assert.False(t, auth.HasScope([]string{"tags:write"}, "tasks:read"))
+}
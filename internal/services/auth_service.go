@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+	// ErrRefreshTokenReused is returned when an already rotated refresh token
+	// is presented again; every token of its family is revoked
+	ErrRefreshTokenReused = errors.New("refresh token was already used")
+
+	// ErrInvalidCredentials is returned for an unknown email or a wrong password
+	ErrInvalidCredentials = errors.New("invalid email or password")
+
+	// ErrTooManyAttempts is returned while logins are throttled
+	ErrTooManyAttempts = errors.New("too many failed login attempts, try again later")
+)
+
+// LoginThrottledError reports how long to wait before logging in again
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// dummyPasswordHash is compared against when the email is unknown so that
+// failed logins take as long whether or not the account exists
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// TokenPair is a short-lived access token and the refresh token that renews it
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // seconds until the access token expires
+}
+
+// AuthService issues, rotates and revokes tokens
+type AuthService struct {
+	repos    *repository.Repositories
+	jwt      *auth.JWTService
+	denylist auth.Denylist
+	limiter  *auth.LoginLimiter
+}
+
+// NewAuthService creates a new authentication service. Without a denylist,
+// logging out revokes refresh tokens but access tokens stay valid until
+// they expire.
+func NewAuthService(repos *repository.Repositories, jwtService *auth.JWTService, denylist auth.Denylist) *AuthService {
+	return &AuthService{repos: repos, jwt: jwtService, denylist: denylist}
+}
+
+// SetLoginLimiter enables brute-force protection for Authenticate
+func (s *AuthService) SetLoginLimiter(limiter *auth.LoginLimiter) {
+	s.limiter = limiter
+}
+
+// Authenticate checks an email and password. Logins for an email or from
+// an IP address that failed too often are refused with a
+// LoginThrottledError until their delay or lockout is over.
+func (s *AuthService) Authenticate(ctx context.Context, email, password, ip string) (*models.User, error) {
+	if s.limiter != nil {
+		wait, err := s.limiter.Check(ctx, email, ip)
+		if err != nil {
+			return nil, err
+		}
+		if wait > 0 {
+			return nil, &LoginThrottledError{RetryAfter: wait}
+		}
+	}
+
+	user, err := s.repos.Users.FindByEmail(email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	hash := dummyPasswordHash
+	if user != nil {
+		hash = []byte(user.Password)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || user == nil {
+		if err := s.loginFailed(ctx, user, email, ip); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	// With two-factor authentication the failures are only forgotten once
+	// the second step succeeds, so retrying the password does not reset
+	// the count of wrong codes
+	if s.limiter != nil && !user.TwoFactorEnabled() {
+		if err := s.limiter.Success(ctx, email); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// loginFailed records a failed login and logs a lockout on the account
+func (s *AuthService) loginFailed(ctx context.Context, user *models.User, email, ip string) error {
+	if s.limiter == nil {
+		return nil
+	}
+
+	failure, err := s.limiter.Failure(ctx, email, ip)
+	if err != nil {
+		return err
+	}
+
+	if user == nil || (!failure.EmailLocked && !failure.IPLocked) {
+		return nil
+	}
+
+	details := fmt.Sprintf("Login locked for %s after %d failed attempts", s.limiter.LockoutDuration(), failure.Attempts)
+	if !failure.EmailLocked {
+		details = fmt.Sprintf("Logins from %s locked for %s", ip, s.limiter.LockoutDuration())
+	}
+	activity := &models.Activity{
+		UserID:    user.ID,
+		Action:    "login_locked",
+		Entity:    "user",
+		EntityID:  user.ID,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repos.Activities.Create(activity); err != nil {
+		// Just log the error, the lockout is in place
+	}
+
+	return nil
+}
+
+// IssueTokens starts a new session for a user
+func (s *AuthService) IssueTokens(userID uuid.UUID) (*TokenPair, error) {
+	return s.issue(userID, uuid.New())
+}
+
+// Refresh rotates a refresh token: it is marked used and a new pair in the
+// same family is returned. Presenting a used token, or a token of a deleted
+// or deactivated user, revokes the family.
+func (s *AuthService) Refresh(refreshToken string) (*TokenPair, error) {
+	token, err := s.repos.Tokens.FindByHash(hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if token.RevokedAt != nil || !time.Now().Before(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Deleted and deactivated users get no new tokens, and their session
+	// ends for good
+	user, err := s.repos.Users.FindByID(token.UserID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		if err := s.repos.Tokens.RevokeFamily(token.FamilyID, time.Now()); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if token.UsedAt != nil {
+		return nil, s.revokeReused(token)
+	}
+
+	// A concurrent request may have rotated the token since it was read
+	used, err := s.repos.Tokens.MarkUsed(token.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, s.revokeReused(token)
+	}
+
+	return s.issue(token.UserID, token.FamilyID)
+}
+
+// Logout ends the session of an access token: its refresh token family is
+// revoked, if given, and the access token itself is denied
+func (s *AuthService) Logout(ctx context.Context, claims *auth.TokenClaims, refreshToken string) error {
+	if refreshToken != "" {
+		token, err := s.repos.Tokens.FindByHash(hashToken(refreshToken))
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+
+		if err == nil && token.UserID == claims.UserID {
+			if err := s.repos.Tokens.RevokeFamily(token.FamilyID, time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.denyAccessToken(ctx, claims)
+}
+
+// LogoutAll ends every session of the user an access token belongs to
+func (s *AuthService) LogoutAll(ctx context.Context, claims *auth.TokenClaims) error {
+	return s.RevokeSessions(ctx, claims.UserID)
+}
+
+// RevokeSessions ends every session of a user: all refresh tokens are
+// revoked and all access tokens issued so far are denied
+func (s *AuthService) RevokeSessions(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	if err := s.repos.Tokens.RevokeUser(userID, now); err != nil {
+		return err
+	}
+
+	if s.denylist == nil {
+		return nil
+	}
+
+	return s.denylist.DenyUser(ctx, userID, now, s.jwt.AccessTokenTTL())
+}
+
+// JWKS returns the public keys access tokens are signed with
+func (s *AuthService) JWKS() auth.JWKSet {
+	return s.jwt.JWKS()
+}
+
+// issue creates an access token and a refresh token in the given family
+func (s *AuthService) issue(userID, familyID uuid.UUID) (*TokenPair, error) {
+	accessToken, err := s.jwt.GenerateToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Tokens.Create(&models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.jwt.RefreshTokenTTL()),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.jwt.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// revokeReused revokes the family of a refresh token presented after rotation
+func (s *AuthService) revokeReused(token *models.RefreshToken) error {
+	if err := s.repos.Tokens.RevokeFamily(token.FamilyID, time.Now()); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// denyAccessToken adds an access token to the denylist until it expires
+func (s *AuthService) denyAccessToken(ctx context.Context, claims *auth.TokenClaims) error {
+	if s.denylist == nil {
+		return nil
+	}
+
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if ttl <= 0 {
+		return nil
+	}
+	return s.denylist.DenyToken(ctx, claims.Id, ttl)
+}
+
+// newOpaqueToken returns a random URL-safe token
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the hex SHA-256 of a refresh token, the form it is stored in
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+)
+
+var (
+	// ErrCommentNotFound is returned when a comment does not exist or belongs
+	// to another task
+	ErrCommentNotFound = errors.New("comment not found")
+
+	// ErrCommentEmpty is returned for comments without text
+	ErrCommentEmpty = errors.New("comment cannot be empty")
+
+	// ErrCommentForbidden is returned when editing another user's comment, or
+	// deleting it without moderating the task
+	ErrCommentForbidden = errors.New("not allowed to change this comment")
+
+	// ErrInvalidCursor is returned for malformed pagination cursors
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// mentionPattern matches @email mentions that do not continue a word, e.g.
+// "@jane@example.com" but not "bob@jane@example.com"
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([\w.%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,})`)
+
+// CommentService manages the comments on tasks and notifies mentioned users
+type CommentService struct {
+	repos       *repository.Repositories
+	taskService *TaskService
+	queue       jobs.Enqueuer
+	queueName   string
+}
+
+// NewCommentService creates a new comment service
+func NewCommentService(repos *repository.Repositories, taskService *TaskService) *CommentService {
+	return &CommentService{repos: repos, taskService: taskService}
+}
+
+// SetQueue enables notifying mentioned users through jobs on the named
+// queue. Without a queue, mentions are not notified.
+func (s *CommentService) SetQueue(q jobs.Enqueuer, queueName string) {
+	s.queue = q
+	s.queueName = queueName
+}
+
+// CommentPage is one page of the comments of a task
+type CommentPage struct {
+	Comments []models.Comment
+	// NextCursor fetches the following page; it is empty on the last page
+	NextCursor string
+}
+
+// CreateComment adds a comment to a task the user can read and notifies the
+// users it mentions
+func (s *CommentService) CreateComment(ctx context.Context, taskID uuid.UUID, author *models.User, body string) (*models.Comment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, ErrCommentEmpty
+	}
+
+	if _, err := s.taskService.GetTaskByID(taskID, author.ID); err != nil {
+		return nil, err
+	}
+
+	mentioned, err := s.mentionedUsers(taskID, author.ID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	comment := &models.Comment{
+		TaskID:    taskID,
+		UserID:    author.ID,
+		Body:      body,
+		Mentions:  mentionEmails(mentioned),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repos.Comments.Create(comment); err != nil {
+		return nil, err
+	}
+
+	if err := s.notifyMentions(ctx, comment, mentioned); err != nil {
+		return nil, err
+	}
+
+	comment.User = author
+	return comment, nil
+}
+
+// GetComments lists the comments of a task the user can read, oldest first.
+// An empty cursor starts at the first comment.
+func (s *CommentService) GetComments(taskID, userID uuid.UUID, cursor string, limit int) (*CommentPage, error) {
+	after, err := decodeCommentCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.taskService.GetTaskByID(taskID, userID); err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra comment to tell whether another page follows
+	comments, err := s.repos.Comments.ListByTask(taskID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CommentPage{Comments: comments}
+	if len(comments) > limit {
+		page.Comments = comments[:limit]
+		page.NextCursor = encodeCommentCursor(&page.Comments[limit-1])
+	}
+	return page, nil
+}
+
+// UpdateComment changes the text of one of the user's comments. Users
+// mentioned for the first time are notified.
+func (s *CommentService) UpdateComment(ctx context.Context, taskID, commentID uuid.UUID, author *models.User, body string) (*models.Comment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, ErrCommentEmpty
+	}
+
+	comment, _, err := s.findComment(taskID, commentID, author.ID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != author.ID {
+		return nil, ErrCommentForbidden
+	}
+
+	mentioned, err := s.mentionedUsers(taskID, author.ID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	notified := make(map[string]bool, len(comment.Mentions))
+	for _, email := range comment.Mentions {
+		notified[email] = true
+	}
+	newlyMentioned := make([]models.User, 0, len(mentioned))
+	for _, user := range mentioned {
+		if !notified[strings.ToLower(user.Email)] {
+			newlyMentioned = append(newlyMentioned, user)
+		}
+	}
+
+	now := time.Now()
+	comment.Body = body
+	comment.Mentions = mentionEmails(mentioned)
+	comment.EditedAt = &now
+	comment.UpdatedAt = now
+	if err := s.repos.Comments.Update(comment); err != nil {
+		return nil, err
+	}
+
+	if err := s.notifyMentions(ctx, comment, newlyMentioned); err != nil {
+		return nil, err
+	}
+
+	comment.User = author
+	return comment, nil
+}
+
+// DeleteComment deletes a comment. Authors delete their own comments; the
+// owner of a personal task and admins of a workspace delete any comment on
+// their tasks.
+func (s *CommentService) DeleteComment(taskID, commentID, userID uuid.UUID) (*models.Comment, error) {
+	comment, task, err := s.findComment(taskID, commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.UserID != userID {
+		moderator, err := s.canModerate(task, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !moderator {
+			return nil, ErrCommentForbidden
+		}
+	}
+
+	if err := s.repos.Comments.Delete(comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// findComment retrieves a comment of a task the user can read
+func (s *CommentService) findComment(taskID, commentID, userID uuid.UUID) (*models.Comment, *models.Task, error) {
+	task, err := s.taskService.GetTaskByID(taskID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comment, err := s.repos.Comments.FindByID(commentID)
+	if err != nil || comment.TaskID != taskID {
+		if err == nil || errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrCommentNotFound
+		}
+		return nil, nil, err
+	}
+	return comment, task, nil
+}
+
+// canModerate reports whether a user may delete other users' comments on a task
+func (s *CommentService) canModerate(task *models.Task, userID uuid.UUID) (bool, error) {
+	if task.WorkspaceID == nil {
+		return task.UserID == userID, nil
+	}
+
+	role, err := memberRole(s.repos, *task.WorkspaceID, userID)
+	if err != nil {
+		return false, err
+	}
+	return canManageMembers(role), nil
+}
+
+// mentionedUsers resolves the @email mentions of a comment to the users who
+// can read the task, leaving out the author. Mentions of unknown addresses
+// and of users without access are ignored, so a comment does not reveal the
+// task to them.
+func (s *CommentService) mentionedUsers(taskID, authorID uuid.UUID, body string) ([]models.User, error) {
+	users := []models.User{}
+	for _, email := range parseMentions(body) {
+		user, err := s.repos.Users.FindByEmail(email)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if user.ID == authorID {
+			continue
+		}
+
+		if _, err := s.taskService.GetTaskByID(taskID, user.ID); err != nil {
+			if errors.Is(err, ErrTaskNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, nil
+}
+
+// notifyMentions enqueues a comment_mention job for each mentioned user
+func (s *CommentService) notifyMentions(ctx context.Context, comment *models.Comment, users []models.User) error {
+	if s.queue == nil {
+		return nil
+	}
+
+	for _, user := range users {
+		if _, err := jobs.CommentMention.Enqueue(ctx, s.queue, s.queueName, jobs.CommentMentionPayload{
+			CommentID: comment.ID,
+			UserID:    user.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseMentions returns the distinct lowercased emails mentioned as @email
+func parseMentions(body string) []string {
+	seen := make(map[string]bool)
+	emails := []string{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		email := strings.ToLower(match[1])
+		if !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// mentionEmails returns the lowercased emails of mentioned users
+func mentionEmails(users []models.User) models.StringList {
+	emails := make(models.StringList, 0, len(users))
+	for _, user := range users {
+		emails = append(emails, strings.ToLower(user.Email))
+	}
+	return emails
+}
+
+// encodeCommentCursor builds the opaque cursor of the page after a comment
+func encodeCommentCursor(comment *models.Comment) string {
+	raw := comment.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + comment.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCommentCursor parses a cursor built by encodeCommentCursor; an empty
+// cursor yields nil
+func decodeCommentCursor(cursor string) (*repository.CommentCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+
+	parsedTime, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &repository.CommentCursor{CreatedAt: parsedTime, ID: parsedID}, nil
+}
@@ -0,0 +1,130 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentMentions(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	taskService := services.NewTaskService(repos)
+	commentService := services.NewCommentService(repos, taskService)
+	mentionQueue := &fakeAccountQueue{scheduled: make(map[string]time.Time)}
+	commentService.SetQueue(mentionQueue, "tasks")
+	shareService := services.NewShareService(repos)
+	userService := services.NewUserService(repos)
+	sink := &fakeNotifier{}
+	mentionService := services.NewMentionService(repos, sink)
+	ctx := context.Background()
+
+	owner, err := userService.CreateUser("owner@example.com", "password123", "Olive", "Owner")
+	assert.NoError(t, err)
+	friend, err := userService.CreateUser("friend@example.com", "password123", "Fay", "Friend")
+	assert.NoError(t, err)
+	// Mentions and shares match emails whatever their case at registration
+	_, err = userService.CreateUser("Stranger@Example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	task, err := taskService.CreateTask(owner.ID, services.TaskInput{Title: "Plan trip"})
+	assert.NoError(t, err)
+	_, err = shareService.ShareTask(task.ID, owner.ID, "friend@example.com", models.SharePermissionRead)
+	assert.NoError(t, err)
+
+	_, err = commentService.CreateComment(ctx, task.ID, owner, "   ")
+	assert.ErrorIs(t, err, services.ErrCommentEmpty)
+
+	// Only users who can see the task are notified, and each of them once
+	comment, err := commentService.CreateComment(ctx, task.ID, owner,
+		"@Friend@example.com and @stranger@example.com, see @friend@example.com. Not me: @owner@example.com, not mail: bob@friend@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, models.StringList{"friend@example.com"}, comment.Mentions)
+	if assert.Len(t, mentionQueue.tasks, 1) {
+		assert.Equal(t, jobs.CommentMention.Type, mentionQueue.tasks[0].Type)
+		payload, err := jobs.CommentMention.Decode(mentionQueue.tasks[0])
+		assert.NoError(t, err)
+		assert.Equal(t, friend.ID, payload.UserID)
+
+		assert.NoError(t, mentionService.HandleMention(ctx, payload))
+		if assert.Len(t, sink.sent, 1) {
+			assert.Equal(t, "friend@example.com", sink.sent[0].Email)
+			assert.Equal(t, `Olive mentioned you on "Plan trip"`, sink.sent[0].Subject)
+			assert.Contains(t, sink.sent[0].Body, "@stranger@example.com")
+		}
+	}
+
+	// Editing notifies only users mentioned for the first time
+	_, err = commentService.UpdateComment(ctx, task.ID, comment.ID, friend, "Hijacked")
+	assert.ErrorIs(t, err, services.ErrCommentForbidden)
+	_, err = shareService.ShareTask(task.ID, owner.ID, "stranger@example.com", models.SharePermissionRead)
+	assert.NoError(t, err)
+	edited, err := commentService.UpdateComment(ctx, task.ID, comment.ID, owner, "@friend@example.com @stranger@example.com")
+	assert.NoError(t, err)
+	assert.NotNil(t, edited.EditedAt)
+	assert.Len(t, mentionQueue.tasks, 2)
+
+	// A friend reading the task can answer, and the owner moderates
+	reply, err := commentService.CreateComment(ctx, task.ID, friend, "Sounds good")
+	assert.NoError(t, err)
+	_, err = commentService.DeleteComment(task.ID, comment.ID, friend.ID)
+	assert.ErrorIs(t, err, services.ErrCommentForbidden)
+	_, err = commentService.DeleteComment(task.ID, reply.ID, owner.ID)
+	assert.NoError(t, err)
+
+	// Mentions of deleted comments are dropped
+	payload, err := jobs.CommentMention.Decode(mentionQueue.tasks[1])
+	assert.NoError(t, err)
+	_, err = commentService.DeleteComment(task.ID, comment.ID, owner.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, mentionService.HandleMention(ctx, payload))
+	assert.Len(t, sink.sent, 1)
+}
+
+func TestCommentPagination(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	taskService := services.NewTaskService(repos)
+	commentService := services.NewCommentService(repos, taskService)
+	userService := services.NewUserService(repos)
+	ctx := context.Background()
+
+	owner, err := userService.CreateUser("owner@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	stranger, err := userService.CreateUser("stranger@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	task, err := taskService.CreateTask(owner.ID, services.TaskInput{Title: "Busy task"})
+	assert.NoError(t, err)
+
+	for _, body := range []string{"one", "two", "three", "four", "five"} {
+		_, err := commentService.CreateComment(ctx, task.ID, owner, body)
+		assert.NoError(t, err)
+	}
+
+	_, err = commentService.GetComments(task.ID, stranger.ID, "", 2)
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+	_, err = commentService.GetComments(task.ID, owner.ID, "not a cursor", 2)
+	assert.ErrorIs(t, err, services.ErrInvalidCursor)
+
+	bodies := []string{}
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		page, err := commentService.GetComments(task.ID, owner.ID, cursor, 2)
+		if !assert.NoError(t, err) {
+			return
+		}
+		for _, comment := range page.Comments {
+			bodies = append(bodies, comment.Body)
+			assert.Equal(t, owner.Email, comment.User.Email)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"one", "two", "three", "four", "five"}, bodies)
+}
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/services/queue"
+	"github.com/jaimesHub/golang-todo-app/internal/services/worker"
+)
+
+// ErrInvalidPayload is returned when a job payload fails validation
+var ErrInvalidPayload = errors.New("invalid job payload")
+
+// Payload is the typed data of a job
+type Payload interface {
+	// Validate reports why the payload cannot be processed, if it cannot
+	Validate() error
+}
+
+// Enqueuer adds tasks to a queue; *queue.Queue implements it
+type Enqueuer interface {
+	EnqueueTask(ctx context.Context, queueName string, task *queue.Task) error
+}
+
+// Scheduler adds tasks to a queue's schedule; *queue.Queue implements it
+type Scheduler interface {
+	ScheduleTaskAt(ctx context.Context, queueName string, task *queue.Task, executeAt time.Time) error
+}
+
+// Job declares a job type together with its payload struct and the schema
+// version new jobs are created with
+type Job[P Payload] struct {
+	Type    string
+	Version int
+}
+
+// NewTask validates a payload and builds the queue task for it
+func (j Job[P]) NewTask(id string, payload P) (*queue.Task, error) {
+	if err := payload.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPayload, j.Type, err)
+	}
+
+	data, err := encode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	return &queue.Task{
+		ID:        id,
+		Type:      j.Type,
+		Data:      data,
+		CreatedAt: time.Now(),
+		Version:   j.Version,
+	}, nil
+}
+
+// Enqueue validates a payload and adds the job to the queue
+func (j Job[P]) Enqueue(ctx context.Context, q Enqueuer, queueName string, payload P) (string, error) {
+	task, err := j.NewTask("", payload)
+	if err != nil {
+		return "", err
+	}
+
+	if err := q.EnqueueTask(ctx, queueName, task); err != nil {
+		return "", err
+	}
+	return task.ID, nil
+}
+
+// Schedule validates a payload and schedules the job under id; scheduling
+// an existing ID replaces it
+func (j Job[P]) Schedule(ctx context.Context, q Scheduler, queueName, id string, payload P, executeAt time.Time) error {
+	task, err := j.NewTask(id, payload)
+	if err != nil {
+		return err
+	}
+
+	return q.ScheduleTaskAt(ctx, queueName, task, executeAt)
+}
+
+// Decode returns the payload of a queued task. Tasks created by a newer
+// schema version or with an invalid payload fail permanently.
+func (j Job[P]) Decode(task *queue.Task) (P, error) {
+	var payload P
+
+	if task.Version > j.Version {
+		return payload, worker.Permanent(fmt.Errorf("%s job %s has schema version %d, this worker supports up to %d", j.Type, task.ID, task.Version, j.Version))
+	}
+
+	raw, err := json.Marshal(task.Data)
+	if err != nil {
+		return payload, worker.Permanent(fmt.Errorf("%w: %s: %v", ErrInvalidPayload, j.Type, err))
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return payload, worker.Permanent(fmt.Errorf("%w: %s: %v", ErrInvalidPayload, j.Type, err))
+	}
+
+	if err := payload.Validate(); err != nil {
+		return payload, worker.Permanent(fmt.Errorf("%w: %s: %v", ErrInvalidPayload, j.Type, err))
+	}
+
+	return payload, nil
+}
+
+// encode converts a payload to the map stored in queue tasks
+func encode(payload interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+	return data, nil
+}
+
+// Registry maps job types to handlers of their typed payloads
+type Registry struct {
+	handlers map[string]worker.TaskHandler
+}
+
+// NewRegistry creates an empty job registry
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]worker.TaskHandler)}
+}
+
+// Handle registers the handler of a job type. The handler receives the
+// decoded, validated payload.
+func Handle[P Payload](r *Registry, job Job[P], handler func(ctx context.Context, payload P) error) {
+	r.handlers[job.Type] = func(ctx context.Context, task *queue.Task) error {
+		payload, err := job.Decode(task)
+		if err != nil {
+			return err
+		}
+		return handler(ctx, payload)
+	}
+}
+
+// Handler returns the handler of a job type, or nil if it has none
+func (r *Registry) Handler(taskType string) worker.TaskHandler {
+	return r.handlers[taskType]
+}
+
+// Types returns the registered job types in alphabetical order
+func (r *Registry) Types() []string {
+	types := make([]string, 0, len(r.handlers))
+	for taskType := range r.handlers {
+		types = append(types, taskType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Register adds the registered handlers to a worker
+func (r *Registry) Register(w *worker.Worker) {
+	for taskType, handler := range r.handlers {
+		w.RegisterHandler(taskType, handler)
+	}
+}
@@ -0,0 +1,84 @@
+package jobs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+	"github.com/jaimesHub/golang-todo-app/internal/services/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeQueue records enqueued tasks
+type fakeQueue struct {
+	tasks []*queue.Task
+}
+
+func (f *fakeQueue) EnqueueTask(ctx context.Context, queueName string, task *queue.Task) error {
+	f.tasks = append(f.tasks, task)
+	return nil
+}
+
+func TestEnqueueValidatesPayload(t *testing.T) {
+	q := &fakeQueue{}
+
+	_, err := jobs.EmailNotification.Enqueue(context.Background(), q, "tasks", jobs.EmailNotificationPayload{To: "not an address", Subject: "Hi"})
+	assert.ErrorIs(t, err, jobs.ErrInvalidPayload)
+	assert.Empty(t, q.tasks)
+
+	id, err := jobs.EmailNotification.Enqueue(context.Background(), q, "tasks", jobs.EmailNotificationPayload{To: "a@example.com", Subject: "Hi"})
+	assert.NoError(t, err)
+	assert.Len(t, q.tasks, 1)
+	assert.Equal(t, id, q.tasks[0].ID)
+	assert.Equal(t, "email_notification", q.tasks[0].Type)
+	assert.Equal(t, 1, q.tasks[0].Version)
+	assert.Equal(t, "a@example.com", q.tasks[0].Data["to"])
+}
+
+func TestDecode(t *testing.T) {
+	taskID := uuid.New()
+	task, err := jobs.TaskReminder.NewTask("reminder:1", jobs.TaskReminderPayload{TaskID: taskID})
+	assert.NoError(t, err)
+
+	payload, err := jobs.TaskReminder.Decode(task)
+	assert.NoError(t, err)
+	assert.Equal(t, taskID, payload.TaskID)
+
+	// Untyped tasks from before versioning still decode
+	legacy := &queue.Task{Type: "task_reminder", Data: map[string]interface{}{"task_id": taskID.String()}}
+	payload, err = jobs.TaskReminder.Decode(legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, taskID, payload.TaskID)
+
+	_, err = jobs.TaskReminder.Decode(&queue.Task{Type: "task_reminder", Data: map[string]interface{}{"task_id": "nope"}})
+	assert.ErrorIs(t, err, jobs.ErrInvalidPayload)
+	_, err = jobs.TaskReminder.Decode(&queue.Task{Type: "task_reminder", Data: map[string]interface{}{}})
+	assert.ErrorIs(t, err, jobs.ErrInvalidPayload)
+
+	newer := *task
+	newer.Version = jobs.TaskReminder.Version + 1
+	_, err = jobs.TaskReminder.Decode(&newer)
+	assert.Error(t, err)
+}
+
+func TestRegistry(t *testing.T) {
+	registry := jobs.NewRegistry()
+
+	var received jobs.EmailNotificationPayload
+	jobs.Handle(registry, jobs.EmailNotification, func(ctx context.Context, payload jobs.EmailNotificationPayload) error {
+		received = payload
+		return nil
+	})
+	jobs.Handle(registry, jobs.TaskReminder, func(ctx context.Context, payload jobs.TaskReminderPayload) error {
+		return nil
+	})
+
+	assert.Equal(t, []string{"email_notification", "task_reminder"}, registry.Types())
+
+	task, err := jobs.EmailNotification.NewTask("", jobs.EmailNotificationPayload{To: "a@example.com", Subject: "Hi", Body: "Hello"})
+	assert.NoError(t, err)
+	assert.NoError(t, registry.Handler("email_notification")(context.Background(), task))
+	assert.Equal(t, "Hello", received.Body)
+	assert.Nil(t, registry.Handler("unknown"))
+}
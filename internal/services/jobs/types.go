@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"errors"
+	"net/mail"
+
+	"github.com/google/uuid"
+)
+
+// TaskReminder reminds a user that a task is due soon
+var TaskReminder = Job[TaskReminderPayload]{Type: "task_reminder", Version: 1}
+
+// TaskReminderPayload is the payload of task_reminder jobs
+type TaskReminderPayload struct {
+	TaskID uuid.UUID `json:"task_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// Validate checks that the reminder names a task
+func (p TaskReminderPayload) Validate() error {
+	if p.TaskID == uuid.Nil {
+		return errors.New("task_id is required")
+	}
+	return nil
+}
+
+// EmailNotification sends an email to a user
+var EmailNotification = Job[EmailNotificationPayload]{Type: "email_notification", Version: 1}
+
+// EmailNotificationPayload is the payload of email_notification jobs
+type EmailNotificationPayload struct {
+	UserID  uuid.UUID `json:"user_id,omitempty"`
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+}
+
+// Validate checks that the email has a valid recipient and a subject
+func (p EmailNotificationPayload) Validate() error {
+	if _, err := mail.ParseAddress(p.To); err != nil {
+		return errors.New("to must be a valid email address")
+	}
+	if p.Subject == "" {
+		return errors.New("subject is required")
+	}
+	return nil
+}
+
+// AccountPurge removes the data of a deleted account once its grace period ends
+var AccountPurge = Job[AccountPurgePayload]{Type: "account_purge", Version: 1}
+
+// AccountPurgePayload is the payload of account_purge jobs
+type AccountPurgePayload struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// Validate checks that the purge names a user
+func (p AccountPurgePayload) Validate() error {
+	if p.UserID == uuid.Nil {
+		return errors.New("user_id is required")
+	}
+	return nil
+}
+
+// CommentMention notifies a user that a comment mentions them
+var CommentMention = Job[CommentMentionPayload]{Type: "comment_mention", Version: 1}
+
+// CommentMentionPayload is the payload of comment_mention jobs
+type CommentMentionPayload struct {
+	CommentID uuid.UUID `json:"comment_id"`
+	UserID    uuid.UUID `json:"user_id"`
+}
+
+// Validate checks that the mention names a comment and a user
+func (p CommentMentionPayload) Validate() error {
+	if p.CommentID == uuid.Nil {
+		return errors.New("comment_id is required")
+	}
+	if p.UserID == uuid.Nil {
+		return errors.New("user_id is required")
+	}
+	return nil
+}
+
+// AttachmentCleanup removes the stored files of deleted tasks
+var AttachmentCleanup = Job[AttachmentCleanupPayload]{Type: "attachment_cleanup", Version: 1}
+
+// AttachmentCleanupPayload is the payload of attachment_cleanup jobs
+type AttachmentCleanupPayload struct {
+	// TaskID limits the cleanup to one deleted task; without it the
+	// attachments of every deleted task are removed
+	TaskID uuid.UUID `json:"task_id,omitempty"`
+}
+
+// Validate accepts every cleanup; an empty payload sweeps all deleted tasks
+func (p AttachmentCleanupPayload) Validate() error {
+	return nil
+}
@@ -0,0 +1,142 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/logger"
+)
+
+// ErrInvalidHeader is returned for messages whose headers contain line breaks
+var ErrInvalidHeader = errors.New("invalid email header")
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// New creates the mailer selected by the configuration
+func New(cfg config.MailerConfig, log *logger.Logger) (Mailer, error) {
+	switch cfg.Backend {
+	case "", "log":
+		return NewLogMailer(log), nil
+	case "file":
+		return NewFileMailer(cfg.Dir, cfg.From)
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer backend: %s", cfg.Backend)
+	}
+}
+
+// LogMailer writes emails to the application log
+type LogMailer struct {
+	log *logger.Logger
+}
+
+// NewLogMailer creates a mailer that writes to the application log
+func NewLogMailer(log *logger.Logger) *LogMailer {
+	return &LogMailer{log: log}
+}
+
+// Send logs an email
+func (m *LogMailer) Send(ctx context.Context, message Message) error {
+	m.log.Info("Email", map[string]interface{}{
+		"to":      message.To,
+		"subject": message.Subject,
+		"body":    message.Body,
+	})
+	return nil
+}
+
+// FileMailer writes every email to its own .eml file, for local development
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer creates a mailer that writes to dir, creating it if needed
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send writes an email to a new file named after the time it was sent
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	now := time.Now()
+	data, err := format(m.from, message, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), uuid.New().String()[:8])
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
+
+// SMTPMailer delivers emails to an SMTP server without authentication or
+// TLS, such as a local capture server like MailHog
+type SMTPMailer struct {
+	addr string
+	from string
+}
+
+// NewSMTPMailer creates a mailer that delivers to host:port
+func NewSMTPMailer(host string, port int, from string) *SMTPMailer {
+	return &SMTPMailer{addr: fmt.Sprintf("%s:%d", host, port), from: from}
+}
+
+// Send delivers an email
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	data, err := format(m.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(m.addr, nil, m.from, []string{message.To}, data); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// format renders an email in RFC 5322 form
+func format(from string, message Message, date time.Time) ([]byte, error) {
+	for _, header := range []string{from, message.To, message.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, ErrInvalidHeader
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,39 @@
+package mailer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/services/mailer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileMailerWritesOneFilePerEmail(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	m, err := mailer.New(config.MailerConfig{Backend: "file", Dir: dir, From: "no-reply@example.com"}, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.Send(context.Background(), mailer.Message{To: "a@example.com", Subject: "First", Body: "Hello\nthere"}))
+	assert.NoError(t, m.Send(context.Background(), mailer.Message{To: "b@example.com", Subject: "Second"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	content, err := os.ReadFile(files[0])
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "From: no-reply@example.com\r\nTo: a@example.com\r\nSubject: First\r\n"))
+	assert.Contains(t, string(content), "\r\n\r\nHello\r\nthere\r\n")
+
+	// Line breaks in headers are rejected
+	err = m.Send(context.Background(), mailer.Message{To: "a@example.com", Subject: "Hi\r\nBcc: x@example.com"})
+	assert.ErrorIs(t, err, mailer.ErrInvalidHeader)
+
+	_, err = mailer.New(config.MailerConfig{Backend: "fax"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+	"github.com/jaimesHub/golang-todo-app/internal/services/notifier"
+)
+
+// MentionService delivers the comment mentions enqueued by CommentService
+type MentionService struct {
+	repos       *repository.Repositories
+	taskService *TaskService
+	notifier    notifier.Notifier
+}
+
+// NewMentionService creates a new mention service
+func NewMentionService(repos *repository.Repositories, notifier notifier.Notifier) *MentionService {
+	return &MentionService{repos: repos, taskService: NewTaskService(repos), notifier: notifier}
+}
+
+// HandleMention processes a comment_mention job. Mentions are dropped when
+// the comment was deleted or edited to no longer mention the user, or when
+// the user lost access to the task in the meantime.
+func (s *MentionService) HandleMention(ctx context.Context, payload jobs.CommentMentionPayload) error {
+	comment, err := s.repos.Comments.FindByID(payload.CommentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	user, err := s.repos.Users.FindByID(payload.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	mentioned := false
+	for _, email := range comment.Mentions {
+		if email == strings.ToLower(user.Email) {
+			mentioned = true
+			break
+		}
+	}
+	if !mentioned {
+		return nil
+	}
+
+	task, err := s.taskService.GetTaskByID(comment.TaskID, user.ID)
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	authorName := "Someone"
+	if author, err := s.repos.Users.FindByID(comment.UserID); err == nil {
+		authorName = greetingName(author)
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	return s.notifier.Notify(ctx, notifier.Notification{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Subject: fmt.Sprintf("%s mentioned you on %q", authorName, task.Title),
+		Body:    fmt.Sprintf("%s commented on %q:\n\n%s", authorName, task.Title, comment.Body),
+		SentAt:  time.Now(),
+	})
+}
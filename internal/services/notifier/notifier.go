@@ -0,0 +1,105 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/logger"
+)
+
+// Notification is a message delivered to a user
+type Notification struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Email   string    `json:"email"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Notifier delivers notifications to users
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// New creates the notifier selected by the configuration
+func New(cfg config.NotifierConfig, log *logger.Logger) (Notifier, error) {
+	switch cfg.Sink {
+	case "", "log":
+		return NewLogNotifier(log), nil
+	case "file":
+		return NewFileNotifier(cfg.File)
+	default:
+		return nil, fmt.Errorf("unknown notifier sink: %s", cfg.Sink)
+	}
+}
+
+// LogNotifier writes notifications to the application log
+type LogNotifier struct {
+	log *logger.Logger
+}
+
+// NewLogNotifier creates a notifier that writes to the application log
+func NewLogNotifier(log *logger.Logger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+// Notify logs a notification
+func (n *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.log.Info("Notification", map[string]interface{}{
+		"user_id": notification.UserID,
+		"email":   notification.Email,
+		"subject": notification.Subject,
+		"body":    notification.Body,
+	})
+	return nil
+}
+
+// FileNotifier appends notifications to a file as JSON lines
+type FileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileNotifier creates a notifier that appends to the file at path,
+// creating its directory if needed
+func NewFileNotifier(path string) (*FileNotifier, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create notification directory: %w", err)
+	}
+
+	return &FileNotifier{path: path}, nil
+}
+
+// Notify appends a notification to the file
+func (n *FileNotifier) Notify(ctx context.Context, notification Notification) error {
+	if notification.SentAt.IsZero() {
+		notification.SentAt = time.Now()
+	}
+
+	line, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open notification file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package notifier_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/services/notifier"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileNotifierAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "notifications.log")
+
+	n, err := notifier.New(config.NotifierConfig{Sink: "file", File: path}, nil)
+	assert.NoError(t, err)
+
+	userID := uuid.New()
+	assert.NoError(t, n.Notify(context.Background(), notifier.Notification{UserID: userID, Subject: "First"}))
+	assert.NoError(t, n.Notify(context.Background(), notifier.Notification{UserID: userID, Subject: "Second"}))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 2)
+
+	var notification notifier.Notification
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &notification))
+	assert.Equal(t, "Second", notification.Subject)
+	assert.Equal(t, userID, notification.UserID)
+	assert.False(t, notification.SentAt.IsZero())
+
+	_, err = notifier.New(config.NotifierConfig{Sink: "carrier-pigeon"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+)
+
+// ProjectService handles project-related business logic
+type ProjectService struct {
+	repos *repository.Repositories
+}
+
+// NewProjectService creates a new project service
+func NewProjectService(repos *repository.Repositories) *ProjectService {
+	return &ProjectService{repos: repos}
+}
+
+// ProjectInput holds the user-editable fields of a project. When updating,
+// nil values leave the current value unchanged.
+type ProjectInput struct {
+	Name     *string
+	Color    *string
+	Archived *bool
+	Position *int
+}
+
+// CreateProject creates a new project, placed after the user's existing projects
+// unless a position is given
+func (s *ProjectService) CreateProject(userID uuid.UUID, input ProjectInput) (*models.Project, error) {
+	project := &models.Project{
+		UserID:    userID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if input.Position == nil {
+		existing, err := s.repos.Projects.ListByUser(userID, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range existing {
+			if p.Position >= project.Position {
+				project.Position = p.Position + 1
+			}
+		}
+	}
+
+	if err := applyProjectInput(project, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Projects.Create(project); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// GetProjectByID retrieves a user's project by ID
+func (s *ProjectService) GetProjectByID(id uuid.UUID, userID uuid.UUID) (*models.Project, error) {
+	project, err := s.repos.Projects.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+
+	if project.UserID != userID {
+		return nil, ErrProjectNotFound
+	}
+
+	return project, nil
+}
+
+// GetProjects retrieves a user's projects in display order
+func (s *ProjectService) GetProjects(userID uuid.UUID, includeArchived bool) ([]models.Project, error) {
+	return s.repos.Projects.ListByUser(userID, includeArchived)
+}
+
+// UpdateProject updates a project; archiving hides its tasks from the default task list
+func (s *ProjectService) UpdateProject(id uuid.UUID, userID uuid.UUID, input ProjectInput) (*models.Project, error) {
+	project, err := s.GetProjectByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyProjectInput(project, input); err != nil {
+		return nil, err
+	}
+
+	project.UpdatedAt = time.Now()
+
+	if err := s.repos.Projects.Update(project); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// DeleteProject deletes a project; its tasks are kept but no longer belong to a project
+func (s *ProjectService) DeleteProject(id uuid.UUID, userID uuid.UUID) error {
+	project, err := s.GetProjectByID(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.repos.Projects.Delete(project)
+}
+
+// applyProjectInput copies the provided fields onto a project and validates it
+func applyProjectInput(project *models.Project, input ProjectInput) error {
+	if input.Name != nil {
+		project.Name = strings.TrimSpace(*input.Name)
+	}
+
+	if input.Color != nil {
+		project.Color = *input.Color
+	}
+
+	if input.Archived != nil {
+		project.Archived = *input.Archived
+	}
+
+	if input.Position != nil {
+		project.Position = *input.Position
+	}
+
+	if project.Name == "" {
+		return errors.New("project name is required")
+	}
+
+	if len(project.Name) > 100 {
+		return errors.New("project name must be at most 100 characters")
+	}
+
+	if project.Color != "" && !colorPattern.MatchString(project.Color) {
+		return errors.New("project color must be a hex color like #ff0000")
+	}
+
+	if project.Position < 0 {
+		return errors.New("project position must not be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func stringPtr(s string) *string { return &s }
+
+func boolPtr(b bool) *bool { return &b }
+
+func intPtr(i int) *int { return &i }
+
+func TestProjectOrderingAndValidation(t *testing.T) {
+	projectService := services.NewProjectService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	_, err := projectService.CreateProject(userID, services.ProjectInput{Name: stringPtr("  ")})
+	assert.EqualError(t, err, "project name is required")
+
+	first, err := projectService.CreateProject(userID, services.ProjectInput{Name: stringPtr("Home")})
+	assert.NoError(t, err)
+	second, err := projectService.CreateProject(userID, services.ProjectInput{Name: stringPtr("Work"), Color: stringPtr("#00ff00")})
+	assert.NoError(t, err)
+	assert.Equal(t, first.Position+1, second.Position)
+
+	// Move "Work" before "Home"
+	_, err = projectService.UpdateProject(second.ID, userID, services.ProjectInput{Position: intPtr(0)})
+	assert.NoError(t, err)
+	_, err = projectService.UpdateProject(first.ID, userID, services.ProjectInput{Position: intPtr(1)})
+	assert.NoError(t, err)
+
+	projects, err := projectService.GetProjects(userID, false)
+	assert.NoError(t, err)
+	assert.Len(t, projects, 2)
+	assert.Equal(t, "Work", projects[0].Name)
+
+	// Projects are private to their owner
+	_, err = projectService.GetProjectByID(first.ID, uuid.New())
+	assert.ErrorIs(t, err, services.ErrProjectNotFound)
+}
+
+func TestArchivedProjectTasksAreHidden(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	projectService := services.NewProjectService(repos)
+	taskService := services.NewTaskService(repos)
+	userID := uuid.New()
+
+	project, err := projectService.CreateProject(userID, services.ProjectInput{Name: stringPtr("Side project")})
+	assert.NoError(t, err)
+
+	inProject, err := taskService.CreateTask(userID, services.TaskInput{Title: "In project", ProjectID: &project.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, project.ID, *inProject.ProjectID)
+	_, err = taskService.CreateTask(userID, services.TaskInput{Title: "Loose"})
+	assert.NoError(t, err)
+
+	// Another user's project cannot be used
+	otherProject, _ := projectService.CreateProject(uuid.New(), services.ProjectInput{Name: stringPtr("Not mine")})
+	_, err = taskService.CreateTask(userID, services.TaskInput{Title: "Sneaky", ProjectID: &otherProject.ID})
+	assert.ErrorIs(t, err, services.ErrProjectNotFound)
+
+	_, err = projectService.UpdateProject(project.ID, userID, services.ProjectInput{Archived: boolPtr(true)})
+	assert.NoError(t, err)
+
+	// Hidden from the default list, visible when asked for
+	tasks, err := taskService.GetTasks(repository.TaskFilter{UserID: userID, Priority: -1})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	tasks, err = taskService.GetTasks(repository.TaskFilter{UserID: userID, Priority: -1, IncludeArchived: true})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+
+	tasks, err = taskService.GetTasks(repository.TaskFilter{UserID: userID, Priority: -1, ProjectID: &project.ID})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	// No new tasks in archived projects
+	_, err = taskService.CreateTask(userID, services.TaskInput{Title: "Late", ProjectID: &project.ID})
+	assert.ErrorIs(t, err, services.ErrProjectArchived)
+
+	// Removing the task from the project makes it visible again
+	_, err = taskService.UpdateTask(inProject.ID, userID, services.TaskInput{Priority: -1, ProjectID: &uuid.Nil})
+	assert.NoError(t, err)
+	count, err := taskService.CountTasks(repository.TaskFilter{UserID: userID, Priority: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// Deleting a project keeps its tasks
+	_, _ = projectService.UpdateProject(project.ID, userID, services.ProjectInput{Archived: boolPtr(false)})
+	_, err = taskService.UpdateTask(inProject.ID, userID, services.TaskInput{Priority: -1, ProjectID: &project.ID})
+	assert.NoError(t, err)
+	assert.NoError(t, projectService.DeleteProject(project.ID, userID))
+	task, err := taskService.GetTaskByID(inProject.ID, userID)
+	assert.NoError(t, err)
+	assert.Nil(t, task.ProjectID)
+}
@@ -3,7 +3,9 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -11,18 +13,137 @@ import (
 	"github.com/jaimesHub/golang-todo-app/internal/config"
 )
 
+// ErrNotScheduled is returned when looking up a scheduled task that does not exist
+var ErrNotScheduled = errors.New("task is not scheduled")
+
+// ErrNotProcessing is returned when acknowledging, retrying or dead-lettering
+// a task that is no longer in the processing list, for example because its
+// visibility timeout expired and it was reclaimed
+var ErrNotProcessing = errors.New("task is no longer being processed")
+
 // Task represents a task in the queue
 type Task struct {
 	ID        string                 `json:"id"`
 	Type      string                 `json:"type"`
 	Data      map[string]interface{} `json:"data"`
 	CreatedAt time.Time              `json:"created_at"`
+
+	// Version is the schema version of Data; zero for untyped tasks
+	Version int `json:"version,omitempty"`
+
+	// Attempts counts the failed runs of the task so far
+	Attempts  int        `json:"attempts,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	FailedAt  *time.Time `json:"failed_at,omitempty"`
+
+	// raw is the payload as stored in the processing list, used to remove it
+	raw string
 }
 
+// ScheduledTask is a task waiting in a queue's schedule
+type ScheduledTask struct {
+	Task
+	ExecuteAt time.Time `json:"execute_at"`
+
+	// ScheduleID is what the task is scheduled under: its ID, or a retry ID
+	// for retries
+	ScheduleID string `json:"schedule_id"`
+}
+
+// scheduledKey is the sorted set of a queue's scheduled task IDs, scored by execution time
+func scheduledKey(queueName string) string {
+	return queueName + ":scheduled"
+}
+
+// scheduledDataKey is the hash holding a queue's scheduled task payloads by ID
+func scheduledDataKey(queueName string) string {
+	return queueName + ":scheduled:data"
+}
+
+// retryID is the schedule ID of a task's retry. Retries are kept apart from
+// the task's own ID, which callers may use to schedule the next run of a
+// job, so neither replaces the other.
+func retryID(id string, attempt int) string {
+	return "retry:" + id + ":" + strconv.Itoa(attempt)
+}
+
+// processingKey is the list holding the tasks taken from a queue but not acknowledged yet
+func processingKey(queueName string) string {
+	return queueName + ":processing"
+}
+
+// inflightKey is the sorted set of processing payloads scored by visibility deadline
+func inflightKey(queueName string) string {
+	return queueName + ":inflight"
+}
+
+// deadLetterKey is the list of tasks that exhausted their retries, newest first
+func deadLetterKey(queueName string) string {
+	return queueName + ":dead"
+}
+
+// moveScript moves a payload from one list to another (LPUSH or RPUSH),
+// replacing it with a new payload, and forgets its visibility deadline. It
+// does nothing if the payload is no longer in the source list.
+var moveScript = redis.NewScript(`
+local removed = redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('ZREM', KEYS[3], ARGV[1])
+if removed == 0 then
+	return 0
+end
+redis.call(ARGV[3], KEYS[2], ARGV[2])
+return 1
+`)
+
+// retryScript moves a payload from the processing list to the schedule
+var retryScript = redis.NewScript(`
+local removed = redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+if removed == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[3], ARGV[2], ARGV[3])
+redis.call('ZADD', KEYS[4], ARGV[4], ARGV[2])
+return 1
+`)
+
+// promoteScript moves up to ARGV[2] scheduled tasks due at ARGV[1] from the
+// schedule to the end of the queue. Running it as one script means a due task
+// is promoted exactly once, however many workers call it.
+var promoteScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local promoted = 0
+for _, id in ipairs(ids) do
+	local payload = redis.call('HGET', KEYS[2], id)
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('HDEL', KEYS[2], id)
+	if payload then
+		redis.call('RPUSH', KEYS[3], payload)
+		promoted = promoted + 1
+	end
+end
+return promoted
+`)
+
+// expiredScript returns the processing payloads whose visibility deadline
+// has passed. Payloads without a deadline (the process died right after
+// taking them) get one now.
+var expiredScript = redis.NewScript(`
+local expired = {}
+for _, item in ipairs(redis.call('LRANGE', KEYS[1], 0, -1)) do
+	local deadline = redis.call('ZSCORE', KEYS[2], item)
+	if not deadline then
+		redis.call('ZADD', KEYS[2], ARGV[2], item)
+	elseif tonumber(deadline) <= tonumber(ARGV[1]) then
+		table.insert(expired, item)
+	end
+end
+return expired
+`)
+
 // Queue handles task queue operations
 type Queue struct {
 	client *redis.Client
-	ctx    context.Context
 }
 
 // NewQueue creates a new queue
@@ -42,7 +163,6 @@ func NewQueue(cfg config.RedisConfig) (*Queue, error) {
 
 	return &Queue{
 		client: client,
-		ctx:    ctx,
 	}, nil
 }
 
@@ -52,32 +172,43 @@ func (q *Queue) Close() error {
 }
 
 // Enqueue adds a task to the queue
-func (q *Queue) Enqueue(queueName, taskType string, data map[string]interface{}) (string, error) {
-	task := Task{
+func (q *Queue) Enqueue(ctx context.Context, queueName, taskType string, data map[string]interface{}) (string, error) {
+	task := &Task{
 		ID:        uuid.New().String(),
 		Type:      taskType,
 		Data:      data,
 		CreatedAt: time.Now(),
 	}
 
+	if err := q.EnqueueTask(ctx, queueName, task); err != nil {
+		return "", err
+	}
+
+	return task.ID, nil
+}
+
+// EnqueueTask adds an existing task to the queue, keeping its ID and attempts
+func (q *Queue) EnqueueTask(ctx context.Context, queueName string, task *Task) error {
 	// Serialize task to JSON
 	taskJSON, err := json.Marshal(task)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal task: %w", err)
+		return fmt.Errorf("failed to marshal task: %w", err)
 	}
 
 	// Add task to queue
-	if err := q.client.RPush(q.ctx, queueName, taskJSON).Err(); err != nil {
-		return "", fmt.Errorf("failed to enqueue task: %w", err)
+	if err := q.client.RPush(ctx, queueName, taskJSON).Err(); err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
 	}
 
-	return task.ID, nil
+	return nil
 }
 
-// Dequeue removes and returns a task from the queue
-func (q *Queue) Dequeue(queueName string, timeout time.Duration) (*Task, error) {
-	// Use BLPOP to wait for a task
-	result, err := q.client.BLPop(q.ctx, timeout, queueName).Result()
+// Dequeue takes the next task from the queue and moves it to the processing
+// list, waiting up to timeout for one to arrive. The task must be passed to
+// Ack, Retry or DeadLetter before the visibility timeout expires, otherwise
+// ReclaimExpired puts it back in the queue.
+func (q *Queue) Dequeue(ctx context.Context, queueName string, timeout, visibility time.Duration) (*Task, error) {
+	raw, err := q.client.BLMove(ctx, queueName, processingKey(queueName), "LEFT", "RIGHT", timeout).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, nil // No tasks available
@@ -85,74 +216,404 @@ func (q *Queue) Dequeue(queueName string, timeout time.Duration) (*Task, error)
 		return nil, fmt.Errorf("failed to dequeue task: %w", err)
 	}
 
+	if err := q.client.ZAdd(ctx, inflightKey(queueName), &redis.Z{
+		Score:  float64(time.Now().Add(visibility).Unix()),
+		Member: raw,
+	}).Err(); err != nil {
+		return nil, fmt.Errorf("failed to track dequeued task: %w", err)
+	}
+
 	// Parse task from JSON
+	task, err := decodeTask(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// Ack removes a processed task from the processing list
+func (q *Queue) Ack(ctx context.Context, queueName string, task *Task) error {
+	pipe := q.client.TxPipeline()
+	removed := pipe.LRem(ctx, processingKey(queueName), 1, task.raw)
+	pipe.ZRem(ctx, inflightKey(queueName), task.raw)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to acknowledge task: %w", err)
+	}
+
+	if removed.Val() == 0 {
+		return ErrNotProcessing
+	}
+	return nil
+}
+
+// Release puts a task being processed back at the end of the queue without
+// counting an attempt, for example when the worker shuts down before it ran
+func (q *Queue) Release(ctx context.Context, queueName string, task *Task) error {
+	moved, err := moveScript.Run(ctx, q.client,
+		[]string{processingKey(queueName), queueName, inflightKey(queueName)},
+		task.raw, task.raw, "RPUSH",
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to release task: %w", err)
+	}
+
+	if moved == 0 {
+		return ErrNotProcessing
+	}
+	return nil
+}
+
+// Retry records a failed attempt of a task being processed and schedules it
+// to run again after delay, under a retry ID rather than the task's ID
+func (q *Queue) Retry(ctx context.Context, queueName string, task *Task, delay time.Duration, cause error) error {
+	task.Attempts++
+	task.LastError = cause.Error()
+
+	taskJSON, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	moved, err := retryScript.Run(ctx, q.client,
+		[]string{processingKey(queueName), inflightKey(queueName), scheduledDataKey(queueName), scheduledKey(queueName)},
+		task.raw, retryID(task.ID, task.Attempts), taskJSON, time.Now().Add(delay).Unix(),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to retry task: %w", err)
+	}
+
+	if moved == 0 {
+		return ErrNotProcessing
+	}
+	return nil
+}
+
+// DeadLetter records a final failed attempt of a task being processed and
+// moves it to the dead-letter list
+func (q *Queue) DeadLetter(ctx context.Context, queueName string, task *Task, cause error) error {
+	now := time.Now()
+	task.Attempts++
+	task.LastError = cause.Error()
+	task.FailedAt = &now
+
+	taskJSON, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	moved, err := moveScript.Run(ctx, q.client,
+		[]string{processingKey(queueName), deadLetterKey(queueName), inflightKey(queueName)},
+		task.raw, taskJSON, "LPUSH",
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter task: %w", err)
+	}
+
+	if moved == 0 {
+		return ErrNotProcessing
+	}
+	return nil
+}
+
+// ErrVisibilityTimeout is recorded as the last error of tasks reclaimed
+// after their visibility timeout expired
+var ErrVisibilityTimeout = errors.New("visibility timeout expired")
+
+// ReclaimExpired puts tasks whose visibility timeout expired back at the
+// front of the queue. Each expiry counts as a failed attempt, so a task that
+// keeps crashing or hanging workers is moved to the dead-letter list once it
+// has had more than maxRetries attempts. It returns how many tasks were
+// requeued and how many were dead-lettered.
+func (q *Queue) ReclaimExpired(ctx context.Context, queueName string, visibility time.Duration, maxRetries int) (int, int, error) {
+	now := time.Now()
+
+	expired, err := expiredScript.Run(ctx, q.client,
+		[]string{processingKey(queueName), inflightKey(queueName)},
+		now.Unix(), now.Add(visibility).Unix(),
+	).StringSlice()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to reclaim expired tasks: %w", err)
+	}
+
+	reclaimed, deadLettered := 0, 0
+	for _, raw := range expired {
+		// Payloads that cannot be decoded cannot run either; they are left
+		// in the processing list for inspection
+		task, err := decodeTask(raw)
+		if err != nil {
+			continue
+		}
+
+		task.Attempts++
+		task.LastError = ErrVisibilityTimeout.Error()
+		destination := queueName
+		if task.Attempts > maxRetries {
+			task.FailedAt = &now
+			destination = deadLetterKey(queueName)
+		}
+
+		taskJSON, err := json.Marshal(task)
+		if err != nil {
+			return reclaimed, deadLettered, fmt.Errorf("failed to marshal task: %w", err)
+		}
+
+		// Tasks acknowledged in the meantime are not moved
+		moved, err := moveScript.Run(ctx, q.client,
+			[]string{processingKey(queueName), destination, inflightKey(queueName)},
+			raw, taskJSON, "LPUSH",
+		).Int()
+		if err != nil {
+			return reclaimed, deadLettered, fmt.Errorf("failed to reclaim expired task: %w", err)
+		}
+
+		if moved == 0 {
+			continue
+		}
+		if task.FailedAt != nil {
+			deadLettered++
+		} else {
+			reclaimed++
+		}
+	}
+
+	return reclaimed, deadLettered, nil
+}
+
+// ListDeadLetters returns dead-lettered tasks, newest first
+func (q *Queue) ListDeadLetters(ctx context.Context, queueName string, offset, limit int64) ([]*Task, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = offset + limit - 1
+	}
+
+	results, err := q.client.LRange(ctx, deadLetterKey(queueName), offset, stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	tasks := make([]*Task, 0, len(results))
+	for _, raw := range results {
+		task, err := decodeTask(raw)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// CountDeadLetters returns the number of dead-lettered tasks
+func (q *Queue) CountDeadLetters(ctx context.Context, queueName string) (int64, error) {
+	return q.client.LLen(ctx, deadLetterKey(queueName)).Result()
+}
+
+// RequeueDeadLetters moves dead-lettered tasks back to the queue with their
+// attempts reset. An empty id requeues all of them. It returns how many
+// tasks were requeued.
+func (q *Queue) RequeueDeadLetters(ctx context.Context, queueName, id string) (int, error) {
+	tasks, err := q.ListDeadLetters(ctx, queueName, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for _, task := range tasks {
+		if id != "" && task.ID != id {
+			continue
+		}
+
+		task.Attempts = 0
+		task.LastError = ""
+		task.FailedAt = nil
+
+		taskJSON, err := json.Marshal(task)
+		if err != nil {
+			return requeued, fmt.Errorf("failed to marshal task: %w", err)
+		}
+
+		moved, err := moveScript.Run(ctx, q.client,
+			[]string{deadLetterKey(queueName), queueName, inflightKey(queueName)},
+			task.raw, taskJSON, "RPUSH",
+		).Int()
+		if err != nil {
+			return requeued, fmt.Errorf("failed to requeue dead letter: %w", err)
+		}
+		requeued += moved
+	}
+
+	return requeued, nil
+}
+
+// PurgeDeadLetters deletes all dead-lettered tasks and returns how many there were
+func (q *Queue) PurgeDeadLetters(ctx context.Context, queueName string) (int64, error) {
+	pipe := q.client.TxPipeline()
+	count := pipe.LLen(ctx, deadLetterKey(queueName))
+	pipe.Del(ctx, deadLetterKey(queueName))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to purge dead letters: %w", err)
+	}
+
+	return count.Val(), nil
+}
+
+// GetQueueLength returns the number of tasks in the queue
+func (q *Queue) GetQueueLength(ctx context.Context, queueName string) (int64, error) {
+	return q.client.LLen(ctx, queueName).Result()
+}
+
+// decodeTask parses a stored task payload
+func decodeTask(raw string) (*Task, error) {
 	var task Task
-	if err := json.Unmarshal([]byte(result[1]), &task); err != nil {
+	if err := json.Unmarshal([]byte(raw), &task); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
 	}
 
+	task.raw = raw
 	return &task, nil
 }
 
-// GetQueueLength returns the number of tasks in the queue
-func (q *Queue) GetQueueLength(queueName string) (int64, error) {
-	return q.client.LLen(q.ctx, queueName).Result()
+// ScheduleTask schedules a task to be added to a queue at a specific time
+func (q *Queue) ScheduleTask(ctx context.Context, queueName, taskType string, data map[string]interface{}, executeAt time.Time) (string, error) {
+	id := uuid.New().String()
+	if err := q.ScheduleTaskWithID(ctx, queueName, id, taskType, data, executeAt); err != nil {
+		return "", err
+	}
+	return id, nil
 }
 
-// ScheduleTask schedules a task to be executed at a specific time
-func (q *Queue) ScheduleTask(taskType string, data map[string]interface{}, executeAt time.Time) (string, error) {
-	task := Task{
-		ID:        uuid.New().String(),
+// ScheduleTaskWithID schedules a task under a caller-chosen ID, so it can be
+// rescheduled or cancelled later. Scheduling an existing ID replaces it.
+func (q *Queue) ScheduleTaskWithID(ctx context.Context, queueName, id, taskType string, data map[string]interface{}, executeAt time.Time) error {
+	return q.ScheduleTaskAt(ctx, queueName, &Task{
+		ID:        id,
 		Type:      taskType,
 		Data:      data,
 		CreatedAt: time.Now(),
-	}
+	}, executeAt)
+}
 
+// ScheduleTaskAt schedules an existing task under its ID. Scheduling an
+// existing ID replaces it.
+func (q *Queue) ScheduleTaskAt(ctx context.Context, queueName string, task *Task, executeAt time.Time) error {
 	// Serialize task to JSON
 	taskJSON, err := json.Marshal(task)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal task: %w", err)
+		return fmt.Errorf("failed to marshal task: %w", err)
 	}
 
-	// Add task to sorted set with score as Unix timestamp
-	if err := q.client.ZAdd(q.ctx, "scheduled_tasks", &redis.Z{
+	// The sorted set orders task IDs by Unix timestamp; the payloads live in a hash
+	pipe := q.client.TxPipeline()
+	pipe.HSet(ctx, scheduledDataKey(queueName), task.ID, taskJSON)
+	pipe.ZAdd(ctx, scheduledKey(queueName), &redis.Z{
 		Score:  float64(executeAt.Unix()),
-		Member: taskJSON,
-	}).Err(); err != nil {
-		return "", fmt.Errorf("failed to schedule task: %w", err)
+		Member: task.ID,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to schedule task: %w", err)
 	}
 
-	return task.ID, nil
+	return nil
+}
+
+// CancelScheduledTask removes a scheduled task by schedule ID and reports
+// whether it was still pending. Pending retries of the task are not affected.
+func (q *Queue) CancelScheduledTask(ctx context.Context, queueName, id string) (bool, error) {
+	pipe := q.client.TxPipeline()
+	removed := pipe.ZRem(ctx, scheduledKey(queueName), id)
+	pipe.HDel(ctx, scheduledDataKey(queueName), id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to cancel scheduled task: %w", err)
+	}
+
+	return removed.Val() > 0, nil
+}
+
+// GetScheduledTask returns a pending scheduled task by schedule ID
+func (q *Queue) GetScheduledTask(ctx context.Context, queueName, id string) (*ScheduledTask, error) {
+	pipe := q.client.TxPipeline()
+	score := pipe.ZScore(ctx, scheduledKey(queueName), id)
+	payload := pipe.HGet(ctx, scheduledDataKey(queueName), id)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get scheduled task: %w", err)
+	}
+
+	if score.Err() == redis.Nil || payload.Err() == redis.Nil {
+		return nil, ErrNotScheduled
+	}
+
+	return decodeScheduledTask(id, payload.Val(), score.Val())
 }
 
-// GetDueScheduledTasks returns tasks that are due to be executed
-func (q *Queue) GetDueScheduledTasks() ([]*Task, error) {
-	now := time.Now().Unix()
+// ListScheduledTasks returns pending scheduled tasks, soonest first
+func (q *Queue) ListScheduledTasks(ctx context.Context, queueName string, offset, limit int64) ([]*ScheduledTask, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = offset + limit - 1
+	}
 
-	// Get tasks with score <= now
-	results, err := q.client.ZRangeByScore(q.ctx, "scheduled_tasks", &redis.ZRangeBy{
-		Min: "0",
-		Max: fmt.Sprintf("%d", now),
-	}).Result()
+	entries, err := q.client.ZRangeWithScores(ctx, scheduledKey(queueName), offset, stop).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get due tasks: %w", err)
+		return nil, fmt.Errorf("failed to list scheduled tasks: %w", err)
+	}
+	if len(entries) == 0 {
+		return []*ScheduledTask{}, nil
 	}
 
-	var tasks []*Task
-	for _, result := range results {
-		var task Task
-		if err := json.Unmarshal([]byte(result), &task); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal task: %w", err)
-		}
-		tasks = append(tasks, &task)
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.Member.(string)
+	}
+
+	payloads, err := q.client.HMGet(ctx, scheduledDataKey(queueName), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled tasks: %w", err)
 	}
 
-	// Remove the tasks from the sorted set
-	if len(results) > 0 {
-		if err := q.client.ZRemRangeByScore(q.ctx, "scheduled_tasks", "0", fmt.Sprintf("%d", now)).Err(); err != nil {
-			return nil, fmt.Errorf("failed to remove due tasks: %w", err)
+	tasks := make([]*ScheduledTask, 0, len(entries))
+	for i, entry := range entries {
+		// Promoted or cancelled in between
+		payload, ok := payloads[i].(string)
+		if !ok {
+			continue
+		}
+
+		task, err := decodeScheduledTask(ids[i], payload, entry.Score)
+		if err != nil {
+			return nil, err
 		}
+		tasks = append(tasks, task)
 	}
 
 	return tasks, nil
 }
+
+// CountScheduledTasks returns the number of pending scheduled tasks
+func (q *Queue) CountScheduledTasks(ctx context.Context, queueName string) (int64, error) {
+	return q.client.ZCard(ctx, scheduledKey(queueName)).Result()
+}
+
+// PromoteDueTasks moves up to limit scheduled tasks that are due, including
+// retries, to the end of the queue and returns how many were moved
+func (q *Queue) PromoteDueTasks(ctx context.Context, queueName string, limit int) (int, error) {
+	promoted, err := promoteScript.Run(ctx, q.client,
+		[]string{scheduledKey(queueName), scheduledDataKey(queueName), queueName},
+		time.Now().Unix(), limit,
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to promote due tasks: %w", err)
+	}
+
+	return promoted, nil
+}
+
+// decodeScheduledTask parses a scheduled task payload and its execution time
+func decodeScheduledTask(scheduleID, raw string, score float64) (*ScheduledTask, error) {
+	task, err := decodeTask(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScheduledTask{Task: *task, ExecuteAt: time.Unix(int64(score), 0), ScheduleID: scheduleID}, nil
+}
@@ -0,0 +1,316 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/services/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestQueue(t *testing.T) (*queue.Queue, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	port, err := strconv.Atoi(server.Port())
+	assert.NoError(t, err)
+
+	q, err := queue.NewQueue(config.RedisConfig{Host: server.Host(), Port: port})
+	assert.NoError(t, err)
+	t.Cleanup(func() { q.Close() })
+
+	return q, server
+}
+
+func TestScheduledTasksAreAddressableByID(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	assert.NoError(t, q.ScheduleTaskWithID(ctx, "jobs", "reminder:1", "task_reminder", map[string]interface{}{"task_id": "1"}, future))
+	assert.NoError(t, q.ScheduleTaskWithID(ctx, "jobs", "reminder:2", "task_reminder", map[string]interface{}{"task_id": "2"}, past))
+	emailID, err := q.ScheduleTask(ctx, "jobs", "email_notification", map[string]interface{}{"to": "a@example.com"}, past)
+	assert.NoError(t, err)
+
+	// Rescheduling replaces the job instead of adding a second one
+	assert.NoError(t, q.ScheduleTaskWithID(ctx, "jobs", "reminder:1", "task_reminder", map[string]interface{}{"task_id": "1"}, future.Add(time.Hour)))
+	count, err := q.CountScheduledTasks(ctx, "jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	scheduled, err := q.GetScheduledTask(ctx, "jobs", "reminder:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "task_reminder", scheduled.Type)
+	assert.Equal(t, future.Add(time.Hour).Unix(), scheduled.ExecuteAt.Unix())
+
+	list, err := q.ListScheduledTasks(ctx, "jobs", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 3)
+	assert.Equal(t, "reminder:1", list[2].ID)
+
+	cancelled, err := q.CancelScheduledTask(ctx, "jobs", "reminder:2")
+	assert.NoError(t, err)
+	assert.True(t, cancelled)
+	cancelled, err = q.CancelScheduledTask(ctx, "jobs", "reminder:2")
+	assert.NoError(t, err)
+	assert.False(t, cancelled)
+	_, err = q.GetScheduledTask(ctx, "jobs", "reminder:2")
+	assert.ErrorIs(t, err, queue.ErrNotScheduled)
+
+	// Other queues have their own schedule
+	_, err = q.ScheduleTask(ctx, "reports", "export", nil, past)
+	assert.NoError(t, err)
+
+	promoted, err := q.PromoteDueTasks(ctx, "jobs", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, promoted)
+
+	task, err := q.Dequeue(ctx, "jobs", time.Second, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, emailID, task.ID)
+
+	// Due tasks are promoted only once
+	promoted, err = q.PromoteDueTasks(ctx, "jobs", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, promoted)
+
+	length, err := q.GetQueueLength(ctx, "reports")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), length)
+}
+
+func TestPromoteDueTasksConcurrently(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute)
+	for i := 0; i < 50; i++ {
+		_, err := q.ScheduleTask(ctx, "jobs", "task_reminder", nil, past)
+		assert.NoError(t, err)
+	}
+
+	// Several workers promoting at once move every task exactly once
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				promoted, err := q.PromoteDueTasks(ctx, "jobs", 7)
+				if err != nil || promoted == 0 {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	length, err := q.GetQueueLength(ctx, "jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50), length)
+	count, err := q.CountScheduledTasks(ctx, "jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestDequeueAckAndReclaim(t *testing.T) {
+	q, server := newTestQueue(t)
+	ctx := context.Background()
+
+	_, err := q.Enqueue(ctx, "jobs", "email_notification", map[string]interface{}{"to": "a@example.com"})
+	assert.NoError(t, err)
+
+	task, err := q.Dequeue(ctx, "jobs", time.Second, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "email_notification", task.Type)
+
+	// The task stays in the processing list until acknowledged
+	processing, _ := server.List("jobs:processing")
+	assert.Len(t, processing, 1)
+	reclaimed, _, err := q.ReclaimExpired(ctx, "jobs", time.Minute, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reclaimed)
+
+	assert.NoError(t, q.Ack(ctx, "jobs", task))
+	assert.ErrorIs(t, q.Ack(ctx, "jobs", task), queue.ErrNotProcessing)
+	assert.False(t, server.Exists("jobs:processing"))
+
+	// A task held past its visibility timeout goes back to the queue
+	_, err = q.Enqueue(ctx, "jobs", "email_notification", nil)
+	assert.NoError(t, err)
+	stuck, err := q.Dequeue(ctx, "jobs", time.Second, 0)
+	assert.NoError(t, err)
+
+	reclaimed, deadLettered, err := q.ReclaimExpired(ctx, "jobs", time.Minute, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reclaimed)
+	assert.Equal(t, 0, deadLettered)
+	assert.ErrorIs(t, q.Ack(ctx, "jobs", stuck), queue.ErrNotProcessing)
+
+	// The expiry counts as a failed attempt
+	again, err := q.Dequeue(ctx, "jobs", time.Second, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, stuck.ID, again.ID)
+	assert.Equal(t, 1, again.Attempts)
+	assert.Equal(t, queue.ErrVisibilityTimeout.Error(), again.LastError)
+}
+
+func TestReclaimDeadLettersPoisonTasks(t *testing.T) {
+	q, server := newTestQueue(t)
+	ctx := context.Background()
+
+	id, err := q.Enqueue(ctx, "jobs", "task_reminder", nil)
+	assert.NoError(t, err)
+
+	// A task that hangs its worker every time is retried, then dead-lettered
+	for attempt := 1; attempt <= 3; attempt++ {
+		task, err := q.Dequeue(ctx, "jobs", time.Second, 0)
+		if !assert.NoError(t, err) || !assert.NotNil(t, task) {
+			return
+		}
+		assert.Equal(t, id, task.ID)
+
+		reclaimed, deadLettered, err := q.ReclaimExpired(ctx, "jobs", time.Minute, 2)
+		assert.NoError(t, err)
+		if attempt <= 2 {
+			assert.Equal(t, 1, reclaimed)
+			assert.Equal(t, 0, deadLettered)
+		} else {
+			assert.Equal(t, 0, reclaimed)
+			assert.Equal(t, 1, deadLettered)
+		}
+	}
+
+	length, err := q.GetQueueLength(ctx, "jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), length)
+	assert.False(t, server.Exists("jobs:processing"))
+
+	dead, err := q.ListDeadLetters(ctx, "jobs", 0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, dead, 1) {
+		assert.Equal(t, id, dead[0].ID)
+		assert.Equal(t, 3, dead[0].Attempts)
+		assert.Equal(t, queue.ErrVisibilityTimeout.Error(), dead[0].LastError)
+		assert.NotNil(t, dead[0].FailedAt)
+	}
+}
+
+func TestRetryAndDeadLetters(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	id, err := q.Enqueue(ctx, "jobs", "task_reminder", map[string]interface{}{"task_id": "1"})
+	assert.NoError(t, err)
+
+	// A retry goes through the schedule and keeps its ID and attempts
+	task, _ := q.Dequeue(ctx, "jobs", time.Second, time.Minute)
+	assert.NoError(t, q.Retry(ctx, "jobs", task, -time.Second, errors.New("smtp timeout")))
+
+	scheduled, err := q.GetScheduledTask(ctx, "jobs", "retry:"+id+":1")
+	assert.NoError(t, err)
+	assert.Equal(t, id, scheduled.ID)
+	assert.Equal(t, 1, scheduled.Attempts)
+	assert.Equal(t, "smtp timeout", scheduled.LastError)
+	promoted, err := q.PromoteDueTasks(ctx, "jobs", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, promoted)
+
+	task, _ = q.Dequeue(ctx, "jobs", time.Second, time.Minute)
+	assert.Equal(t, id, task.ID)
+	assert.Equal(t, 1, task.Attempts)
+	assert.NoError(t, q.DeadLetter(ctx, "jobs", task, errors.New("smtp rejected")))
+
+	_, err = q.Enqueue(ctx, "jobs", "email_notification", nil)
+	assert.NoError(t, err)
+	other, _ := q.Dequeue(ctx, "jobs", time.Second, time.Minute)
+	assert.NoError(t, q.DeadLetter(ctx, "jobs", other, errors.New("no handler")))
+
+	dead, err := q.ListDeadLetters(ctx, "jobs", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, dead, 2)
+	assert.Equal(t, other.ID, dead[0].ID)
+	assert.Equal(t, 2, dead[1].Attempts)
+	assert.Equal(t, "smtp rejected", dead[1].LastError)
+	assert.NotNil(t, dead[1].FailedAt)
+
+	// Requeue one dead letter by ID with its attempts reset
+	requeued, err := q.RequeueDeadLetters(ctx, "jobs", id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requeued)
+
+	task, _ = q.Dequeue(ctx, "jobs", time.Second, time.Minute)
+	assert.Equal(t, id, task.ID)
+	assert.Equal(t, 0, task.Attempts)
+	assert.Empty(t, task.LastError)
+
+	purged, err := q.PurgeDeadLetters(ctx, "jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	count, err := q.CountDeadLetters(ctx, "jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestRetryKeepsScheduledTaskWithSameID(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	// A reminder runs while its next run is already scheduled under the same ID
+	assert.NoError(t, q.ScheduleTaskWithID(ctx, "jobs", "task_reminder:1", "task_reminder", nil, time.Now().Add(-time.Second)))
+	promoted, err := q.PromoteDueTasks(ctx, "jobs", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, promoted)
+	running, _ := q.Dequeue(ctx, "jobs", time.Second, time.Minute)
+	later := time.Now().Add(time.Hour)
+	assert.NoError(t, q.ScheduleTaskWithID(ctx, "jobs", "task_reminder:1", "task_reminder", nil, later))
+
+	// The failed run is retried without replacing the next run
+	assert.NoError(t, q.Retry(ctx, "jobs", running, time.Minute, errors.New("smtp timeout")))
+	count, err := q.CountScheduledTasks(ctx, "jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	next, err := q.GetScheduledTask(ctx, "jobs", "task_reminder:1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, next.Attempts)
+	assert.Equal(t, later.Unix(), next.ExecuteAt.Unix())
+
+	// Cancelling the next run leaves the retry alone
+	cancelled, err := q.CancelScheduledTask(ctx, "jobs", "task_reminder:1")
+	assert.NoError(t, err)
+	assert.True(t, cancelled)
+	list, err := q.ListScheduledTasks(ctx, "jobs", 0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "task_reminder:1", list[0].ID)
+		assert.Equal(t, "retry:task_reminder:1:1", list[0].ScheduleID)
+		assert.Equal(t, 1, list[0].Attempts)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	q, server := newTestQueue(t)
+	ctx := context.Background()
+
+	first, err := q.Enqueue(ctx, "jobs", "task_reminder", nil)
+	assert.NoError(t, err)
+	_, err = q.Enqueue(ctx, "jobs", "task_reminder", nil)
+	assert.NoError(t, err)
+
+	// A released task goes to the end of the queue without an attempt
+	task, _ := q.Dequeue(ctx, "jobs", time.Second, time.Minute)
+	assert.NoError(t, q.Release(ctx, "jobs", task))
+	assert.ErrorIs(t, q.Release(ctx, "jobs", task), queue.ErrNotProcessing)
+	assert.False(t, server.Exists("jobs:processing"))
+
+	q.Dequeue(ctx, "jobs", time.Second, time.Minute)
+	task, _ = q.Dequeue(ctx, "jobs", time.Second, time.Minute)
+	assert.Equal(t, first, task.ID)
+	assert.Equal(t, 0, task.Attempts)
+}
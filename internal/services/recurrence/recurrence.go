@@ -0,0 +1,224 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is how often a rule repeats
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// ErrInvalidRule is returned when a recurrence rule cannot be parsed
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// weekdays maps RRULE day codes to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Rule is a subset of the iCalendar RRULE format, for example
+// "FREQ=WEEKLY;BYDAY=MO,WE", "FREQ=MONTHLY;BYMONTHDAY=15" or
+// "FREQ=DAILY;INTERVAL=3;FROM=COMPLETION"
+type Rule struct {
+	Freq     Frequency
+	Interval int
+
+	// ByDay lists the weekdays of a weekly rule
+	ByDay []time.Weekday
+
+	// ByMonthDay is the day of a monthly rule; months that are too short
+	// use their last day
+	ByMonthDay int
+
+	// FromCompletion counts the interval from when the task was completed
+	// instead of from its due date
+	FromCompletion bool
+}
+
+// Parse parses a recurrence rule
+func Parse(value string) (*Rule, error) {
+	rule := &Rule{Interval: 1}
+
+	for _, part := range strings.Split(strings.ToUpper(strings.TrimSpace(value)), ";") {
+		if part == "" {
+			continue
+		}
+
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not KEY=VALUE", ErrInvalidRule, part)
+		}
+
+		switch key {
+		case "FREQ":
+			rule.Freq = Frequency(val)
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive number", ErrInvalidRule)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				day, ok := weekdays[code]
+				if !ok {
+					return nil, fmt.Errorf("%w: unknown day %q", ErrInvalidRule, code)
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			day, err := strconv.Atoi(val)
+			if err != nil || day < 1 || day > 31 {
+				return nil, fmt.Errorf("%w: BYMONTHDAY must be between 1 and 31", ErrInvalidRule)
+			}
+			rule.ByMonthDay = day
+		case "FROM":
+			if val != "COMPLETION" {
+				return nil, fmt.Errorf("%w: FROM only supports COMPLETION", ErrInvalidRule)
+			}
+			rule.FromCompletion = true
+		default:
+			return nil, fmt.Errorf("%w: unsupported key %q", ErrInvalidRule, key)
+		}
+	}
+
+	switch rule.Freq {
+	case Daily, Weekly, Monthly:
+	case "":
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	default:
+		return nil, fmt.Errorf("%w: FREQ must be DAILY, WEEKLY or MONTHLY", ErrInvalidRule)
+	}
+
+	if len(rule.ByDay) > 0 && rule.Freq != Weekly {
+		return nil, fmt.Errorf("%w: BYDAY requires FREQ=WEEKLY", ErrInvalidRule)
+	}
+	if rule.ByMonthDay > 0 && rule.Freq != Monthly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY requires FREQ=MONTHLY", ErrInvalidRule)
+	}
+
+	sort.Slice(rule.ByDay, func(i, j int) bool {
+		return weekdayIndex(rule.ByDay[i]) < weekdayIndex(rule.ByDay[j])
+	})
+
+	return rule, nil
+}
+
+// String formats the rule in its canonical form
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		codes := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			for code, weekday := range weekdays {
+				if weekday == day {
+					codes = append(codes, code)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+
+	if r.ByMonthDay > 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+
+	if r.FromCompletion {
+		parts = append(parts, "FROM=COMPLETION")
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence after from, keeping its time of day
+func (r *Rule) Next(from time.Time) time.Time {
+	switch r.Freq {
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return from.AddDate(0, 0, 7*r.Interval)
+		}
+
+		// A later day in the same week, otherwise the first day of the next cycle
+		current := weekdayIndex(from.Weekday())
+		for _, day := range r.ByDay {
+			if index := weekdayIndex(day); index > current {
+				return from.AddDate(0, 0, index-current)
+			}
+		}
+		weekStart := from.AddDate(0, 0, -current)
+		return weekStart.AddDate(0, 0, 7*r.Interval+weekdayIndex(r.ByDay[0]))
+
+	case Monthly:
+		day := r.ByMonthDay
+		if day == 0 {
+			day = from.Day()
+		}
+
+		if candidate := monthDay(from, 0, day); candidate.After(from) {
+			return candidate
+		}
+		return monthDay(from, r.Interval, day)
+
+	default:
+		return from.AddDate(0, 0, r.Interval)
+	}
+}
+
+// Anchor pins the day of a monthly rule without BYMONTHDAY to the day of
+// from, so occurrences after a short month return to that day instead of
+// keeping the shorter one. Rules counted from completion are left as is.
+func (r *Rule) Anchor(from time.Time) {
+	if r.Freq == Monthly && r.ByMonthDay == 0 && !r.FromCompletion {
+		r.ByMonthDay = from.Day()
+	}
+}
+
+// Occurrences returns the next count occurrences after from; monthly rules
+// keep the day of from
+func (r *Rule) Occurrences(from time.Time, count int) []time.Time {
+	anchored := *r
+	anchored.Anchor(from)
+
+	occurrences := make([]time.Time, 0, count)
+	for i := 0; i < count; i++ {
+		from = anchored.Next(from)
+		occurrences = append(occurrences, from)
+	}
+	return occurrences
+}
+
+// weekdayIndex numbers weekdays from Monday (0) to Sunday (6)
+func weekdayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// monthDay returns the given day of the month months after t's month,
+// clamped to the last day of that month
+func monthDay(t time.Time, months, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,83 @@
+package recurrence_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaimesHub/golang-todo-app/internal/services/recurrence"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	rule, err := recurrence.Parse("freq=weekly;byday=fr,mo;interval=2")
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", rule.String())
+
+	invalid := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;FROM=START",
+		"FREQ=DAILY;COUNT=3",
+	}
+	for _, value := range invalid {
+		_, err := recurrence.Parse(value)
+		assert.ErrorIs(t, err, recurrence.ErrInvalidRule, value)
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		rule     string
+		from     time.Time
+		expected []time.Time
+	}{
+		{
+			rule:     "FREQ=DAILY;INTERVAL=3",
+			from:     date(2025, time.April, 30),
+			expected: []time.Time{date(2025, time.May, 3), date(2025, time.May, 6)},
+		},
+		{
+			// Monday the 14th: Wednesday, Friday, then Wednesday two weeks later
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=WE,FR",
+			from:     date(2025, time.April, 14),
+			expected: []time.Time{date(2025, time.April, 16), date(2025, time.April, 18), date(2025, time.April, 30)},
+		},
+		{
+			rule:     "FREQ=WEEKLY",
+			from:     date(2025, time.April, 14),
+			expected: []time.Time{date(2025, time.April, 21), date(2025, time.April, 28)},
+		},
+		{
+			// Short months use their last day
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=31",
+			from:     date(2025, time.January, 10),
+			expected: []time.Time{date(2025, time.January, 31), date(2025, time.February, 28), date(2025, time.March, 31)},
+		},
+		{
+			// The day of the first due date is kept after short months
+			rule:     "FREQ=MONTHLY",
+			from:     date(2025, time.January, 31),
+			expected: []time.Time{date(2025, time.February, 28), date(2025, time.March, 31), date(2025, time.April, 30), date(2025, time.May, 31)},
+		},
+		{
+			rule:     "FREQ=MONTHLY;INTERVAL=3",
+			from:     date(2025, time.January, 15),
+			expected: []time.Time{date(2025, time.April, 15), date(2025, time.July, 15)},
+		},
+	}
+
+	for _, tt := range tests {
+		rule, err := recurrence.Parse(tt.rule)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, rule.Occurrences(tt.from, len(tt.expected)), tt.rule)
+	}
+}
@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/jaimesHub/golang-todo-app/internal/config"
@@ -40,11 +41,44 @@ func (c *Client) Set(ctx context.Context, key string, value interface{}, ttl int
 	return c.client.Set(ctx, key, value, 0).Err()
 }
 
+// SetWithTTL sets a key-value pair in Redis that expires after ttl
+func (c *Client) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
 // Get gets a value from Redis by key
 func (c *Client) Get(ctx context.Context, key string) (string, error) {
 	return c.client.Get(ctx, key).Result()
 }
 
+// MGet gets the values of several keys; missing keys are nil
+func (c *Client) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	return c.client.MGet(ctx, keys...).Result()
+}
+
+// HGetAll gets all fields of a hash; a missing key is an empty map
+func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return c.client.HGetAll(ctx, key).Result()
+}
+
+// HIncrByWithTTL increments a hash field, sets further fields and makes the
+// key expire after ttl in one transaction. It returns the incremented value.
+func (c *Client) HIncrByWithTTL(ctx context.Context, key, field string, incr int64, values map[string]interface{}, ttl time.Duration) (int64, error) {
+	var incremented *redis.IntCmd
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incremented = pipe.HIncrBy(ctx, key, field, incr)
+		if len(values) > 0 {
+			pipe.HSet(ctx, key, values)
+		}
+		pipe.PExpire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incremented.Val(), nil
+}
+
 // Delete deletes a key from Redis
 func (c *Client) Delete(ctx context.Context, key string) error {
 	return c.client.Del(ctx, key).Err()
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+	"github.com/jaimesHub/golang-todo-app/internal/services/notifier"
+)
+
+// ReminderService delivers due-date reminders scheduled by TaskService
+type ReminderService struct {
+	repos    *repository.Repositories
+	notifier notifier.Notifier
+}
+
+// NewReminderService creates a new reminder service
+func NewReminderService(repos *repository.Repositories, notifier notifier.Notifier) *ReminderService {
+	return &ReminderService{repos: repos, notifier: notifier}
+}
+
+// HandleReminder processes a task_reminder job. Reminders for tasks that were
+// deleted, completed or lost their due date in the meantime are dropped, as
+// are those for recipients who were deleted.
+func (s *ReminderService) HandleReminder(ctx context.Context, payload jobs.TaskReminderPayload) error {
+	task, err := s.repos.Tasks.FindByID(payload.TaskID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if task.DueDate == nil || task.ReminderMinutes == nil || task.Status == "completed" {
+		return nil
+	}
+
+	// Assigned tasks remind the assignee instead of their creator
+	recipientID := task.UserID
+	if task.AssigneeID != nil {
+		recipientID = *task.AssigneeID
+	}
+
+	user, err := s.repos.Users.FindByID(recipientID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return s.notifier.Notify(ctx, notifier.Notification{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Subject: "Reminder: " + task.Title,
+		Body:    fmt.Sprintf("Your task %q is due at %s.", task.Title, task.DueDate.Format(time.RFC1123)),
+		SentAt:  time.Now(),
+	})
+}
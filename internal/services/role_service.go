@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
+)
+
+var (
+	// ErrRoleNotFound is returned when a role does not exist
+	ErrRoleNotFound = errors.New("role not found")
+
+	// ErrRoleExists is returned when creating a role whose name is taken
+	ErrRoleExists = errors.New("role already exists")
+
+	// ErrBuiltinRole is returned when changing or deleting the user or admin role
+	ErrBuiltinRole = errors.New("built-in roles cannot be changed")
+
+	// ErrRoleInUse is returned when deleting a role that users still have
+	ErrRoleInUse = errors.New("role is assigned to users")
+
+	// ErrInvalidRoleName is returned for role names that are not lowercase
+	// letters, digits, dashes and underscores
+	ErrInvalidRoleName = errors.New("role name must be 1 to 50 lowercase letters, digits, dashes or underscores")
+
+	// ErrInvalidPermissions is returned for unknown permissions
+	ErrInvalidPermissions = errors.New("invalid permissions")
+
+	// ErrPermissionEscalation is returned when granting permissions the
+	// acting user does not have
+	ErrPermissionEscalation = errors.New("cannot grant permissions you do not have")
+)
+
+// roleNamePattern matches valid role names
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// RoleService manages roles and answers permission checks
+type RoleService struct {
+	repos *repository.Repositories
+}
+
+// NewRoleService creates a new role service
+func NewRoleService(repos *repository.Repositories) *RoleService {
+	return &RoleService{repos: repos}
+}
+
+// builtinRoles returns the roles that exist without being stored
+func builtinRoles() []models.Role {
+	return []models.Role{
+		{Name: models.RoleAdmin, Description: "Full access to the admin API", Permissions: slices.Clone(auth.Permissions), Builtin: true},
+		{Name: models.RoleUser, Description: "Regular user", Permissions: models.StringList{}, Builtin: true},
+	}
+}
+
+// isBuiltinRole reports whether a role name is the user or admin role
+func isBuiltinRole(name string) bool {
+	return name == models.RoleUser || name == models.RoleAdmin
+}
+
+// HasPermission reports whether a role grants a permission. Users without a
+// role are treated as regular users, and roles that were deleted grant
+// nothing.
+func (s *RoleService) HasPermission(role, permission string) (bool, error) {
+	switch role {
+	case models.RoleAdmin:
+		return true, nil
+	case "", models.RoleUser:
+		return false, nil
+	}
+
+	custom, err := s.repos.Roles.FindByName(role)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return slices.Contains(custom.Permissions, permission), nil
+}
+
+// ListRoles retrieves the built-in roles followed by the custom roles
+func (s *RoleService) ListRoles() ([]models.Role, error) {
+	custom, err := s.repos.Roles.List()
+	if err != nil {
+		return nil, err
+	}
+	return append(builtinRoles(), custom...), nil
+}
+
+// GetRole retrieves a built-in or custom role by name
+func (s *RoleService) GetRole(name string) (*models.Role, error) {
+	for _, role := range builtinRoles() {
+		if role.Name == name {
+			return &role, nil
+		}
+	}
+
+	role, err := s.repos.Roles.FindByName(name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// CanGrant checks that an actor holds every permission of a role, so that
+// giving the role away or changing it cannot grant more than the actor has.
+// Only admins grant the admin role. uuid.Nil stands for the command line,
+// which may grant anything.
+func (s *RoleService) CanGrant(actorID uuid.UUID, role *models.Role) error {
+	if actorID == uuid.Nil {
+		return nil
+	}
+
+	actor, err := s.repos.Users.FindByID(actorID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrPermissionEscalation
+		}
+		return err
+	}
+	if actor.Role == models.RoleAdmin {
+		return nil
+	}
+	if role.Name == models.RoleAdmin {
+		return ErrPermissionEscalation
+	}
+
+	for _, permission := range role.Permissions {
+		allowed, err := s.HasPermission(actor.Role, permission)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s", ErrPermissionEscalation, permission)
+		}
+	}
+	return nil
+}
+
+// CreateRole creates a custom role with permissions the actor holds
+func (s *RoleService) CreateRole(actorID uuid.UUID, name, description string, permissions []string) (*models.Role, error) {
+	name = strings.TrimSpace(name)
+	if !roleNamePattern.MatchString(name) {
+		return nil, ErrInvalidRoleName
+	}
+	if isBuiltinRole(name) {
+		return nil, ErrRoleExists
+	}
+
+	normalized, err := normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		Permissions: normalized,
+	}
+	if err := s.CanGrant(actorID, role); err != nil {
+		return nil, err
+	}
+	if err := s.repos.Roles.Create(role); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrRoleExists
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// UpdateRole changes the description and permissions of a custom role. Nil
+// arguments are left unchanged. The actor must hold every permission of the
+// role, both before and after the change.
+func (s *RoleService) UpdateRole(actorID uuid.UUID, name string, description *string, permissions []string) (*models.Role, error) {
+	if isBuiltinRole(name) {
+		return nil, ErrBuiltinRole
+	}
+
+	role, err := s.GetRole(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CanGrant(actorID, role); err != nil {
+		return nil, err
+	}
+
+	if description != nil {
+		role.Description = strings.TrimSpace(*description)
+	}
+	if permissions != nil {
+		if role.Permissions, err = normalizePermissions(permissions); err != nil {
+			return nil, err
+		}
+		if err := s.CanGrant(actorID, role); err != nil {
+			return nil, err
+		}
+	}
+
+	role.UpdatedAt = time.Now()
+	if err := s.repos.Roles.Update(role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// DeleteRole deletes a custom role that no user has
+func (s *RoleService) DeleteRole(name string) error {
+	if isBuiltinRole(name) {
+		return ErrBuiltinRole
+	}
+
+	role, err := s.GetRole(name)
+	if err != nil {
+		return err
+	}
+
+	holders, err := s.repos.Users.Count(repository.UserFilter{Role: name})
+	if err != nil {
+		return err
+	}
+	if holders > 0 {
+		return ErrRoleInUse
+	}
+
+	return s.repos.Roles.Delete(role)
+}
+
+// normalizePermissions checks permissions and removes duplicates. A role
+// may have no permissions.
+func normalizePermissions(permissions []string) (models.StringList, error) {
+	normalized := models.StringList{}
+	for _, permission := range permissions {
+		permission = strings.ToLower(strings.TrimSpace(permission))
+		if !auth.ValidPermission(permission) {
+			return nil, fmt.Errorf("%w: unknown permission %q", ErrInvalidPermissions, permission)
+		}
+		if !slices.Contains(normalized, permission) {
+			normalized = append(normalized, permission)
+		}
+	}
+	return normalized, nil
+}
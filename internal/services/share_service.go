@@ -0,0 +1,274 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+)
+
+var (
+	// ErrShareNotFound is returned when a task or project is not shared with the user
+	ErrShareNotFound = errors.New("share not found")
+
+	// ErrInvalidSharePermission is returned for permissions other than read and edit
+	ErrInvalidSharePermission = errors.New("permission must be read or edit")
+
+	// ErrShareWithSelf is returned when the owner shares with themselves
+	ErrShareWithSelf = errors.New("cannot share with yourself")
+
+	// ErrShareWorkspaceTask is returned when sharing a workspace task, which
+	// the members of its workspace see already
+	ErrShareWorkspaceTask = errors.New("workspace tasks are shared through their workspace")
+
+	// ErrShareReadOnly is returned when changing a task shared with the user
+	// for reading only
+	ErrShareReadOnly = errors.New("task is shared read-only")
+
+	// ErrShareOwnerOnly is returned when a user a task or project is shared
+	// with attempts something only its owner can do
+	ErrShareOwnerOnly = errors.New("only the owner can do this")
+)
+
+// ShareService manages the shares of personal tasks and projects with other users
+type ShareService struct {
+	repos *repository.Repositories
+}
+
+// NewShareService creates a new share service
+func NewShareService(repos *repository.Repositories) *ShareService {
+	return &ShareService{repos: repos}
+}
+
+// ShareTask shares one of the owner's personal tasks with the user with the
+// given email, or changes the permission of an existing share
+func (s *ShareService) ShareTask(taskID, ownerID uuid.UUID, email, permission string) (*models.Share, error) {
+	if !validSharePermission(permission) {
+		return nil, ErrInvalidSharePermission
+	}
+
+	if _, err := s.ownedTask(taskID, ownerID); err != nil {
+		return nil, err
+	}
+
+	recipient, err := s.findRecipient(ownerID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	share := &models.Share{
+		TaskID:     &taskID,
+		OwnerID:    ownerID,
+		UserID:     recipient.ID,
+		Permission: permission,
+	}
+	return s.grant(share, recipient, func() (*models.Share, error) {
+		return s.repos.Shares.FindByTask(taskID, recipient.ID)
+	})
+}
+
+// ShareProject shares every task of one of the owner's projects with the
+// user with the given email, or changes the permission of an existing share
+func (s *ShareService) ShareProject(projectID, ownerID uuid.UUID, email, permission string) (*models.Share, error) {
+	if !validSharePermission(permission) {
+		return nil, ErrInvalidSharePermission
+	}
+
+	if _, err := s.ownedProject(projectID, ownerID); err != nil {
+		return nil, err
+	}
+
+	recipient, err := s.findRecipient(ownerID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	share := &models.Share{
+		ProjectID:  &projectID,
+		OwnerID:    ownerID,
+		UserID:     recipient.ID,
+		Permission: permission,
+	}
+	return s.grant(share, recipient, func() (*models.Share, error) {
+		return s.repos.Shares.FindByProject(projectID, recipient.ID)
+	})
+}
+
+// GetTaskShares lists the users one of the owner's tasks is shared with
+func (s *ShareService) GetTaskShares(taskID, ownerID uuid.UUID) ([]models.Share, error) {
+	if _, err := s.ownedTask(taskID, ownerID); err != nil {
+		return nil, err
+	}
+	return s.repos.Shares.ListByTask(taskID)
+}
+
+// GetProjectShares lists the users one of the owner's projects is shared with
+func (s *ShareService) GetProjectShares(projectID, ownerID uuid.UUID) ([]models.Share, error) {
+	if _, err := s.ownedProject(projectID, ownerID); err != nil {
+		return nil, err
+	}
+	return s.repos.Shares.ListByProject(projectID)
+}
+
+// UnshareTask revokes the share of a task with a user. The owner revokes any
+// share; the user it is shared with can give up their own.
+func (s *ShareService) UnshareTask(taskID, actorID, userID uuid.UUID) error {
+	if actorID != userID {
+		if _, err := s.ownedTask(taskID, actorID); err != nil {
+			return err
+		}
+	}
+
+	share, err := s.repos.Shares.FindByTask(taskID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrShareNotFound
+		}
+		return err
+	}
+	return s.repos.Shares.Delete(share)
+}
+
+// UnshareProject revokes the share of a project with a user. The owner
+// revokes any share; the user it is shared with can give up their own.
+func (s *ShareService) UnshareProject(projectID, actorID, userID uuid.UUID) error {
+	if actorID != userID {
+		if _, err := s.ownedProject(projectID, actorID); err != nil {
+			return err
+		}
+	}
+
+	share, err := s.repos.Shares.FindByProject(projectID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrShareNotFound
+		}
+		return err
+	}
+	return s.repos.Shares.Delete(share)
+}
+
+// grant creates a share, or updates the permission of the existing share of
+// the same task or project with the recipient
+func (s *ShareService) grant(share *models.Share, recipient *models.User, find func() (*models.Share, error)) (*models.Share, error) {
+	now := time.Now()
+	share.CreatedAt = now
+	share.UpdatedAt = now
+
+	err := s.repos.Shares.Create(share)
+	if errors.Is(err, repository.ErrDuplicate) {
+		existing, findErr := find()
+		if findErr != nil {
+			return nil, findErr
+		}
+		existing.Permission = share.Permission
+		existing.UpdatedAt = now
+		share, err = existing, s.repos.Shares.Update(existing)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	share.User = recipient
+	return share, nil
+}
+
+// ownedTask retrieves a personal task of the owner for managing its shares.
+// Users who can see the task without owning it get an error that says why.
+func (s *ShareService) ownedTask(taskID, ownerID uuid.UUID) (*models.Task, error) {
+	task, err := s.repos.Tasks.FindByID(taskID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+
+	if task.WorkspaceID != nil {
+		role, err := memberRole(s.repos, *task.WorkspaceID, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		if role == "" {
+			return nil, ErrTaskNotFound
+		}
+		return nil, ErrShareWorkspaceTask
+	}
+
+	if task.UserID != ownerID {
+		permission, err := sharePermission(s.repos, task, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		if permission == "" {
+			return nil, ErrTaskNotFound
+		}
+		return nil, ErrShareOwnerOnly
+	}
+
+	return task, nil
+}
+
+// ownedProject retrieves a project of the owner for managing its shares
+func (s *ShareService) ownedProject(projectID, ownerID uuid.UUID) (*models.Project, error) {
+	project, err := s.repos.Projects.FindByID(projectID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+
+	if project.UserID != ownerID {
+		if _, err := s.repos.Shares.FindByProject(projectID, ownerID); err == nil {
+			return nil, ErrShareOwnerOnly
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		return nil, ErrProjectNotFound
+	}
+
+	return project, nil
+}
+
+// findRecipient looks up the user to share with by email
+func (s *ShareService) findRecipient(ownerID uuid.UUID, email string) (*models.User, error) {
+	user, err := s.repos.Users.FindByEmail(strings.TrimSpace(email))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if user.ID == ownerID {
+		return nil, ErrShareWithSelf
+	}
+	return user, nil
+}
+
+// validSharePermission reports whether a share permission is known
+func validSharePermission(permission string) bool {
+	return permission == models.SharePermissionRead || permission == models.SharePermissionEdit
+}
+
+// sharePermission returns the highest permission the shares of a personal
+// task or of its project give a user, or "" if it is not shared with them
+func sharePermission(repos *repository.Repositories, task *models.Task, userID uuid.UUID) (string, error) {
+	shares, err := repos.Shares.ListForTask(task.ID, task.ProjectID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	permission := ""
+	for _, share := range shares {
+		if share.Permission == models.SharePermissionEdit {
+			return models.SharePermissionEdit, nil
+		}
+		permission = share.Permission
+	}
+	return permission, nil
+}
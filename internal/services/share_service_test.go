@@ -0,0 +1,176 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareTask(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	shareService := services.NewShareService(repos)
+	taskService := services.NewTaskService(repos)
+	userService := services.NewUserService(repos)
+
+	owner, err := userService.CreateUser("owner@example.com", "password123", "Olive", "Owner")
+	assert.NoError(t, err)
+	friend, err := userService.CreateUser("friend@example.com", "password123", "Fay", "Friend")
+	assert.NoError(t, err)
+	stranger, err := userService.CreateUser("stranger@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	task, err := taskService.CreateTask(owner.ID, services.TaskInput{Title: "Plan trip"})
+	assert.NoError(t, err)
+
+	_, err = shareService.ShareTask(task.ID, owner.ID, "friend@example.com", "admin")
+	assert.ErrorIs(t, err, services.ErrInvalidSharePermission)
+	_, err = shareService.ShareTask(task.ID, owner.ID, "owner@example.com", models.SharePermissionRead)
+	assert.ErrorIs(t, err, services.ErrShareWithSelf)
+	_, err = shareService.ShareTask(task.ID, owner.ID, "nobody@example.com", models.SharePermissionRead)
+	assert.ErrorIs(t, err, services.ErrUserNotFound)
+	_, err = shareService.ShareTask(task.ID, stranger.ID, "friend@example.com", models.SharePermissionRead)
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+
+	// A read share lets the friend see the task but not change it
+	share, err := shareService.ShareTask(task.ID, owner.ID, "Friend@Example.com", models.SharePermissionRead)
+	assert.NoError(t, err)
+	assert.Equal(t, friend.ID, share.UserID)
+
+	_, err = taskService.GetTaskByID(task.ID, friend.ID)
+	assert.NoError(t, err)
+	_, err = taskService.GetTaskByID(task.ID, stranger.ID)
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+	_, err = taskService.UpdateTask(task.ID, friend.ID, services.TaskInput{Title: "Plan holiday", Priority: -1})
+	assert.ErrorIs(t, err, services.ErrShareReadOnly)
+
+	// Sharing again changes the permission of the existing share
+	upgraded, err := shareService.ShareTask(task.ID, owner.ID, "friend@example.com", models.SharePermissionEdit)
+	assert.NoError(t, err)
+	assert.Equal(t, share.ID, upgraded.ID)
+	shares, err := shareService.GetTaskShares(task.ID, owner.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, shares, 1) {
+		assert.Equal(t, models.SharePermissionEdit, shares[0].Permission)
+		assert.Equal(t, "friend@example.com", shares[0].User.Email)
+	}
+
+	updated, err := taskService.UpdateTask(task.ID, friend.ID, services.TaskInput{Title: "Plan holiday", Priority: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, "Plan holiday", updated.Title)
+	assert.Equal(t, owner.ID, updated.UserID)
+
+	// Only the owner deletes the task or manages its shares
+	err = taskService.DeleteTask(task.ID, friend.ID)
+	assert.ErrorIs(t, err, services.ErrShareOwnerOnly)
+	_, err = shareService.GetTaskShares(task.ID, friend.ID)
+	assert.ErrorIs(t, err, services.ErrShareOwnerOnly)
+	_, err = shareService.ShareTask(task.ID, friend.ID, "stranger@example.com", models.SharePermissionRead)
+	assert.ErrorIs(t, err, services.ErrShareOwnerOnly)
+
+	shared, err := taskService.GetTasks(repository.TaskFilter{UserID: friend.ID, Priority: -1, SharedWithMe: true})
+	assert.NoError(t, err)
+	assert.Len(t, shared, 1)
+	own, err := taskService.GetTasks(repository.TaskFilter{UserID: friend.ID, Priority: -1})
+	assert.NoError(t, err)
+	assert.Empty(t, own)
+
+	// The friend can give up the share, after which the task is gone for them
+	assert.NoError(t, shareService.UnshareTask(task.ID, friend.ID, friend.ID))
+	assert.ErrorIs(t, shareService.UnshareTask(task.ID, owner.ID, friend.ID), services.ErrShareNotFound)
+	_, err = taskService.GetTaskByID(task.ID, friend.ID)
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+
+	// Emails match whatever their case was at registration
+	jane, err := userService.CreateUser("Jane@Example.com", "password123", "", "")
+	assert.NoError(t, err)
+	_, err = userService.CreateUser("jane@example.com", "password123", "", "")
+	assert.ErrorIs(t, err, services.ErrEmailTaken)
+	for _, email := range []string{"Jane@Example.com", "jane@example.com"} {
+		share, err := shareService.ShareTask(task.ID, owner.ID, email, models.SharePermissionRead)
+		if assert.NoError(t, err, email) {
+			assert.Equal(t, jane.ID, share.UserID)
+		}
+	}
+}
+
+func TestShareProject(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	shareService := services.NewShareService(repos)
+	taskService := services.NewTaskService(repos)
+	projectService := services.NewProjectService(repos)
+	userService := services.NewUserService(repos)
+
+	owner, err := userService.CreateUser("owner@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	friend, err := userService.CreateUser("friend@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	project, err := projectService.CreateProject(owner.ID, services.ProjectInput{Name: stringPtr("Home")})
+	assert.NoError(t, err)
+	inProject, err := taskService.CreateTask(owner.ID, services.TaskInput{Title: "Paint", ProjectID: &project.ID})
+	assert.NoError(t, err)
+	outside, err := taskService.CreateTask(owner.ID, services.TaskInput{Title: "Private"})
+	assert.NoError(t, err)
+
+	_, err = shareService.ShareProject(project.ID, friend.ID, "owner@example.com", models.SharePermissionRead)
+	assert.ErrorIs(t, err, services.ErrProjectNotFound)
+	_, err = shareService.ShareProject(project.ID, owner.ID, "friend@example.com", models.SharePermissionRead)
+	assert.NoError(t, err)
+
+	// Every task of the project is shared, tasks outside it are not
+	_, err = taskService.GetTaskByID(inProject.ID, friend.ID)
+	assert.NoError(t, err)
+	_, err = taskService.GetTaskByID(outside.ID, friend.ID)
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+
+	// A direct edit share outranks the read-only project share
+	_, err = shareService.ShareTask(inProject.ID, owner.ID, "friend@example.com", models.SharePermissionEdit)
+	assert.NoError(t, err)
+	_, err = taskService.UpdateTask(inProject.ID, friend.ID, services.TaskInput{Status: "in_progress", Priority: -1})
+	assert.NoError(t, err)
+
+	// Moving a shared task out of its project is up to the owner
+	noProject := uuid.Nil
+	_, err = taskService.UpdateTask(inProject.ID, friend.ID, services.TaskInput{Priority: -1, ProjectID: &noProject})
+	assert.ErrorIs(t, err, services.ErrShareOwnerOnly)
+
+	shared, err := taskService.GetTasks(repository.TaskFilter{UserID: friend.ID, Priority: -1, SharedWithMe: true})
+	assert.NoError(t, err)
+	assert.Len(t, shared, 1)
+
+	// Tasks added to the project later are shared too
+	_, err = taskService.UpdateTask(outside.ID, owner.ID, services.TaskInput{Priority: -1, ProjectID: &project.ID})
+	assert.NoError(t, err)
+	count, err := taskService.CountTasks(repository.TaskFilter{UserID: friend.ID, Priority: -1, SharedWithMe: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	assert.NoError(t, shareService.UnshareProject(project.ID, owner.ID, friend.ID))
+	_, err = taskService.GetTaskByID(outside.ID, friend.ID)
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+}
+
+func TestShareWorkspaceTask(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	shareService := services.NewShareService(repos)
+	taskService := services.NewTaskService(repos)
+	workspaceService, _ := newWorkspaceService(repos)
+	userService := services.NewUserService(repos)
+
+	owner, err := userService.CreateUser("owner@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	_, err = userService.CreateUser("friend@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	workspace, err := workspaceService.CreateWorkspace(owner.ID, "Team")
+	assert.NoError(t, err)
+	task, err := taskService.CreateTask(owner.ID, services.TaskInput{Title: "Team task", WorkspaceID: &workspace.ID})
+	assert.NoError(t, err)
+
+	_, err = shareService.ShareTask(task.ID, owner.ID, "friend@example.com", models.SharePermissionRead)
+	assert.ErrorIs(t, err, services.ErrShareWorkspaceTask)
+}
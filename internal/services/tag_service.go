@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+)
+
+// colorPattern matches hex colors such as #ff0000, used by tags and projects
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// TagService handles tag-related business logic
+type TagService struct {
+	repos *repository.Repositories
+}
+
+// NewTagService creates a new tag service
+func NewTagService(repos *repository.Repositories) *TagService {
+	return &TagService{repos: repos}
+}
+
+// CreateTag creates a new tag for a user
+func (s *TagService) CreateTag(userID uuid.UUID, name, color string) (*models.Tag, error) {
+	name = strings.TrimSpace(name)
+	if err := validateTag(name, color); err != nil {
+		return nil, err
+	}
+
+	// Check if the user already has a tag with this name
+	_, err := s.repos.Tags.FindByName(userID, name)
+	if err == nil {
+		return nil, errors.New("tag with this name already exists")
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	tag := &models.Tag{
+		UserID:    userID,
+		Name:      name,
+		Color:     color,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := s.repos.Tags.Create(tag); err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// GetTagByID retrieves a user's tag by ID
+func (s *TagService) GetTagByID(id uuid.UUID, userID uuid.UUID) (*models.Tag, error) {
+	tag, err := s.repos.Tags.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+
+	if tag.UserID != userID {
+		return nil, ErrTagNotFound
+	}
+
+	return tag, nil
+}
+
+// GetTags retrieves all tags of a user
+func (s *TagService) GetTags(userID uuid.UUID) ([]models.Tag, error) {
+	return s.repos.Tags.ListByUser(userID)
+}
+
+// UpdateTag renames or recolors a tag
+func (s *TagService) UpdateTag(id uuid.UUID, userID uuid.UUID, name, color string) (*models.Tag, error) {
+	tag, err := s.GetTagByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name != "" && name != tag.Name {
+		if _, err := s.repos.Tags.FindByName(userID, name); err == nil {
+			return nil, errors.New("tag with this name already exists")
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		tag.Name = name
+	}
+
+	if color != "" {
+		tag.Color = color
+	}
+
+	if err := validateTag(tag.Name, tag.Color); err != nil {
+		return nil, err
+	}
+
+	tag.UpdatedAt = time.Now()
+
+	if err := s.repos.Tags.Update(tag); err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// DeleteTag deletes a tag and detaches it from all tasks
+func (s *TagService) DeleteTag(id uuid.UUID, userID uuid.UUID) error {
+	tag, err := s.GetTagByID(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.repos.Tags.Delete(tag)
+}
+
+// FindOrCreateTag returns the user's tag with the given name, creating it if needed
+func (s *TagService) FindOrCreateTag(userID uuid.UUID, name string) (*models.Tag, error) {
+	tag, err := s.repos.Tags.FindByName(userID, strings.TrimSpace(name))
+	if err == nil {
+		return tag, nil
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	return s.CreateTag(userID, name, "")
+}
+
+// validateTag checks a tag's name and color
+func validateTag(name, color string) error {
+	if name == "" {
+		return errors.New("tag name is required")
+	}
+
+	if len(name) > 50 {
+		return errors.New("tag name must be at most 50 characters")
+	}
+
+	if strings.Contains(name, ",") {
+		return errors.New("tag name must not contain commas")
+	}
+
+	if color != "" && !colorPattern.MatchString(color) {
+		return errors.New("tag color must be a hex color like #ff0000")
+	}
+
+	return nil
+}
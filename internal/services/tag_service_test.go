@@ -0,0 +1,95 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagCRUD(t *testing.T) {
+	tagService := services.NewTagService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	tag, err := tagService.CreateTag(userID, " bug ", "#ff0000")
+	assert.NoError(t, err)
+	assert.Equal(t, "bug", tag.Name)
+
+	// Names are unique per user
+	_, err = tagService.CreateTag(userID, "bug", "")
+	assert.EqualError(t, err, "tag with this name already exists")
+	_, err = tagService.CreateTag(uuid.New(), "bug", "")
+	assert.NoError(t, err)
+
+	// Invalid colors are rejected
+	_, err = tagService.CreateTag(userID, "feature", "red")
+	assert.Error(t, err)
+
+	updated, err := tagService.UpdateTag(tag.ID, userID, "defect", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "defect", updated.Name)
+	assert.Equal(t, "#ff0000", updated.Color)
+
+	// Other users cannot see the tag
+	_, err = tagService.GetTagByID(tag.ID, uuid.New())
+	assert.EqualError(t, err, "tag not found")
+
+	tags, err := tagService.GetTags(userID)
+	assert.NoError(t, err)
+	assert.Len(t, tags, 1)
+
+	assert.NoError(t, tagService.DeleteTag(tag.ID, userID))
+	tags, err = tagService.GetTags(userID)
+	assert.NoError(t, err)
+	assert.Empty(t, tags)
+}
+
+func TestGetTasksByTags(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	taskService := services.NewTaskService(repos)
+	tagService := services.NewTagService(repos)
+	userID := uuid.New()
+
+	bug, _ := tagService.CreateTag(userID, "bug", "")
+	urgent, _ := tagService.CreateTag(userID, "urgent", "")
+	otherUsersTag, _ := tagService.CreateTag(uuid.New(), "bug", "")
+
+	bugOnly, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Bug only"})
+	both, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Urgent bug"})
+	_, _ = taskService.CreateTask(userID, services.TaskInput{Title: "Untagged"})
+
+	_, err := taskService.AttachTag(bugOnly.ID, userID, bug.ID)
+	assert.NoError(t, err)
+	_, err = taskService.AttachTag(both.ID, userID, bug.ID)
+	assert.NoError(t, err)
+	task, err := taskService.AttachTag(both.ID, userID, urgent.ID)
+	assert.NoError(t, err)
+	assert.Len(t, task.Tags, 2)
+
+	// Another user's tag cannot be attached
+	_, err = taskService.AttachTag(bugOnly.ID, userID, otherUsersTag.ID)
+	assert.EqualError(t, err, "tag not found")
+
+	anyTasks, err := taskService.GetTasks(repository.TaskFilter{UserID: userID, Priority: -1, Tags: []string{"bug", "urgent"}})
+	assert.NoError(t, err)
+	assert.Len(t, anyTasks, 2)
+
+	allTasks, err := taskService.GetTasks(repository.TaskFilter{UserID: userID, Priority: -1, Tags: []string{"bug", "urgent"}, MatchAllTags: true})
+	assert.NoError(t, err)
+	assert.Len(t, allTasks, 1)
+	assert.Equal(t, both.ID, allTasks[0].ID)
+
+	// Detaching and deleting tags updates the filter results
+	_, err = taskService.DetachTag(both.ID, userID, urgent.ID)
+	assert.NoError(t, err)
+	count, err := taskService.CountTasks(repository.TaskFilter{UserID: userID, Priority: -1, Tags: []string{"bug", "urgent"}, MatchAllTags: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	assert.NoError(t, tagService.DeleteTag(bug.ID, userID))
+	count, err = taskService.CountTasks(repository.TaskFilter{UserID: userID, Priority: -1, Tags: []string{"bug"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
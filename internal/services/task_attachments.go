@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+)
+
+// SetQueue enables removing the attachments of deleted tasks through jobs on
+// the named queue. Without a queue, their files are kept until the worker
+// sweeps the attachments of deleted tasks after a workspace or account is
+// removed.
+func (s *TaskService) SetQueue(q jobs.Enqueuer, queueName string) {
+	s.queue = q
+	s.queueName = queueName
+}
+
+// cleanupAttachments enqueues the removal of a deleted task's attachments,
+// if it has any
+func (s *TaskService) cleanupAttachments(taskID uuid.UUID) error {
+	if s.queue == nil {
+		return nil
+	}
+
+	attachments, err := s.repos.Attachments.ListByTask(taskID)
+	if err != nil {
+		return err
+	}
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	if _, err := jobs.AttachmentCleanup.Enqueue(context.Background(), s.queue, s.queueName, jobs.AttachmentCleanupPayload{
+		TaskID: taskID,
+	}); err != nil {
+		return fmt.Errorf("failed to enqueue attachment cleanup: %w", err)
+	}
+	return nil
+}
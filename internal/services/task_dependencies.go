@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+)
+
+var (
+	// ErrDependencyNotFound is returned when removing a dependency that does not exist
+	ErrDependencyNotFound = errors.New("dependency not found")
+
+	// ErrDependencyExists is returned when adding a dependency twice
+	ErrDependencyExists = errors.New("dependency already exists")
+
+	// ErrDependencyCycle is returned when a dependency would make a task block itself
+	ErrDependencyCycle = errors.New("dependency would create a cycle")
+
+	// ErrTaskBlocked is returned when starting or completing a task whose blockers are still open
+	ErrTaskBlocked = errors.New("task is blocked by tasks that are not completed")
+)
+
+// TaskDependencies lists the tasks a task is blocked by and the tasks it blocks
+type TaskDependencies struct {
+	BlockedBy []models.Task `json:"blocked_by"`
+	Blocking  []models.Task `json:"blocking"`
+}
+
+// AddDependency records that a task cannot start until blockedByID is
+// completed. Both tasks must be personal tasks of one user or belong to the
+// same workspace.
+func (s *TaskService) AddDependency(taskID, userID, blockedByID uuid.UUID) (*TaskDependencies, error) {
+	task, err := s.getTaskForEdit(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	blocker, err := s.GetTaskByID(blockedByID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !sameScope(task, blocker) {
+		return nil, ErrTaskNotFound
+	}
+
+	if taskID == blockedByID {
+		return nil, ErrDependencyCycle
+	}
+
+	// The new edge closes a cycle if the task already blocks blockedByID
+	cycle, err := s.isBlockedBy(blockedByID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, ErrDependencyCycle
+	}
+
+	if err := s.repos.Dependencies.Add(&models.TaskDependency{
+		TaskID:      taskID,
+		BlockedByID: blockedByID,
+		CreatedAt:   time.Now(),
+	}); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrDependencyExists
+		}
+		return nil, err
+	}
+
+	return s.GetDependencies(taskID, userID)
+}
+
+// RemoveDependency removes a blocked-by edge from a task the user can change
+func (s *TaskService) RemoveDependency(taskID, userID, blockedByID uuid.UUID) (*TaskDependencies, error) {
+	if _, err := s.getTaskForEdit(taskID, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Dependencies.Remove(taskID, blockedByID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrDependencyNotFound
+		}
+		return nil, err
+	}
+
+	return s.GetDependencies(taskID, userID)
+}
+
+// GetDependencies retrieves the tasks a task is blocked by and the tasks it blocks
+func (s *TaskService) GetDependencies(taskID, userID uuid.UUID) (*TaskDependencies, error) {
+	if _, err := s.GetTaskByID(taskID, userID); err != nil {
+		return nil, err
+	}
+
+	blockedBy, err := s.repos.Dependencies.ListBlockers(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	blocking, err := s.repos.Dependencies.ListDependents(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaskDependencies{BlockedBy: blockedBy, Blocking: blocking}, nil
+}
+
+// isBlockedBy reports whether target is among the direct or transitive blockers of a task
+func (s *TaskService) isBlockedBy(taskID, target uuid.UUID) (bool, error) {
+	visited := map[uuid.UUID]bool{taskID: true}
+	queue := []uuid.UUID{taskID}
+
+	for len(queue) > 0 {
+		blockers, err := s.repos.Dependencies.ListBlockers(queue[0])
+		if err != nil {
+			return false, err
+		}
+		queue = queue[1:]
+
+		for _, blocker := range blockers {
+			if blocker.ID == target {
+				return true, nil
+			}
+			if !visited[blocker.ID] {
+				visited[blocker.ID] = true
+				queue = append(queue, blocker.ID)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// checkNotBlocked returns ErrTaskBlocked if any of a task's blockers is not completed
+func (s *TaskService) checkNotBlocked(taskID uuid.UUID) error {
+	blockers, err := s.repos.Dependencies.ListBlockers(taskID)
+	if err != nil {
+		return err
+	}
+
+	for _, blocker := range blockers {
+		if blocker.Status != "completed" {
+			return ErrTaskBlocked
+		}
+	}
+
+	return nil
+}
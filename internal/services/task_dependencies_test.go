@@ -0,0 +1,84 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencyCycles(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	design, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Design"})
+	build, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Build"})
+	ship, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Ship"})
+
+	_, err := taskService.AddDependency(build.ID, userID, design.ID)
+	assert.NoError(t, err)
+	dependencies, err := taskService.AddDependency(ship.ID, userID, build.ID)
+	assert.NoError(t, err)
+	assert.Len(t, dependencies.BlockedBy, 1)
+
+	_, err = taskService.AddDependency(ship.ID, userID, build.ID)
+	assert.ErrorIs(t, err, services.ErrDependencyExists)
+
+	// Direct, transitive and self cycles are rejected
+	_, err = taskService.AddDependency(design.ID, userID, design.ID)
+	assert.ErrorIs(t, err, services.ErrDependencyCycle)
+	_, err = taskService.AddDependency(build.ID, userID, ship.ID)
+	assert.ErrorIs(t, err, services.ErrDependencyCycle)
+	_, err = taskService.AddDependency(design.ID, userID, ship.ID)
+	assert.ErrorIs(t, err, services.ErrDependencyCycle)
+
+	dependencies, err = taskService.GetDependencies(build.ID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, design.ID, dependencies.BlockedBy[0].ID)
+	assert.Equal(t, ship.ID, dependencies.Blocking[0].ID)
+
+	// Another user's task cannot be a blocker
+	foreign, _ := taskService.CreateTask(uuid.New(), services.TaskInput{Title: "Not mine"})
+	_, err = taskService.AddDependency(ship.ID, userID, foreign.ID)
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+
+	_, err = taskService.RemoveDependency(ship.ID, userID, design.ID)
+	assert.ErrorIs(t, err, services.ErrDependencyNotFound)
+}
+
+func TestBlockedTaskCannotStart(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	blocker, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Get approval"})
+	blocked, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Deploy"})
+	_, err := taskService.AddDependency(blocked.ID, userID, blocker.ID)
+	assert.NoError(t, err)
+
+	_, err = taskService.UpdateTask(blocked.ID, userID, services.TaskInput{Status: "in_progress", Priority: -1})
+	assert.ErrorIs(t, err, services.ErrTaskBlocked)
+	_, err = taskService.UpdateTask(blocked.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.ErrorIs(t, err, services.ErrTaskBlocked)
+
+	// Other fields can still be edited
+	_, err = taskService.UpdateTask(blocked.ID, userID, services.TaskInput{Title: "Deploy to production", Priority: -1})
+	assert.NoError(t, err)
+
+	isBlocked := true
+	tasks, err := taskService.GetTasks(repository.TaskFilter{UserID: userID, Priority: -1, Blocked: &isBlocked})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	_, err = taskService.UpdateTask(blocker.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+
+	tasks, err = taskService.GetTasks(repository.TaskFilter{UserID: userID, Priority: -1, Blocked: &isBlocked})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 0)
+
+	started, err := taskService.UpdateTask(blocked.ID, userID, services.TaskInput{Status: "in_progress", Priority: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, "in_progress", started.Status)
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+)
+
+// MaxTaskDepth is the maximum number of levels in a task hierarchy,
+// counting the top-level task
+const MaxTaskDepth = 5
+
+var (
+	// ErrParentNotFound is returned when a parent task does not exist or belongs to another user
+	ErrParentNotFound = errors.New("parent task not found")
+
+	// ErrTaskCycle is returned when a task would become its own ancestor
+	ErrTaskCycle = errors.New("a task cannot be moved under itself or one of its subtasks")
+
+	// ErrTaskTooDeep is returned when a move would exceed MaxTaskDepth
+	ErrTaskTooDeep = fmt.Errorf("subtasks cannot be nested more than %d levels deep", MaxTaskDepth)
+)
+
+// TaskTree is a task together with its nested subtasks
+type TaskTree struct {
+	models.Task
+	Subtasks []TaskTree `json:"subtasks"`
+
+	// Completion is the percentage of completed subtasks across the whole
+	// subtree; a task without subtasks is 0 or 100 depending on its status
+	Completion float64 `json:"completion"`
+}
+
+// GetTaskTree retrieves a task with its full subtree of subtasks
+func (s *TaskService) GetTaskTree(id uuid.UUID, userID uuid.UUID) (*TaskTree, error) {
+	task, err := s.GetTaskByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, _, _, err := s.buildTree(*task)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// buildTree loads the subtree below a task and returns it with the number of
+// descendants and how many of them are completed
+func (s *TaskService) buildTree(task models.Task) (*TaskTree, int, int, error) {
+	children, err := s.repos.Tasks.ListChildren(task.ID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	tree := &TaskTree{Task: task, Subtasks: []TaskTree{}}
+	total, completed := 0, 0
+
+	for _, child := range children {
+		subtree, childTotal, childCompleted, err := s.buildTree(child)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+
+		tree.Subtasks = append(tree.Subtasks, *subtree)
+		total += childTotal + 1
+		completed += childCompleted
+		if child.Status == "completed" {
+			completed++
+		}
+	}
+
+	switch {
+	case total > 0:
+		tree.Completion = float64(completed) * 100 / float64(total)
+	case task.Status == "completed":
+		tree.Completion = 100
+	}
+
+	return tree, total, completed, nil
+}
+
+// setParent moves a task under another task of its owner or workspace, or to
+// the top level when parentID is uuid.Nil. A nil parentID leaves the task as
+// is.
+func (s *TaskService) setParent(task *models.Task, parentID *uuid.UUID) error {
+	if parentID == nil {
+		return nil
+	}
+
+	if *parentID == uuid.Nil {
+		task.ParentID = nil
+		return nil
+	}
+
+	parent, err := s.repos.Tasks.FindByID(*parentID)
+	if err != nil || !sameScope(task, parent) {
+		if err == nil || errors.Is(err, repository.ErrNotFound) {
+			return ErrParentNotFound
+		}
+		return err
+	}
+
+	// Walk up from the new parent: the task must not be one of its ancestors
+	depth := 1
+	for ancestor := parent; ; depth++ {
+		if ancestor.ID == task.ID {
+			return ErrTaskCycle
+		}
+		if depth >= MaxTaskDepth {
+			return ErrTaskTooDeep
+		}
+		if ancestor.ParentID == nil {
+			break
+		}
+		if ancestor, err = s.repos.Tasks.FindByID(*ancestor.ParentID); err != nil {
+			return err
+		}
+	}
+
+	height, err := s.subtreeHeight(task.ID)
+	if err != nil {
+		return err
+	}
+
+	if depth+height > MaxTaskDepth {
+		return ErrTaskTooDeep
+	}
+
+	task.ParentID = &parent.ID
+	return nil
+}
+
+// subtreeHeight returns the number of levels in a task's subtree, including the task
+func (s *TaskService) subtreeHeight(id uuid.UUID) (int, error) {
+	children, err := s.repos.Tasks.ListChildren(id)
+	if err != nil {
+		return 0, err
+	}
+
+	height := 0
+	for _, child := range children {
+		childHeight, err := s.subtreeHeight(child.ID)
+		if err != nil {
+			return 0, err
+		}
+		if childHeight > height {
+			height = childHeight
+		}
+	}
+
+	return height + 1, nil
+}
+
+// rollUpStatus keeps a parent's status in line with its subtasks: it is
+// completed once all of them are, and reopened when one of them is reopened.
+// Parents with open blockers are not completed. Changes propagate up the
+// hierarchy.
+func (s *TaskService) rollUpStatus(parentID *uuid.UUID) error {
+	for parentID != nil {
+		parent, err := s.repos.Tasks.FindByID(*parentID)
+		if err != nil {
+			return err
+		}
+
+		children, err := s.repos.Tasks.ListChildren(parent.ID)
+		if err != nil {
+			return err
+		}
+
+		allCompleted := len(children) > 0
+		for _, child := range children {
+			if child.Status != "completed" {
+				allCompleted = false
+				break
+			}
+		}
+
+		status := parent.Status
+		switch {
+		case allCompleted:
+			status = "completed"
+		case len(children) > 0 && parent.Status == "completed":
+			status = "in_progress"
+		}
+
+		if status == parent.Status {
+			return nil
+		}
+
+		// A blocked parent is neither started nor completed by its subtasks:
+		// it stays open, and is reopened as pending rather than in progress
+		if err := s.checkNotBlocked(parent.ID); err != nil {
+			if !errors.Is(err, ErrTaskBlocked) {
+				return err
+			}
+			if status == "completed" {
+				return nil
+			}
+			status = "pending"
+		}
+
+		previousStatus := parent.Status
+		parent.Status = status
+		rule := handOffRecurrence(parent, previousStatus)
+		parent.UpdatedAt = time.Now()
+		if err := s.repos.Tasks.Update(parent); err != nil {
+			return err
+		}
+
+		// A recurring parent completed by its subtasks comes back like any
+		// completed task
+		if rule != "" {
+			if _, err := s.createNextOccurrence(parent, rule); err != nil {
+				return err
+			}
+		}
+
+		parentID = parent.ParentID
+	}
+
+	return nil
+}
+
+// deleteSubtree deletes every subtask below a task
+func (s *TaskService) deleteSubtree(id uuid.UUID) error {
+	children, err := s.repos.Tasks.ListChildren(id)
+	if err != nil {
+		return err
+	}
+
+	for i := range children {
+		if err := s.deleteSubtree(children[i].ID); err != nil {
+			return err
+		}
+		if err := s.repos.Tasks.Delete(&children[i]); err != nil {
+			return err
+		}
+		if err := s.cancelReminder(children[i].ID); err != nil {
+			return err
+		}
+		if err := s.cleanupAttachments(children[i].ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
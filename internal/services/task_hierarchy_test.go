@@ -0,0 +1,130 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubtaskCyclesAndDepth(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	root, err := taskService.CreateTask(userID, services.TaskInput{Title: "Level 1"})
+	assert.NoError(t, err)
+
+	// Build a chain of MaxTaskDepth levels
+	chain := []uuid.UUID{root.ID}
+	for i := 1; i < services.MaxTaskDepth; i++ {
+		child, err := taskService.CreateTask(userID, services.TaskInput{Title: "Nested", ParentID: &chain[i-1]})
+		assert.NoError(t, err)
+		chain = append(chain, child.ID)
+	}
+
+	_, err = taskService.CreateTask(userID, services.TaskInput{Title: "Too deep", ParentID: &chain[len(chain)-1]})
+	assert.ErrorIs(t, err, services.ErrTaskTooDeep)
+
+	// The root cannot move under its own descendant, nor under itself
+	_, err = taskService.UpdateTask(root.ID, userID, services.TaskInput{Priority: -1, ParentID: &chain[2]})
+	assert.ErrorIs(t, err, services.ErrTaskCycle)
+	_, err = taskService.UpdateTask(root.ID, userID, services.TaskInput{Priority: -1, ParentID: &root.ID})
+	assert.ErrorIs(t, err, services.ErrTaskCycle)
+
+	// Moving a subtree under another task counts the subtree's height
+	other, err := taskService.CreateTask(userID, services.TaskInput{Title: "Other"})
+	assert.NoError(t, err)
+	_, err = taskService.UpdateTask(root.ID, userID, services.TaskInput{Priority: -1, ParentID: &other.ID})
+	assert.ErrorIs(t, err, services.ErrTaskTooDeep)
+	moved, err := taskService.UpdateTask(chain[2], userID, services.TaskInput{Priority: -1, ParentID: &other.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, other.ID, *moved.ParentID)
+
+	// uuid.Nil moves a task back to the top level
+	moved, err = taskService.UpdateTask(chain[2], userID, services.TaskInput{Priority: -1, ParentID: &uuid.Nil})
+	assert.NoError(t, err)
+	assert.Nil(t, moved.ParentID)
+
+	// Another user's task cannot be a parent
+	foreign, _ := taskService.CreateTask(uuid.New(), services.TaskInput{Title: "Not mine"})
+	_, err = taskService.CreateTask(userID, services.TaskInput{Title: "Sneaky", ParentID: &foreign.ID})
+	assert.ErrorIs(t, err, services.ErrParentNotFound)
+}
+
+func TestSubtaskStatusRollUp(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	parent, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Release"})
+	first, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Write changelog", ParentID: &parent.ID})
+	second, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Tag version", ParentID: &parent.ID})
+	nested, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Push tag", ParentID: &second.ID})
+
+	_, err := taskService.UpdateTask(first.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+
+	tree, err := taskService.GetTaskTree(parent.ID, userID)
+	assert.NoError(t, err)
+	assert.Len(t, tree.Subtasks, 2)
+	assert.Len(t, tree.Subtasks[1].Subtasks, 1)
+	assert.InDelta(t, 100.0/3, tree.Completion, 0.01)
+	assert.Equal(t, "pending", tree.Status)
+
+	// Completing the last leaf completes every ancestor
+	_, err = taskService.UpdateTask(nested.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+
+	tree, err = taskService.GetTaskTree(parent.ID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "completed", tree.Status)
+	assert.Equal(t, "completed", tree.Subtasks[1].Status)
+	assert.Equal(t, 100.0, tree.Completion)
+
+	// Reopening a child reopens the parent
+	_, err = taskService.UpdateTask(first.ID, userID, services.TaskInput{Status: "pending", Priority: -1})
+	assert.NoError(t, err)
+	reopened, _ := taskService.GetTaskByID(parent.ID, userID)
+	assert.Equal(t, "in_progress", reopened.Status)
+
+	// Deleting a parent deletes its subtree
+	assert.NoError(t, taskService.DeleteTask(second.ID, userID))
+	_, err = taskService.GetTaskByID(nested.ID, userID)
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+}
+
+func TestSubtaskRollUpRespectsBlockers(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	blocker, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Security review"})
+	parent, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Release"})
+	child, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Write changelog", ParentID: &parent.ID})
+	_, err := taskService.AddDependency(parent.ID, userID, blocker.ID)
+	assert.NoError(t, err)
+
+	// Completing the only subtask does not complete a blocked parent
+	_, err = taskService.UpdateTask(child.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+	blocked, _ := taskService.GetTaskByID(parent.ID, userID)
+	assert.Equal(t, "pending", blocked.Status)
+
+	// Once unblocked, the roll-up completes it
+	_, err = taskService.UpdateTask(blocker.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+	_, err = taskService.UpdateTask(child.ID, userID, services.TaskInput{Status: "pending", Priority: -1})
+	assert.NoError(t, err)
+	_, err = taskService.UpdateTask(child.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+	completed, _ := taskService.GetTaskByID(parent.ID, userID)
+	assert.Equal(t, "completed", completed.Status)
+
+	// A completed parent whose blocker reopens is reopened as pending
+	_, err = taskService.UpdateTask(blocker.ID, userID, services.TaskInput{Status: "pending", Priority: -1})
+	assert.NoError(t, err)
+	_, err = taskService.UpdateTask(child.ID, userID, services.TaskInput{Status: "pending", Priority: -1})
+	assert.NoError(t, err)
+	reopened, _ := taskService.GetTaskByID(parent.ID, userID)
+	assert.Equal(t, "pending", reopened.Status)
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/services/recurrence"
+)
+
+// MaxPreviewOccurrences is the maximum number of occurrences returned by PreviewOccurrences
+const MaxPreviewOccurrences = 50
+
+// ErrTaskNotRecurring is returned when previewing occurrences of a task without a recurrence rule
+var ErrTaskNotRecurring = errors.New("task does not recur")
+
+// PreviewOccurrences returns the next count due dates of a recurring task
+func (s *TaskService) PreviewOccurrences(taskID, userID uuid.UUID, count int) ([]time.Time, error) {
+	task, err := s.GetTaskByID(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Recurrence == "" {
+		return nil, ErrTaskNotRecurring
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	if count < 1 || count > MaxPreviewOccurrences {
+		count = MaxPreviewOccurrences
+	}
+
+	from := time.Now()
+	if task.DueDate != nil {
+		from = *task.DueDate
+	}
+
+	return rule.Occurrences(from, count), nil
+}
+
+// setRecurrence validates and stores a task's recurrence rule in canonical
+// form; an empty rule stops the task from recurring. A nil value leaves the
+// task as is.
+func (s *TaskService) setRecurrence(task *models.Task, value *string) error {
+	if value == nil {
+		return nil
+	}
+
+	if *value == "" {
+		task.Recurrence = ""
+		return nil
+	}
+
+	rule, err := recurrence.Parse(*value)
+	if err != nil {
+		return err
+	}
+
+	task.Recurrence = rule.String()
+	return nil
+}
+
+// handOffRecurrence clears the rule of a task that is being completed and
+// returns it, so the caller creates the next occurrence once the task is
+// saved. Reopening and completing the task again does not repeat it twice.
+func handOffRecurrence(task *models.Task, previousStatus string) string {
+	if task.Status != "completed" || previousStatus == "completed" {
+		return ""
+	}
+
+	rule := task.Recurrence
+	task.Recurrence = ""
+	return rule
+}
+
+// nextOccurrence builds the task that follows a completed recurring task.
+// Its due date is the first occurrence after the completion time, so tasks
+// completed late do not generate occurrences that are already overdue.
+func (s *TaskService) nextOccurrence(task *models.Task, value string, completedAt time.Time) (*models.Task, error) {
+	rule, err := recurrence.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+
+	from := completedAt
+	if task.DueDate != nil && !rule.FromCompletion {
+		from = *task.DueDate
+	}
+
+	// Monthly rules keep their day in the next occurrence's rule, so a task
+	// due on the 31st comes back to it after February
+	rule.Anchor(from)
+
+	dueDate := rule.Next(from)
+	for !dueDate.After(completedAt) {
+		dueDate = rule.Next(dueDate)
+	}
+
+	return &models.Task{
+		Title:           task.Title,
+		Description:     task.Description,
+		Status:          "pending",
+		Priority:        task.Priority,
+		DueDate:         &dueDate,
+		ReminderMinutes: task.ReminderMinutes,
+		UserID:          task.UserID,
+		WorkspaceID:     task.WorkspaceID,
+		AssigneeID:      task.AssigneeID,
+		ProjectID:       task.ProjectID,
+		ParentID:        task.ParentID,
+		Recurrence:      rule.String(),
+		CreatedAt:       completedAt,
+		UpdatedAt:       completedAt,
+	}, nil
+}
+
+// createNextOccurrence creates the next occurrence of a completed task that
+// recurred following rule, with the same tags
+func (s *TaskService) createNextOccurrence(task *models.Task, rule string) (*models.Task, error) {
+	next, err := s.nextOccurrence(task, rule, task.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Tasks.Create(next); err != nil {
+		return nil, err
+	}
+
+	if err := s.syncReminder(next); err != nil {
+		return nil, err
+	}
+
+	for _, tag := range task.Tags {
+		if err := s.repos.Tags.Attach(next.ID, tag.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repos.Tasks.FindByID(next.ID)
+}
@@ -0,0 +1,146 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/jaimesHub/golang-todo-app/internal/services/recurrence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletingRecurringTaskCreatesNextOccurrence(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	taskService := services.NewTaskService(repos)
+	tagService := services.NewTagService(repos)
+	userID := uuid.New()
+
+	_, err := taskService.CreateTask(userID, services.TaskInput{Title: "Bad rule", Recurrence: stringPtr("FREQ=HOURLY")})
+	assert.ErrorIs(t, err, recurrence.ErrInvalidRule)
+
+	// Due tomorrow, every Monday and Thursday
+	dueDate := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	task, err := taskService.CreateTask(userID, services.TaskInput{
+		Title:      "Water plants",
+		DueDate:    &dueDate,
+		Recurrence: stringPtr("freq=weekly;byday=th,mo"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TH", task.Recurrence)
+
+	tag, _ := tagService.FindOrCreateTag(userID, "home")
+	_, err = taskService.AttachTag(task.ID, userID, tag.ID)
+	assert.NoError(t, err)
+
+	occurrences, err := taskService.PreviewOccurrences(task.ID, userID, 4)
+	assert.NoError(t, err)
+	assert.Len(t, occurrences, 4)
+	for _, occurrence := range occurrences {
+		assert.Contains(t, []time.Weekday{time.Monday, time.Thursday}, occurrence.Weekday())
+	}
+
+	completed, err := taskService.UpdateTask(task.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+	assert.Empty(t, completed.Recurrence)
+	assert.NotNil(t, completed.NextOccurrence)
+
+	next := completed.NextOccurrence
+	assert.Equal(t, "pending", next.Status)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TH", next.Recurrence)
+	assert.True(t, next.DueDate.Equal(occurrences[0]))
+	assert.Len(t, next.Tags, 1)
+
+	// Reopening and completing again does not create another occurrence
+	_, err = taskService.UpdateTask(task.ID, userID, services.TaskInput{Status: "pending", Priority: -1})
+	assert.NoError(t, err)
+	again, err := taskService.UpdateTask(task.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+	assert.Nil(t, again.NextOccurrence)
+
+	count, err := taskService.CountTasks(repository.TaskFilter{UserID: userID, Priority: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestOverdueRecurringTaskSkipsPastOccurrences(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	dueDate := time.Now().AddDate(0, 0, -10)
+	task, err := taskService.CreateTask(userID, services.TaskInput{Title: "Stand-up notes", DueDate: &dueDate, Recurrence: stringPtr("FREQ=DAILY")})
+	assert.NoError(t, err)
+
+	completed, err := taskService.UpdateTask(task.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+	assert.True(t, completed.NextOccurrence.DueDate.After(time.Now()))
+	assert.True(t, completed.NextOccurrence.DueDate.Before(time.Now().Add(25*time.Hour)))
+
+	_, err = taskService.PreviewOccurrences(completed.ID, userID, 3)
+	assert.ErrorIs(t, err, services.ErrTaskNotRecurring)
+}
+
+func TestMonthlyTaskKeepsDayOfMonth(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	// Due on January 31st of next year, every month
+	dueDate := time.Date(time.Now().Year()+1, time.January, 31, 9, 0, 0, 0, time.UTC)
+	task, err := taskService.CreateTask(userID, services.TaskInput{Title: "Pay rent", DueDate: &dueDate, Recurrence: stringPtr("FREQ=MONTHLY")})
+	assert.NoError(t, err)
+
+	expected := []time.Month{time.February, time.March, time.April, time.May}
+	days := []int{28, 31, 30, 31}
+	if time.Date(dueDate.Year(), time.February, 29, 0, 0, 0, 0, time.UTC).Day() == 29 {
+		days[0] = 29
+	}
+
+	for i, month := range expected {
+		completed, err := taskService.UpdateTask(task.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+		if !assert.NoError(t, err) || !assert.NotNil(t, completed.NextOccurrence) {
+			return
+		}
+
+		task = completed.NextOccurrence
+		assert.Equal(t, month, task.DueDate.Month())
+		assert.Equal(t, days[i], task.DueDate.Day())
+		assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=31", task.Recurrence)
+	}
+}
+
+func TestRecurringParentCompletedBySubtasks(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	dueDate := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	parent, err := taskService.CreateTask(userID, services.TaskInput{Title: "Weekly review", DueDate: &dueDate, Recurrence: stringPtr("FREQ=WEEKLY")})
+	assert.NoError(t, err)
+	child, err := taskService.CreateTask(userID, services.TaskInput{Title: "Clear inbox", ParentID: &parent.ID})
+	assert.NoError(t, err)
+
+	// Completing the only subtask completes the parent, which recurs
+	_, err = taskService.UpdateTask(child.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+
+	completed, err := taskService.GetTaskByID(parent.ID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "completed", completed.Status)
+	assert.Empty(t, completed.Recurrence)
+
+	tasks, err := taskService.GetTasks(repository.TaskFilter{UserID: userID, Priority: -1})
+	assert.NoError(t, err)
+	if assert.Len(t, tasks, 3) {
+		var next *models.Task
+		for i := range tasks {
+			if tasks[i].ID != parent.ID && tasks[i].ID != child.ID {
+				next = &tasks[i]
+			}
+		}
+		assert.Equal(t, "Weekly review", next.Title)
+		assert.Equal(t, "pending", next.Status)
+		assert.Equal(t, "FREQ=WEEKLY", next.Recurrence)
+		assert.True(t, next.DueDate.Equal(dueDate.AddDate(0, 0, 7)))
+	}
+}
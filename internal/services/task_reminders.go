@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+)
+
+// Scheduler schedules and cancels delayed jobs by ID; *queue.Queue implements it
+type Scheduler interface {
+	jobs.Scheduler
+	CancelScheduledTask(ctx context.Context, queueName, id string) (bool, error)
+}
+
+// SetScheduler enables due-date reminders, scheduled on the named queue.
+// Without a scheduler, reminder settings are stored but no reminders are sent.
+func (s *TaskService) SetScheduler(scheduler Scheduler, queueName string) {
+	s.scheduler = scheduler
+	s.queueName = queueName
+}
+
+// reminderJobID is the scheduled job ID of a task's reminder, so moving or
+// clearing the due date replaces or cancels the same job
+func reminderJobID(taskID uuid.UUID) string {
+	return jobs.TaskReminder.Type + ":" + taskID.String()
+}
+
+// setReminder stores a task's reminder offset; a negative value removes the
+// reminder and a nil value leaves the task as is
+func (s *TaskService) setReminder(task *models.Task, minutes *int) {
+	if minutes == nil {
+		return
+	}
+
+	if *minutes < 0 {
+		task.ReminderMinutes = nil
+		return
+	}
+
+	reminderMinutes := *minutes
+	task.ReminderMinutes = &reminderMinutes
+}
+
+// syncReminder schedules, moves or cancels the reminder job of a task to
+// match its due date, reminder offset and status
+func (s *TaskService) syncReminder(task *models.Task) error {
+	if s.scheduler == nil {
+		return nil
+	}
+
+	if task.DueDate == nil || task.ReminderMinutes == nil || task.Status == "completed" {
+		return s.cancelReminder(task.ID)
+	}
+
+	remindAt := task.DueDate.Add(-time.Duration(*task.ReminderMinutes) * time.Minute)
+	if !remindAt.After(time.Now()) {
+		return s.cancelReminder(task.ID)
+	}
+
+	if err := jobs.TaskReminder.Schedule(context.Background(), s.scheduler, s.queueName, reminderJobID(task.ID), jobs.TaskReminderPayload{
+		TaskID: task.ID,
+		UserID: task.UserID,
+	}, remindAt); err != nil {
+		return fmt.Errorf("failed to schedule reminder: %w", err)
+	}
+
+	return nil
+}
+
+// cancelReminder cancels the pending reminder of a task, if any
+func (s *TaskService) cancelReminder(taskID uuid.UUID) error {
+	if s.scheduler == nil {
+		return nil
+	}
+
+	if _, err := s.scheduler.CancelScheduledTask(context.Background(), s.queueName, reminderJobID(taskID)); err != nil {
+		return fmt.Errorf("failed to cancel reminder: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+	"github.com/jaimesHub/golang-todo-app/internal/services/notifier"
+	"github.com/jaimesHub/golang-todo-app/internal/services/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeScheduler keeps scheduled jobs in a map keyed by job ID
+type fakeScheduler struct {
+	jobs map[string]queue.Task
+	at   map[string]time.Time
+}
+
+func newFakeScheduler() *fakeScheduler {
+	return &fakeScheduler{jobs: make(map[string]queue.Task), at: make(map[string]time.Time)}
+}
+
+func (f *fakeScheduler) ScheduleTaskAt(ctx context.Context, queueName string, task *queue.Task, executeAt time.Time) error {
+	f.jobs[task.ID] = *task
+	f.at[task.ID] = executeAt
+	return nil
+}
+
+func (f *fakeScheduler) CancelScheduledTask(ctx context.Context, queueName, id string) (bool, error) {
+	_, exists := f.jobs[id]
+	delete(f.jobs, id)
+	delete(f.at, id)
+	return exists, nil
+}
+
+// fakeNotifier records delivered notifications
+type fakeNotifier struct {
+	sent []notifier.Notification
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, notification notifier.Notification) error {
+	f.sent = append(f.sent, notification)
+	return nil
+}
+
+func TestReminderJobsFollowDueDate(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+	scheduler := newFakeScheduler()
+	taskService.SetScheduler(scheduler, "tasks")
+	userID := uuid.New()
+
+	dueDate := time.Now().Add(48 * time.Hour)
+	reminder := 30
+	task, err := taskService.CreateTask(userID, services.TaskInput{Title: "File taxes", DueDate: &dueDate, ReminderMinutes: &reminder})
+	assert.NoError(t, err)
+
+	jobID := jobs.TaskReminder.Type + ":" + task.ID.String()
+	assert.Equal(t, jobs.TaskReminder.Type, scheduler.jobs[jobID].Type)
+	assert.True(t, scheduler.at[jobID].Equal(dueDate.Add(-30*time.Minute)))
+
+	// Moving the due date moves the same job
+	movedDate := dueDate.Add(24 * time.Hour)
+	_, err = taskService.UpdateTask(task.ID, userID, services.TaskInput{DueDate: &movedDate, Priority: -1})
+	assert.NoError(t, err)
+	assert.Len(t, scheduler.jobs, 1)
+	assert.True(t, scheduler.at[jobID].Equal(movedDate.Add(-30*time.Minute)))
+
+	// Removing the reminder cancels the job
+	noReminder := -1
+	_, err = taskService.UpdateTask(task.ID, userID, services.TaskInput{ReminderMinutes: &noReminder, Priority: -1})
+	assert.NoError(t, err)
+	assert.Empty(t, scheduler.jobs)
+
+	// Reminders that would already be due are not scheduled
+	soon := time.Now().Add(10 * time.Minute)
+	_, err = taskService.UpdateTask(task.ID, userID, services.TaskInput{DueDate: &soon, ReminderMinutes: &reminder, Priority: -1})
+	assert.NoError(t, err)
+	assert.Empty(t, scheduler.jobs)
+
+	_, err = taskService.UpdateTask(task.ID, userID, services.TaskInput{DueDate: &dueDate, Priority: -1})
+	assert.NoError(t, err)
+	assert.NoError(t, taskService.DeleteTask(task.ID, userID))
+	assert.Empty(t, scheduler.jobs)
+}
+
+func TestHandleReminder(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	taskService := services.NewTaskService(repos)
+	scheduler := newFakeScheduler()
+	taskService.SetScheduler(scheduler, "tasks")
+	sink := &fakeNotifier{}
+	reminderService := services.NewReminderService(repos, sink)
+
+	user, err := services.NewUserService(repos).CreateUser("remind@example.com", "password123", "Remind", "Me")
+	assert.NoError(t, err)
+
+	dueDate := time.Now().Add(time.Hour)
+	reminder := 15
+	task, err := taskService.CreateTask(user.ID, services.TaskInput{Title: "Call dentist", DueDate: &dueDate, ReminderMinutes: &reminder})
+	assert.NoError(t, err)
+
+	job := scheduler.jobs[jobs.TaskReminder.Type+":"+task.ID.String()]
+	assert.Equal(t, jobs.TaskReminder.Version, job.Version)
+	payload, err := jobs.TaskReminder.Decode(&job)
+	assert.NoError(t, err)
+	assert.NoError(t, reminderService.HandleReminder(context.Background(), payload))
+	assert.Len(t, sink.sent, 1)
+	assert.Equal(t, "remind@example.com", sink.sent[0].Email)
+	assert.Equal(t, "Reminder: Call dentist", sink.sent[0].Subject)
+
+	// Completed tasks are not reminded about
+	_, err = taskService.UpdateTask(task.ID, user.ID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+	assert.NoError(t, reminderService.HandleReminder(context.Background(), payload))
+	assert.Len(t, sink.sent, 1)
+
+	// Reminders for deleted recipients are dropped rather than retried
+	orphan := &models.Task{Title: "Orphan", Status: "pending", DueDate: &dueDate, ReminderMinutes: &reminder, UserID: uuid.New()}
+	assert.NoError(t, repos.Tasks.Create(orphan))
+	assert.NoError(t, reminderService.HandleReminder(context.Background(), jobs.TaskReminderPayload{TaskID: orphan.ID}))
+	assert.Len(t, sink.sent, 1)
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+)
+
+// ErrEmptySearch is returned for search queries without any word
+var ErrEmptySearch = errors.New("search query must contain a word")
+
+// SearchTasks finds the tasks matching the filter whose title or description
+// contains every word of filter.Search, in full or as a prefix, best match
+// first
+func (s *TaskService) SearchTasks(filter repository.TaskFilter) ([]repository.TaskMatch, error) {
+	if len(repository.SearchTerms(filter.Search)) == 0 {
+		return nil, ErrEmptySearch
+	}
+	return s.repos.Tasks.Search(filter)
+}
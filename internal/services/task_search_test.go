@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTasks(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	inTitle, err := taskService.CreateTask(userID, services.TaskInput{Title: "Fix login page", Description: "Users see a blank page", Priority: 1})
+	assert.NoError(t, err)
+	inDescription, err := taskService.CreateTask(userID, services.TaskInput{Title: "Release 2.0", Description: "Wait for the login fixes", Priority: 3})
+	assert.NoError(t, err)
+	_, err = taskService.CreateTask(userID, services.TaskInput{Title: "Buy groceries", Priority: 3})
+	assert.NoError(t, err)
+	_, err = taskService.CreateTask(uuid.New(), services.TaskInput{Title: "Fix login for someone else"})
+	assert.NoError(t, err)
+
+	search := func(query, status string) []repository.TaskMatch {
+		matches, err := taskService.SearchTasks(repository.TaskFilter{UserID: userID, Priority: -1, Status: status, Search: query})
+		assert.NoError(t, err)
+		return matches
+	}
+
+	// Words match as prefixes, and title matches rank first
+	matches := search("LOG fix", "")
+	if assert.Len(t, matches, 2) {
+		assert.Equal(t, inTitle.ID, matches[0].Task.ID)
+		assert.Equal(t, "<mark>Fix</mark> <mark>login</mark> page", matches[0].TitleHighlight)
+		assert.Empty(t, matches[0].Snippet)
+		assert.Equal(t, inDescription.ID, matches[1].Task.ID)
+		assert.Equal(t, "Wait for the <mark>login</mark> <mark>fixes</mark>", matches[1].Snippet)
+		assert.Greater(t, matches[0].Rank, matches[1].Rank)
+	}
+
+	// Every word must match
+	assert.Empty(t, search("login groceries", ""))
+
+	// Search combines with the other filters
+	_, err = taskService.UpdateTask(inTitle.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+	matches = search("login", "completed")
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, inTitle.ID, matches[0].Task.ID)
+	}
+	count, err := taskService.CountTasks(repository.TaskFilter{UserID: userID, Priority: -1, Status: "pending", Search: "login"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = taskService.SearchTasks(repository.TaskFilter{UserID: userID, Priority: -1, Search: " ?! "})
+	assert.ErrorIs(t, err, services.ErrEmptySearch)
+}
+
+func TestSearchSnippet(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+	userID := uuid.New()
+
+	description := strings.Repeat("filler ", 40) + "the deadline moved " + strings.Repeat("padding ", 40)
+	_, err := taskService.CreateTask(userID, services.TaskInput{Title: "Long notes", Description: description})
+	assert.NoError(t, err)
+
+	matches, err := taskService.SearchTasks(repository.TaskFilter{UserID: userID, Priority: -1, Search: "deadl"})
+	assert.NoError(t, err)
+	if assert.Len(t, matches, 1) {
+		snippet := matches[0].Snippet
+		assert.Contains(t, snippet, "the <mark>deadline</mark> moved")
+		assert.True(t, strings.HasPrefix(snippet, "... filler"))
+		assert.True(t, strings.HasSuffix(snippet, "padding ..."))
+		assert.Len(t, strings.Fields(snippet), 32)
+	}
+}
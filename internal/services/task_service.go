@@ -6,153 +6,448 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jaimesHub/golang-todo-app/internal/models"
-	"gorm.io/gorm"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+)
+
+var (
+	// ErrTaskNotFound is returned when a task does not exist or belongs to another user
+	ErrTaskNotFound = errors.New("task not found")
+
+	// ErrProjectNotFound is returned when a project does not exist or belongs to another user
+	ErrProjectNotFound = errors.New("project not found")
+
+	// ErrProjectArchived is returned when adding a task to an archived project
+	ErrProjectArchived = errors.New("project is archived")
+
+	// ErrTagNotFound is returned when a tag does not exist or belongs to another user
+	ErrTagNotFound = errors.New("tag not found")
+
+	// ErrProjectInWorkspace is returned when putting a workspace task into a
+	// personal project
+	ErrProjectInWorkspace = errors.New("workspace tasks cannot belong to a project")
+
+	// ErrInvalidAssignee is returned when assigning a task to someone who
+	// cannot work on it: another user for personal tasks, or a viewer or
+	// non-member for workspace tasks
+	ErrInvalidAssignee = errors.New("invalid assignee")
 )
 
 // TaskService handles task-related business logic
 type TaskService struct {
-	db *gorm.DB
+	repos     *repository.Repositories
+	scheduler Scheduler
+	queue     jobs.Enqueuer
+	queueName string
 }
 
 // NewTaskService creates a new task service
-func NewTaskService(db *gorm.DB) *TaskService {
-	return &TaskService{db: db}
+func NewTaskService(repos *repository.Repositories) *TaskService {
+	return &TaskService{repos: repos}
+}
+
+// TaskInput holds the user-editable fields of a task. When updating, empty
+// values leave the current value unchanged.
+type TaskInput struct {
+	Title       string
+	Description string
+	Status      string
+	Priority    int // values below 0 leave the priority unchanged on update
+	DueDate     *time.Time
+
+	// ReminderMinutes sends a reminder this many minutes before the due date;
+	// a negative value removes the reminder
+	ReminderMinutes *int
+
+	// ProjectID moves the task into a project; uuid.Nil removes it from its project
+	ProjectID *uuid.UUID
+
+	// ParentID makes the task a subtask of another task; uuid.Nil makes it a top-level task
+	ParentID *uuid.UUID
+
+	// Recurrence sets an RRULE-style recurrence rule; an empty rule stops the task from recurring
+	Recurrence *string
+
+	// WorkspaceID creates the task in a workspace instead of as a personal
+	// task; it is ignored on update
+	WorkspaceID *uuid.UUID
+
+	// AssigneeID assigns the task to a user; uuid.Nil unassigns it
+	AssigneeID *uuid.UUID
 }
 
 // CreateTask creates a new task
-func (s *TaskService) CreateTask(userID uuid.UUID, title, description string, priority int, dueDate *time.Time) (*models.Task, error) {
+func (s *TaskService) CreateTask(userID uuid.UUID, input TaskInput) (*models.Task, error) {
 	// Create task
 	task := &models.Task{
-		Title:       title,
-		Description: description,
+		Title:       input.Title,
+		Description: input.Description,
 		Status:      "pending",
-		Priority:    priority,
-		DueDate:     dueDate,
+		Priority:    input.Priority,
+		DueDate:     input.DueDate,
 		UserID:      userID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	if err := s.db.Create(task).Error; err != nil {
+	if input.WorkspaceID != nil {
+		role, err := memberRole(s.repos, *input.WorkspaceID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if role == "" {
+			return nil, ErrWorkspaceNotFound
+		}
+		if !canEditTasks(role) {
+			return nil, ErrWorkspaceForbidden
+		}
+		task.WorkspaceID = input.WorkspaceID
+	}
+
+	if err := s.setAssignee(task, input.AssigneeID); err != nil {
 		return nil, err
 	}
 
-	return task, nil
-}
+	s.setReminder(task, input.ReminderMinutes)
 
-// GetTaskByID retrieves a task by ID
-func (s *TaskService) GetTaskByID(id uuid.UUID, userID uuid.UUID) (*models.Task, error) {
-	var task models.Task
-	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&task).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("task not found")
-		}
+	if err := s.setProject(task, input.ProjectID); err != nil {
 		return nil, err
 	}
-	return &task, nil
-}
 
-// GetTasks retrieves tasks for a user with pagination and filtering
-func (s *TaskService) GetTasks(userID uuid.UUID, status string, priority int, limit, offset int) ([]models.Task, error) {
-	var tasks []models.Task
+	if err := s.setParent(task, input.ParentID); err != nil {
+		return nil, err
+	}
 
-	query := s.db.Where("user_id = ?", userID)
+	if err := s.setRecurrence(task, input.Recurrence); err != nil {
+		return nil, err
+	}
 
-	// Apply filters if provided
-	if status != "" {
-		query = query.Where("status = ?", status)
+	if err := s.repos.Tasks.Create(task); err != nil {
+		return nil, err
 	}
 
-	if priority >= 0 {
-		query = query.Where("priority = ?", priority)
+	if err := s.syncReminder(task); err != nil {
+		return nil, err
 	}
 
-	// Apply pagination
-	if limit > 0 {
-		query = query.Limit(limit)
+	// A new open subtask reopens a completed parent
+	if err := s.rollUpStatus(task.ParentID); err != nil {
+		return nil, err
 	}
 
-	if offset > 0 {
-		query = query.Offset(offset)
+	return task, nil
+}
+
+// GetTaskByID retrieves a task the user can read: one of their personal
+// tasks, a task shared with them or a task of a workspace they are a member of
+func (s *TaskService) GetTaskByID(id uuid.UUID, userID uuid.UUID) (*models.Task, error) {
+	return s.findTask(id, userID, false)
+}
+
+// getTaskForEdit retrieves a task the user can change. Workspace viewers
+// get ErrWorkspaceForbidden, users with a read-only share ErrShareReadOnly.
+func (s *TaskService) getTaskForEdit(id uuid.UUID, userID uuid.UUID) (*models.Task, error) {
+	return s.findTask(id, userID, true)
+}
+
+// findTask retrieves a task after checking the user's access to it
+func (s *TaskService) findTask(id uuid.UUID, userID uuid.UUID, edit bool) (*models.Task, error) {
+	task, err := s.repos.Tasks.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
 	}
 
-	// Order by priority (high to low) and created_at (newest first)
-	query = query.Order("priority DESC, created_at DESC")
+	if task.WorkspaceID == nil {
+		if task.UserID == userID {
+			return task, nil
+		}
+
+		permission, err := sharePermission(s.repos, task, userID)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case permission == "":
+			return nil, ErrTaskNotFound
+		case edit && permission != models.SharePermissionEdit:
+			return nil, ErrShareReadOnly
+		}
+		return task, nil
+	}
 
-	if err := query.Find(&tasks).Error; err != nil {
+	role, err := memberRole(s.repos, *task.WorkspaceID, userID)
+	if err != nil {
 		return nil, err
 	}
+	if role == "" {
+		return nil, ErrTaskNotFound
+	}
+	if edit && !canEditTasks(role) {
+		return nil, ErrWorkspaceForbidden
+	}
 
-	return tasks, nil
+	return task, nil
+}
+
+// GetTasks retrieves a user's tasks with pagination and filtering
+func (s *TaskService) GetTasks(filter repository.TaskFilter) ([]models.Task, error) {
+	return s.repos.Tasks.List(filter)
 }
 
 // UpdateTask updates a task
-func (s *TaskService) UpdateTask(id uuid.UUID, userID uuid.UUID, title, description, status string, priority int, dueDate *time.Time) (*models.Task, error) {
+func (s *TaskService) UpdateTask(id uuid.UUID, userID uuid.UUID, input TaskInput) (*models.Task, error) {
 	// Get task
-	task, err := s.GetTaskByID(id, userID)
+	task, err := s.getTaskForEdit(id, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Moving a shared task would change who it is shared with
+	if input.ProjectID != nil || input.ParentID != nil {
+		if err := checkOwner(task, userID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update fields
-	if title != "" {
-		task.Title = title
+	if input.Title != "" {
+		task.Title = input.Title
+	}
+
+	if input.Description != "" {
+		task.Description = input.Description
+	}
+
+	previousStatus := task.Status
+	if input.Status != "" {
+		// A blocked task cannot be started or completed until its blockers are done
+		if input.Status != task.Status && (input.Status == "in_progress" || input.Status == "completed") {
+			if err := s.checkNotBlocked(task.ID); err != nil {
+				return nil, err
+			}
+		}
+		task.Status = input.Status
+	}
+
+	if input.Priority >= 0 {
+		task.Priority = input.Priority
 	}
 
-	if description != "" {
-		task.Description = description
+	if input.DueDate != nil {
+		task.DueDate = input.DueDate
 	}
 
-	if status != "" {
-		task.Status = status
+	s.setReminder(task, input.ReminderMinutes)
+
+	if err := s.setAssignee(task, input.AssigneeID); err != nil {
+		return nil, err
 	}
 
-	if priority >= 0 {
-		task.Priority = priority
+	if err := s.setProject(task, input.ProjectID); err != nil {
+		return nil, err
 	}
 
-	if dueDate != nil {
-		task.DueDate = dueDate
+	previousParentID := task.ParentID
+	if err := s.setParent(task, input.ParentID); err != nil {
+		return nil, err
 	}
 
+	if err := s.setRecurrence(task, input.Recurrence); err != nil {
+		return nil, err
+	}
+
+	// Completing a recurring task hands its rule over to the next occurrence
+	rule := handOffRecurrence(task, previousStatus)
+
 	task.UpdatedAt = time.Now()
 
-	if err := s.db.Save(task).Error; err != nil {
+	if err := s.repos.Tasks.Update(task); err != nil {
+		return nil, err
+	}
+
+	if err := s.syncReminder(task); err != nil {
+		return nil, err
+	}
+
+	if rule != "" {
+		if task.NextOccurrence, err = s.createNextOccurrence(task, rule); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keep the status of the old and new parent in line with their subtasks
+	if err := s.rollUpStatus(task.ParentID); err != nil {
 		return nil, err
 	}
+	if previousParentID != nil && (task.ParentID == nil || *task.ParentID != *previousParentID) {
+		if err := s.rollUpStatus(previousParentID); err != nil {
+			return nil, err
+		}
+	}
 
 	return task, nil
 }
 
 // DeleteTask deletes a task
 func (s *TaskService) DeleteTask(id uuid.UUID, userID uuid.UUID) error {
-	// Check if task exists and belongs to user
-	task, err := s.GetTaskByID(id, userID)
+	// Check if task exists and the user may delete it
+	task, err := s.getTaskForEdit(id, userID)
 	if err != nil {
 		return err
 	}
+	if err := checkOwner(task, userID); err != nil {
+		return err
+	}
+
+	// Delete the task with its subtasks (soft delete with GORM)
+	if err := s.deleteSubtree(task.ID); err != nil {
+		return err
+	}
 
-	// Delete task (soft delete with GORM)
-	if err := s.db.Delete(task).Error; err != nil {
+	if err := s.repos.Tasks.Delete(task); err != nil {
 		return err
 	}
 
+	if err := s.cancelReminder(task.ID); err != nil {
+		return err
+	}
+	if err := s.cleanupAttachments(task.ID); err != nil {
+		return err
+	}
+
+	// The remaining siblings may now all be completed
+	return s.rollUpStatus(task.ParentID)
+}
+
+// CountTasks counts a user's tasks matching a filter, ignoring pagination
+func (s *TaskService) CountTasks(filter repository.TaskFilter) (int64, error) {
+	return s.repos.Tasks.Count(filter)
+}
+
+// AttachTag attaches one of the user's tags to a task they can change
+func (s *TaskService) AttachTag(taskID, userID, tagID uuid.UUID) (*models.Task, error) {
+	task, err := s.getTaskForEdit(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOwner(task, userID); err != nil {
+		return nil, err
+	}
+
+	tag, err := s.repos.Tags.FindByID(tagID)
+	if err != nil || tag.UserID != userID {
+		if err == nil || errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.repos.Tags.Attach(taskID, tagID); err != nil {
+		return nil, err
+	}
+
+	return s.GetTaskByID(taskID, userID)
+}
+
+// DetachTag removes a tag from a task the user can change
+func (s *TaskService) DetachTag(taskID, userID, tagID uuid.UUID) (*models.Task, error) {
+	task, err := s.getTaskForEdit(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOwner(task, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Tags.Detach(taskID, tagID); err != nil {
+		return nil, err
+	}
+
+	return s.GetTaskByID(taskID, userID)
+}
+
+// setProject moves a task into one of its owner's projects, or out of its
+// project when projectID is uuid.Nil. A nil projectID leaves the task as is.
+// Projects are personal, so workspace tasks cannot join one.
+func (s *TaskService) setProject(task *models.Task, projectID *uuid.UUID) error {
+	if projectID == nil {
+		return nil
+	}
+
+	if *projectID == uuid.Nil {
+		task.ProjectID = nil
+		return nil
+	}
+
+	if task.WorkspaceID != nil {
+		return ErrProjectInWorkspace
+	}
+
+	project, err := s.repos.Projects.FindByID(*projectID)
+	if err != nil || project.UserID != task.UserID {
+		if err == nil || errors.Is(err, repository.ErrNotFound) {
+			return ErrProjectNotFound
+		}
+		return err
+	}
+
+	if project.Archived {
+		return ErrProjectArchived
+	}
+
+	task.ProjectID = &project.ID
 	return nil
 }
 
-// CountTasks counts tasks for a user with optional status filter
-func (s *TaskService) CountTasks(userID uuid.UUID, status string) (int64, error) {
-	var count int64
+// setAssignee assigns a task, or unassigns it when assigneeID is uuid.Nil. A
+// nil assigneeID leaves the task as is. Personal tasks can only be assigned
+// to their owner, workspace tasks to members who can edit tasks.
+func (s *TaskService) setAssignee(task *models.Task, assigneeID *uuid.UUID) error {
+	if assigneeID == nil {
+		return nil
+	}
 
-	query := s.db.Model(&models.Task{}).Where("user_id = ?", userID)
+	if *assigneeID == uuid.Nil {
+		task.AssigneeID = nil
+		return nil
+	}
 
-	if status != "" {
-		query = query.Where("status = ?", status)
+	if task.WorkspaceID == nil {
+		if *assigneeID != task.UserID {
+			return ErrInvalidAssignee
+		}
+	} else {
+		role, err := memberRole(s.repos, *task.WorkspaceID, *assigneeID)
+		if err != nil {
+			return err
+		}
+		if !canEditTasks(role) {
+			return ErrInvalidAssignee
+		}
 	}
 
-	if err := query.Count(&count).Error; err != nil {
-		return 0, err
+	id := *assigneeID
+	task.AssigneeID = &id
+	return nil
+}
+
+// sameScope reports whether two tasks are both personal tasks of the same
+// user or both tasks of the same workspace
+func sameScope(task, other *models.Task) bool {
+	if task.WorkspaceID == nil || other.WorkspaceID == nil {
+		return task.WorkspaceID == nil && other.WorkspaceID == nil && task.UserID == other.UserID
 	}
+	return *task.WorkspaceID == *other.WorkspaceID
+}
 
-	return count, nil
+// checkOwner rejects actions reserved to the owner of a personal task when
+// the task is only shared with the user
+func checkOwner(task *models.Task, userID uuid.UUID) error {
+	if task.WorkspaceID == nil && task.UserID != userID {
+		return ErrShareOwnerOnly
+	}
+	return nil
 }
@@ -5,53 +5,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
 	"github.com/jaimesHub/golang-todo-app/internal/services"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 )
 
-// MockDB is a mock implementation of the database interface
-type MockDB struct {
-	mock.Mock
-}
-
-// Create mocks the Create method
-func (m *MockDB) Create(value interface{}) error {
-	args := m.Called(value)
-	return args.Error(0)
-}
-
-// First mocks the First method
-func (m *MockDB) First(dest interface{}, conds ...interface{}) error {
-	args := m.Called(dest, conds)
-	return args.Error(0)
-}
-
-// Where mocks the Where method
-func (m *MockDB) Where(query interface{}, args ...interface{}) *MockDB {
-	m.Called(query, args)
-	return m
-}
-
-// Save mocks the Save method
-func (m *MockDB) Save(value interface{}) error {
-	args := m.Called(value)
-	return args.Error(0)
-}
-
-// Delete mocks the Delete method
-func (m *MockDB) Delete(value interface{}) error {
-	args := m.Called(value)
-	return args.Error(0)
-}
-
 func TestCreateTask(t *testing.T) {
-	// Create a mock DB
-	mockDB := new(MockDB)
-
-	// Create a task service with the mock DB
-	taskService := services.NewTaskService(mockDB)
+	// Create a task service backed by the in-memory repositories
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
 
 	// Set up test data
 	userID := uuid.New()
@@ -60,54 +21,30 @@ func TestCreateTask(t *testing.T) {
 	priority := 1
 	dueDate := time.Now().Add(24 * time.Hour)
 
-	// Set up expectations
-	mockDB.On("Create", mock.AnythingOfType("*models.Task")).Return(nil)
-
 	// Call the method being tested
-	task, err := taskService.CreateTask(userID, title, description, priority, &dueDate)
+	task, err := taskService.CreateTask(userID, services.TaskInput{Title: title, Description: description, Priority: priority, DueDate: &dueDate})
 
 	// Assert expectations
 	assert.NoError(t, err)
 	assert.NotNil(t, task)
+	assert.NotEqual(t, uuid.Nil, task.ID)
 	assert.Equal(t, title, task.Title)
 	assert.Equal(t, description, task.Description)
 	assert.Equal(t, "pending", task.Status)
 	assert.Equal(t, priority, task.Priority)
 	assert.Equal(t, userID, task.UserID)
-	mockDB.AssertExpectations(t)
 }
 
 func TestGetTaskByID(t *testing.T) {
-	// Create a mock DB
-	mockDB := new(MockDB)
-
-	// Create a task service with the mock DB
-	taskService := services.NewTaskService(mockDB)
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
 
 	// Set up test data
-	taskID := uuid.New()
 	userID := uuid.New()
-
-	// Create a task to return
-	expectedTask := &models.Task{
-		ID:          taskID,
-		Title:       "Test Task",
-		Description: "This is a test task",
-		Status:      "pending",
-		Priority:    1,
-		UserID:      userID,
-	}
-
-	// Set up expectations
-	mockDB.On("Where", "id = ? AND user_id = ?", []interface{}{taskID, userID}).Return(mockDB)
-	mockDB.On("First", mock.AnythingOfType("*models.Task"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
-		// Copy the expected task to the destination
-		task := args.Get(0).(*models.Task)
-		*task = *expectedTask
-	})
+	expectedTask, err := taskService.CreateTask(userID, services.TaskInput{Title: "Test Task", Description: "This is a test task", Priority: 1})
+	assert.NoError(t, err)
 
 	// Call the method being tested
-	task, err := taskService.GetTaskByID(taskID, userID)
+	task, err := taskService.GetTaskByID(expectedTask.ID, userID)
 
 	// Assert expectations
 	assert.NoError(t, err)
@@ -118,5 +55,48 @@ func TestGetTaskByID(t *testing.T) {
 	assert.Equal(t, expectedTask.Status, task.Status)
 	assert.Equal(t, expectedTask.Priority, task.Priority)
 	assert.Equal(t, expectedTask.UserID, task.UserID)
-	mockDB.AssertExpectations(t)
+
+	// Another user must not see the task
+	_, err = taskService.GetTaskByID(expectedTask.ID, uuid.New())
+	assert.EqualError(t, err, "task not found")
+}
+
+func TestGetTasksFiltersAndPagination(t *testing.T) {
+	taskService := services.NewTaskService(repository.NewMemoryRepositories())
+
+	userID := uuid.New()
+	low, _ := taskService.CreateTask(userID, services.TaskInput{Title: "Low"})
+	high, _ := taskService.CreateTask(userID, services.TaskInput{Title: "High", Priority: 2})
+	_, _ = taskService.CreateTask(uuid.New(), services.TaskInput{Title: "Someone else's", Priority: 2})
+
+	_, err := taskService.UpdateTask(low.ID, userID, services.TaskInput{Status: "completed", Priority: -1})
+	assert.NoError(t, err)
+
+	// Ordered by priority, scoped to the user
+	tasks, err := taskService.GetTasks(repository.TaskFilter{UserID: userID, Priority: -1, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, high.ID, tasks[0].ID)
+
+	// Status filter
+	tasks, err = taskService.GetTasks(repository.TaskFilter{UserID: userID, Status: "completed", Priority: -1, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, low.ID, tasks[0].ID)
+
+	// Pagination
+	tasks, err = taskService.GetTasks(repository.TaskFilter{UserID: userID, Priority: -1, Limit: 1, Offset: 1})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, low.ID, tasks[0].ID)
+
+	count, err := taskService.CountTasks(repository.TaskFilter{UserID: userID, Priority: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// Delete
+	assert.NoError(t, taskService.DeleteTask(high.ID, userID))
+	count, err = taskService.CountTasks(repository.TaskFilter{UserID: userID, Priority: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
 }
@@ -0,0 +1,65 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaimesHub/golang-todo-app/internal/services/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA-1 test key of RFC 6238
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// RFC 6238 test vectors, truncated to six digits
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range vectors {
+		code, err := totp.Code(rfcSecret, totp.Counter(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, "time %d", unix)
+	}
+
+	_, err := totp.Code("not base32!", 1)
+	assert.ErrorIs(t, err, totp.ErrInvalidSecret)
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	now := time.Now()
+	code, _ := totp.Code(secret, totp.Counter(now))
+	counter, ok := totp.Validate(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Counter(now), counter)
+
+	// The previous code is still accepted, older ones are not
+	previous, _ := totp.Code(secret, totp.Counter(now)-1)
+	_, ok = totp.Validate(secret, previous[:3]+" "+previous[3:], now)
+	assert.True(t, ok)
+	old, _ := totp.Code(secret, totp.Counter(now)-2)
+	_, ok = totp.Validate(secret, old, now)
+	assert.False(t, ok)
+
+	_, ok = totp.Validate(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("JBSWY3DPEHPK3PXP", "Todo App", "user@example.com")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Todo%20App:user@example.com?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=Todo+App")
+}
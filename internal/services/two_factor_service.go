@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RecoveryCodeCount is how many recovery codes are issued at a time
+const RecoveryCodeCount = 10
+
+var (
+	// ErrTwoFactorEnabled is returned when enrolling while two-factor
+	// authentication is already enabled
+	ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+
+	// ErrTwoFactorNotEnrolled is returned when confirming without a pending enrollment
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication enrollment not started")
+
+	// ErrTwoFactorNotEnabled is returned when two-factor authentication is required but off
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+
+	// ErrInvalidTwoFactorCode is returned for a wrong, reused or expired code
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+	// ErrInvalidChallenge is returned for unknown, expired or used login challenges
+	ErrInvalidChallenge = errors.New("invalid or expired login challenge")
+)
+
+// TwoFactorEnrollment is the secret an authenticator app is set up with
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// LoginChallenge is handed out instead of a session when the password of an
+// account with two-factor authentication was correct
+type LoginChallenge struct {
+	Token     string `json:"challenge_token"`
+	ExpiresIn int64  `json:"expires_in"` // seconds until the challenge expires
+}
+
+// TwoFactorService handles TOTP enrollment, recovery codes and the second
+// step of logging in
+type TwoFactorService struct {
+	repos *repository.Repositories
+	auth  *AuthService
+	cfg   config.TwoFactorConfig
+}
+
+// NewTwoFactorService creates a new two-factor authentication service
+func NewTwoFactorService(repos *repository.Repositories, authService *AuthService, cfg config.TwoFactorConfig) *TwoFactorService {
+	return &TwoFactorService{repos: repos, auth: authService, cfg: cfg}
+}
+
+// Enroll generates a new TOTP secret for a user after checking their
+// password. It takes effect once confirmed with a code; enrolling again
+// before that replaces the secret.
+func (s *TwoFactorService) Enroll(userID uuid.UUID, password string) (*TwoFactorEnrollment, error) {
+	user, err := s.authenticate(userID, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = &secret
+	user.TOTPLastCounter = 0
+	user.UpdatedAt = time.Now()
+	if err := s.repos.Users.Update(user); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(secret, s.cfg.Issuer, user.Email),
+	}, nil
+}
+
+// Confirm enables two-factor authentication with the first code of the
+// enrolled secret and returns the user's recovery codes. They are only
+// stored hashed, so this is the one time they can be shown.
+func (s *TwoFactorService) Confirm(userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	counter, ok := totp.Validate(*user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	user.TOTPLastCounter = counter
+	user.UpdatedAt = now
+	if err := s.repos.Users.Update(user); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(user.ID)
+}
+
+// Disable turns two-factor authentication off after checking the user's
+// password and a current TOTP or recovery code
+func (s *TwoFactorService) Disable(userID uuid.UUID, password, code string) error {
+	user, err := s.authenticateWithCode(userID, password, code)
+	if err != nil {
+		return err
+	}
+
+	user.TOTPSecret = nil
+	user.TOTPEnabledAt = nil
+	user.TOTPLastCounter = 0
+	user.UpdatedAt = time.Now()
+	if err := s.repos.Users.Update(user); err != nil {
+		return err
+	}
+
+	return s.repos.Recovery.DeleteUser(user.ID)
+}
+
+// RegenerateRecoveryCodes replaces a user's recovery codes after checking
+// their password and a current TOTP or recovery code
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uuid.UUID, password, code string) ([]string, error) {
+	user, err := s.authenticateWithCode(userID, password, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(user.ID)
+}
+
+// RemainingRecoveryCodes counts the recovery codes a user has not used yet
+func (s *TwoFactorService) RemainingRecoveryCodes(userID uuid.UUID) (int64, error) {
+	return s.repos.Recovery.CountUnused(userID)
+}
+
+// Challenge starts the second login step for a user whose password matched
+func (s *TwoFactorService) Challenge(user *models.User) (*LoginChallenge, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.UserTokens.Create(&models.UserToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposeLoginChallenge,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.cfg.ChallengeTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &LoginChallenge{
+		Token:     token,
+		ExpiresIn: int64(s.cfg.ChallengeTTL.Seconds()),
+	}, nil
+}
+
+// CompleteLogin redeems a login challenge with a TOTP or recovery code and
+// returns the user who may now be issued a session. Wrong codes count as
+// failed logins for the user's email and the client's IP address.
+func (s *TwoFactorService) CompleteLogin(ctx context.Context, challengeToken, code, ip string) (*models.User, error) {
+	challenge, err := s.repos.UserTokens.FindByHash(hashToken(challengeToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidChallenge
+		}
+		return nil, err
+	}
+
+	if challenge.Purpose != models.TokenPurposeLoginChallenge || challenge.UsedAt != nil || !time.Now().Before(challenge.ExpiresAt) {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := s.repos.Users.FindByID(challenge.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidChallenge
+		}
+		return nil, err
+	}
+
+	limiter := s.auth.limiter
+	if limiter != nil {
+		wait, err := limiter.Check(ctx, user.Email, ip)
+		if err != nil {
+			return nil, err
+		}
+		if wait > 0 {
+			return nil, &LoginThrottledError{RetryAfter: wait}
+		}
+	}
+
+	if err := s.verifyCode(user, code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			if err := s.auth.loginFailed(ctx, user, user.Email, ip); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	// A concurrent request may have redeemed the challenge since it was read
+	used, err := s.repos.UserTokens.MarkUsed(challenge.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidChallenge
+	}
+
+	if limiter != nil {
+		if err := limiter.Success(ctx, user.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// verifyCode accepts a TOTP code newer than the last one accepted, or an
+// unused recovery code
+func (s *TwoFactorService) verifyCode(user *models.User, code string) error {
+	if !user.TwoFactorEnabled() || user.TOTPSecret == nil {
+		return ErrTwoFactorNotEnabled
+	}
+
+	now := time.Now()
+	if counter, ok := totp.Validate(*user.TOTPSecret, code, now); ok {
+		// Each code is accepted once, even within its validity window and
+		// by concurrent requests
+		advanced, err := s.repos.Users.AdvanceTOTPCounter(user.ID, counter, now)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidTwoFactorCode
+		}
+		user.TOTPLastCounter = counter
+		user.UpdatedAt = now
+		return nil
+	}
+
+	redeemed, err := s.repos.Recovery.Redeem(user.ID, hashToken(normalizeRecoveryCode(code)), now)
+	if err != nil {
+		return err
+	}
+	if !redeemed {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// issueRecoveryCodes replaces a user's recovery codes with new ones
+func (s *TwoFactorService) issueRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	records := make([]models.RecoveryCode, RecoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: hashToken(normalizeRecoveryCode(code))}
+	}
+
+	if err := s.repos.Recovery.Replace(userID, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// authenticateWithCode returns a user with two-factor authentication after
+// checking their password and a TOTP or recovery code
+func (s *TwoFactorService) authenticateWithCode(userID uuid.UUID, password, code string) (*models.User, error) {
+	user, err := s.authenticate(userID, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyCode(user, code); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// authenticate returns a user after checking their password
+func (s *TwoFactorService) authenticate(userID uuid.UUID, password string) (*models.User, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrWrongPassword
+	}
+	return user, nil
+}
+
+// findUser retrieves a user by ID
+func (s *TwoFactorService) findUser(userID uuid.UUID) (*models.User, error) {
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// recoveryCodeEncoding renders recovery codes in base32, which has no 0, 1
+// or 8 to be mistaken for letters
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCode returns a random code such as "ABCD-EFGH"
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := recoveryCodeEncoding.EncodeToString(buf)
+	return code[:4] + "-" + code[4:], nil
+}
+
+// normalizeRecoveryCode ignores case, spaces and dashes in a recovery code
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,206 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
+	"github.com/jaimesHub/golang-todo-app/internal/services/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTwoFactorService(t *testing.T, repos *repository.Repositories) (*services.TwoFactorService, *services.AuthService) {
+	jwtService, err := auth.NewJWTService(&config.JWTConfig{Secret: "test-secret-key", AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour})
+	assert.NoError(t, err)
+	authService := services.NewAuthService(repos, jwtService, auth.NewMemoryDenylist())
+	authService.SetLoginLimiter(auth.NewLoginLimiter(auth.NewMemoryAttemptStore(), config.LoginConfig{
+		MaxAttemptsPerEmail: 3,
+		AttemptWindow:       time.Minute,
+		LockoutDuration:     time.Minute,
+	}))
+
+	twoFactorService := services.NewTwoFactorService(repos, authService, config.TwoFactorConfig{Issuer: "Todo App", ChallengeTTL: time.Minute})
+	return twoFactorService, authService
+}
+
+// codeAt returns the TOTP code of a user's secret for a time step
+func codeAt(t *testing.T, repos *repository.Repositories, user *models.User, counter int64) string {
+	stored, err := repos.Users.FindByID(user.ID)
+	assert.NoError(t, err)
+	code, err := totp.Code(*stored.TOTPSecret, counter)
+	assert.NoError(t, err)
+	return code
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	twoFactorService, _ := newTwoFactorService(t, repos)
+	user, err := services.NewUserService(repos).CreateUser("totp@example.com", "password123", "Tom", "")
+	assert.NoError(t, err)
+
+	_, err = twoFactorService.Enroll(user.ID, "wrong")
+	assert.ErrorIs(t, err, services.ErrWrongPassword)
+	_, err = twoFactorService.Confirm(user.ID, "123456")
+	assert.ErrorIs(t, err, services.ErrTwoFactorNotEnrolled)
+	_, err = twoFactorService.Confirm(uuid.New(), "123456")
+	assert.ErrorIs(t, err, services.ErrUserNotFound)
+
+	enrollment, err := twoFactorService.Enroll(user.ID, "password123")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/Todo%20App:totp@example.com?"))
+
+	// Nothing changes until the enrollment is confirmed
+	stored, _ := repos.Users.FindByID(user.ID)
+	assert.False(t, stored.TwoFactorEnabled())
+
+	_, err = twoFactorService.Confirm(user.ID, "000000")
+	assert.ErrorIs(t, err, services.ErrInvalidTwoFactorCode)
+
+	counter := totp.Counter(time.Now())
+	codes, err := twoFactorService.Confirm(user.ID, codeAt(t, repos, user, counter))
+	assert.NoError(t, err)
+	assert.Len(t, codes, services.RecoveryCodeCount)
+	stored, _ = repos.Users.FindByID(user.ID)
+	assert.True(t, stored.TwoFactorEnabled())
+
+	_, err = twoFactorService.Enroll(user.ID, "password123")
+	assert.ErrorIs(t, err, services.ErrTwoFactorEnabled)
+
+	// The confirmation code cannot be used again
+	_, err = twoFactorService.RegenerateRecoveryCodes(user.ID, "password123", codeAt(t, repos, user, counter))
+	assert.ErrorIs(t, err, services.ErrInvalidTwoFactorCode)
+
+	// A recovery code can stand in for a TOTP code; regenerating replaces the codes
+	newCodes, err := twoFactorService.RegenerateRecoveryCodes(user.ID, "password123", strings.ToLower(codes[0]))
+	assert.NoError(t, err)
+	assert.NotEqual(t, codes, newCodes)
+	_, err = twoFactorService.RegenerateRecoveryCodes(user.ID, "password123", codes[1])
+	assert.ErrorIs(t, err, services.ErrInvalidTwoFactorCode)
+
+	remaining, err := twoFactorService.RemainingRecoveryCodes(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(services.RecoveryCodeCount), remaining)
+
+	assert.ErrorIs(t, twoFactorService.Disable(user.ID, "wrong", newCodes[0]), services.ErrWrongPassword)
+	assert.NoError(t, twoFactorService.Disable(user.ID, "password123", strings.ToLower(newCodes[0])))
+	stored, _ = repos.Users.FindByID(user.ID)
+	assert.False(t, stored.TwoFactorEnabled())
+	assert.Nil(t, stored.TOTPSecret)
+	remaining, _ = twoFactorService.RemainingRecoveryCodes(user.ID)
+	assert.Zero(t, remaining)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	twoFactorService, authService := newTwoFactorService(t, repos)
+	ctx := context.Background()
+	user, err := services.NewUserService(repos).CreateUser("login2fa@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	_, err = twoFactorService.Enroll(user.ID, "password123")
+	assert.NoError(t, err)
+	counter := totp.Counter(time.Now())
+	codes, err := twoFactorService.Confirm(user.ID, codeAt(t, repos, user, counter-1))
+	assert.NoError(t, err)
+
+	authenticated, err := authService.Authenticate(ctx, "login2fa@example.com", "password123", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.True(t, authenticated.TwoFactorEnabled())
+
+	challenge, err := twoFactorService.Challenge(authenticated)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(60), challenge.ExpiresIn)
+
+	_, err = twoFactorService.CompleteLogin(ctx, "unknown", codeAt(t, repos, user, counter), "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidChallenge)
+
+	// Wrong codes keep the challenge open
+	_, err = twoFactorService.CompleteLogin(ctx, challenge.Token, "000000", "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidTwoFactorCode)
+
+	loggedIn, err := twoFactorService.CompleteLogin(ctx, challenge.Token, codeAt(t, repos, user, counter), "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, loggedIn.ID)
+
+	// The challenge is single-use
+	_, err = twoFactorService.CompleteLogin(ctx, challenge.Token, codes[0], "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidChallenge)
+
+	// A recovery code completes a login once
+	challenge, _ = twoFactorService.Challenge(authenticated)
+	_, err = twoFactorService.CompleteLogin(ctx, challenge.Token, codes[0], "10.0.0.1")
+	assert.NoError(t, err)
+	challenge, _ = twoFactorService.Challenge(authenticated)
+	_, err = twoFactorService.CompleteLogin(ctx, challenge.Token, codes[0], "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidTwoFactorCode)
+
+	// Wrong codes count towards the lockout, and logging in with the
+	// password again does not reset them
+	_, err = twoFactorService.CompleteLogin(ctx, challenge.Token, "000000", "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidTwoFactorCode)
+	_, err = authService.Authenticate(ctx, "login2fa@example.com", "password123", "10.0.0.1")
+	assert.NoError(t, err)
+	_, err = twoFactorService.CompleteLogin(ctx, challenge.Token, "000000", "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidTwoFactorCode)
+
+	var throttled *services.LoginThrottledError
+	_, err = twoFactorService.CompleteLogin(ctx, challenge.Token, codeAt(t, repos, user, counter+1), "10.0.0.1")
+	assert.ErrorAs(t, err, &throttled)
+	_, err = authService.Authenticate(ctx, "login2fa@example.com", "password123", "10.0.0.1")
+	assert.ErrorAs(t, err, &throttled)
+}
+
+func TestTwoFactorCodeRedeemedOnceConcurrently(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	twoFactorService, _ := newTwoFactorService(t, repos)
+	ctx := context.Background()
+	user, err := services.NewUserService(repos).CreateUser("race2fa@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	_, err = twoFactorService.Enroll(user.ID, "password123")
+	assert.NoError(t, err)
+	counter := totp.Counter(time.Now())
+	_, err = twoFactorService.Confirm(user.ID, codeAt(t, repos, user, counter-1))
+	assert.NoError(t, err)
+	stored, err := repos.Users.FindByID(user.ID)
+	assert.NoError(t, err)
+
+	// Logins racing with the same code each pass their own challenge, but
+	// only one of them gets past the code
+	code := codeAt(t, repos, user, counter)
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		challenge, err := twoFactorService.Challenge(stored)
+		assert.NoError(t, err)
+		wg.Add(1)
+		go func(i int, token string) {
+			defer wg.Done()
+			_, errs[i] = twoFactorService.CompleteLogin(ctx, token, code, "10.0.0.1")
+		}(i, challenge.Token)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(t, err, services.ErrInvalidTwoFactorCode)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	// The counter only moves forward
+	advanced, err := repos.Users.AdvanceTOTPCounter(user.ID, counter, time.Now())
+	assert.NoError(t, err)
+	assert.False(t, advanced)
+}
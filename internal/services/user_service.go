@@ -1,34 +1,45 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
+)
+
+var (
+	// ErrEmailTaken is returned when another account already uses an email address
+	ErrEmailTaken = errors.New("user with this email already exists")
+
+	// ErrUserNotFound is returned when a user does not exist
+	ErrUserNotFound = errors.New("user not found")
 )
 
 // UserService handles user-related business logic
 type UserService struct {
-	db *gorm.DB
+	repos *repository.Repositories
 }
 
 // NewUserService creates a new user service
-func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{db: db}
+func NewUserService(repos *repository.Repositories) *UserService {
+	return &UserService{repos: repos}
 }
 
-// CreateUser creates a new user
+// CreateUser creates a new user. Emails of deleted accounts stay taken until
+// their data is purged.
 func (s *UserService) CreateUser(email, password, firstName, lastName string) (*models.User, error) {
 	// Check if user already exists
-	var existingUser models.User
-	result := s.db.Where("email = ?", email).First(&existingUser)
-	if result.Error == nil {
-		return nil, errors.New("user with this email already exists")
-	} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return nil, result.Error
+	taken, err := s.repos.Users.EmailTaken(email)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrEmailTaken
 	}
 
 	// Hash password
@@ -44,25 +55,21 @@ func (s *UserService) CreateUser(email, password, firstName, lastName string) (*
 		FirstName: firstName,
 		LastName:  lastName,
 		IsActive:  true,
+		Role:      models.RoleUser,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	if err := s.db.Create(user).Error; err != nil {
+	if err := s.repos.Users.Create(user); err != nil {
+		// Another registration may have taken the email since the check
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrEmailTaken
+		}
 		return nil, err
 	}
 
 	// Log activity
-	activity := &models.Activity{
-		UserID:    user.ID,
-		Action:    "register",
-		Entity:    "user",
-		EntityID:  user.ID,
-		Details:   "User registration",
-		CreatedAt: time.Now(),
-	}
-
-	if err := s.db.Create(activity).Error; err != nil {
+	if err := s.LogActivity(user.ID, "register", "user", user.ID, "User registration"); err != nil {
 		// Just log the error, don't fail the user creation
 		// In a real app, you might want to use a proper logger
 		// logger.Error("Failed to log user registration activity", "error", err)
@@ -73,35 +80,32 @@ func (s *UserService) CreateUser(email, password, firstName, lastName string) (*
 
 // GetUserByID retrieves a user by ID
 func (s *UserService) GetUserByID(id uuid.UUID) (*models.User, error) {
-	var user models.User
-	if err := s.db.First(&user, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+	user, err := s.repos.Users.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
-	return &user, nil
+	return user, nil
 }
 
 // GetUserByEmail retrieves a user by email
 func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
-	var user models.User
-	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+	user, err := s.repos.Users.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
-	return &user, nil
+	return user, nil
 }
 
 // UpdateUser updates a user's profile
 func (s *UserService) UpdateUser(id uuid.UUID, firstName, lastName string) (*models.User, error) {
-	var user models.User
-	if err := s.db.First(&user, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
+	user, err := s.GetUserByID(id)
+	if err != nil {
 		return nil, err
 	}
 
@@ -110,47 +114,32 @@ func (s *UserService) UpdateUser(id uuid.UUID, firstName, lastName string) (*mod
 	user.LastName = lastName
 	user.UpdatedAt = time.Now()
 
-	if err := s.db.Save(&user).Error; err != nil {
+	if err := s.repos.Users.Update(user); err != nil {
 		return nil, err
 	}
 
 	// Log activity
-	activity := &models.Activity{
-		UserID:    user.ID,
-		Action:    "update",
-		Entity:    "user",
-		EntityID:  user.ID,
-		Details:   "User profile updated",
-		CreatedAt: time.Now(),
-	}
-
-	if err := s.db.Create(activity).Error; err != nil {
+	if err := s.LogActivity(user.ID, "update", "user", user.ID, "User profile updated"); err != nil {
 		// Just log the error, don't fail the user update
 		// logger.Error("Failed to log user update activity", "error", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
-// GetUserActivities retrieves a user's activities
-func (s *UserService) GetUserActivities(userID uuid.UUID, limit, offset int) ([]models.Activity, error) {
-	var activities []models.Activity
-
-	query := s.db.Where("user_id = ?", userID).Order("created_at DESC")
-
-	if limit > 0 {
-		query = query.Limit(limit)
-	}
-
-	if offset > 0 {
-		query = query.Offset(offset)
-	}
-
-	if err := query.Find(&activities).Error; err != nil {
-		return nil, err
+// HandleAccountPurge processes an account_purge job: the data of a deleted
+// account is removed and the account anonymized. Accounts that were already
+// purged are skipped.
+func (s *UserService) HandleAccountPurge(ctx context.Context, payload jobs.AccountPurgePayload) error {
+	if err := s.repos.Users.Purge(payload.UserID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
 	}
+	return nil
+}
 
-	return activities, nil
+// GetUserActivities retrieves a user's activities
+func (s *UserService) GetUserActivities(userID uuid.UUID, limit, offset int) ([]models.Activity, error) {
+	return s.repos.Activities.ListByUser(userID, limit, offset)
 }
 
 // LogActivity logs a user activity
@@ -164,5 +153,5 @@ func (s *UserService) LogActivity(userID uuid.UUID, action, entity string, entit
 		CreatedAt: time.Now(),
 	}
 
-	return s.db.Create(activity).Error
+	return s.repos.Activities.Create(activity)
 }
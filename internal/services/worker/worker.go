@@ -2,134 +2,375 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/jaimesHub/golang-todo-app/internal/config"
 	"github.com/jaimesHub/golang-todo-app/internal/services/queue"
 )
 
-// Worker processes tasks from the queue
+const (
+	// maxRetryBackoff caps the exponential delay between retries
+	maxRetryBackoff = time.Hour
+
+	// dequeueTimeout is how long a fetcher blocks waiting for a job, which
+	// also bounds how long Stop waits for idle fetchers
+	dequeueTimeout = 2 * time.Second
+
+	// maintenanceInterval is how often scheduled jobs are promoted and
+	// expired jobs reclaimed
+	maintenanceInterval = time.Second
+
+	// promoteBatchSize is how many due scheduled tasks are moved to the
+	// queue per Redis call, so a large backlog does not block Redis
+	promoteBatchSize = 100
+
+	// limitWait is how long a fetcher pauses after handing back a job whose
+	// task type is at its concurrency limit
+	limitWait = 500 * time.Millisecond
+
+	// releaseGrace is how long Stop waits, after its deadline, for cancelled
+	// handlers to hand their jobs back to the queue
+	releaseGrace = time.Second
+)
+
+// Worker processes tasks from the queue with a pool of goroutines
 type Worker struct {
-	queue      *queue.Queue
-	handlers   map[string]TaskHandler
-	queueName  string
-	isRunning  bool
-	ctx        context.Context
-	cancelFunc context.CancelFunc
+	queue     *queue.Queue
+	config    config.QueueConfig
+	handlers  map[string]TaskHandler
+	limits    map[string]chan struct{}
+	queueName string
+
+	mu         sync.Mutex
+	running    bool
+	stopFetch  context.CancelFunc
+	cancelJobs context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// TaskHandler is a function that processes a task. The context is cancelled
+// when the job exceeds its visibility timeout or the worker stops before the
+// job finished.
+type TaskHandler func(ctx context.Context, task *queue.Task) error
+
+// permanentError marks a handler failure that retrying cannot fix
+type permanentError struct {
+	err error
 }
 
-// TaskHandler is a function that processes a task
-type TaskHandler func(task *queue.Task) error
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps a handler error so the job is dead-lettered right away
+// instead of being retried, for example when its payload is invalid
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
 
 // NewWorker creates a new worker
-func NewWorker(cfg config.RedisConfig, queueName string) (*Worker, error) {
+func NewWorker(cfg config.RedisConfig, queueCfg config.QueueConfig, queueName string) (*Worker, error) {
 	q, err := queue.NewQueue(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create queue: %w", err)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	if queueCfg.Concurrency < 1 {
+		queueCfg.Concurrency = 1
+	}
+
+	limits := make(map[string]chan struct{})
+	for taskType, limit := range queueCfg.TypeConcurrency {
+		if limit > 0 {
+			limits[taskType] = make(chan struct{}, limit)
+		}
+	}
 
 	return &Worker{
-		queue:      q,
-		handlers:   make(map[string]TaskHandler),
-		queueName:  queueName,
-		isRunning:  false,
-		ctx:        ctx,
-		cancelFunc: cancel,
+		queue:     q,
+		config:    queueCfg,
+		handlers:  make(map[string]TaskHandler),
+		limits:    limits,
+		queueName: queueName,
 	}, nil
 }
 
-// RegisterHandler registers a handler for a specific task type
+// RegisterHandler registers a handler for a specific task type. Handlers
+// must be registered before Start.
 func (w *Worker) RegisterHandler(taskType string, handler TaskHandler) {
 	w.handlers[taskType] = handler
 }
 
-// Start starts the worker
+// Start starts the configured number of fetchers and the maintenance loop
 func (w *Worker) Start() {
-	if w.isRunning {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.running {
 		return
 	}
 
-	w.isRunning = true
+	// Fetching stops as soon as Stop is called; running jobs keep their own
+	// context so they can finish within the shutdown deadline
+	fetchCtx, stopFetch := context.WithCancel(context.Background())
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	w.stopFetch = stopFetch
+	w.cancelJobs = cancelJobs
+	w.running = true
+
+	for i := 0; i < w.config.Concurrency; i++ {
+		w.wg.Add(1)
+		go w.fetch(fetchCtx, jobsCtx)
+	}
+
+	w.wg.Add(1)
+	go w.maintain(fetchCtx)
+
+	log.Printf("Worker started for queue: %s (concurrency: %d)", w.queueName, w.config.Concurrency)
+}
+
+// Stop stops taking new jobs and waits for running jobs to finish. If ctx
+// expires first, running jobs are cancelled and Stop returns ctx.Err(); jobs
+// whose handlers do not return are reclaimed after their visibility timeout.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return nil
+	}
+	w.running = false
+	stopFetch, cancelJobs := w.stopFetch, w.cancelJobs
+	w.mu.Unlock()
+
+	stopFetch()
 
+	done := make(chan struct{})
 	go func() {
-		for {
-			select {
-			case <-w.ctx.Done():
-				log.Println("Worker stopped")
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		cancelJobs()
+		log.Println("Worker stopped")
+		return nil
+	case <-ctx.Done():
+		cancelJobs()
+		select {
+		case <-done:
+		case <-time.After(releaseGrace):
+		}
+		log.Printf("Worker stopped before running jobs finished: %v", ctx.Err())
+		return ctx.Err()
+	}
+}
+
+// Close stops the worker, waiting for running jobs, and closes its queue
+func (w *Worker) Close() error {
+	w.Stop(context.Background())
+	return w.queue.Close()
+}
+
+// fetch takes jobs from the queue and runs them until fetchCtx is cancelled
+func (w *Worker) fetch(fetchCtx, jobsCtx context.Context) {
+	defer w.wg.Done()
+
+	for fetchCtx.Err() == nil {
+		task, err := w.queue.Dequeue(fetchCtx, w.queueName, dequeueTimeout, w.config.VisibilityTimeout)
+		if err != nil {
+			if fetchCtx.Err() != nil {
 				return
-			default:
-				// Process tasks
-				task, err := w.queue.Dequeue(w.queueName, 5*time.Second)
-				if err != nil {
-					log.Printf("Error dequeueing task: %v", err)
-					time.Sleep(1 * time.Second)
-					continue
-				}
-
-				if task == nil {
-					// No tasks available, check scheduled tasks
-					w.processScheduledTasks()
-					continue
-				}
-
-				// Process task
-				w.processTask(task)
 			}
+			log.Printf("Error dequeueing task: %v", err)
+			wait(fetchCtx, time.Second)
+			continue
+		}
+
+		if task == nil {
+			continue
+		}
+
+		if !w.acquire(task.Type) {
+			// Hand the job back so other task types are not held up
+			w.release(task)
+			wait(fetchCtx, limitWait)
+			continue
 		}
-	}()
 
-	log.Printf("Worker started for queue: %s", w.queueName)
+		w.processTask(jobsCtx, task)
+		w.releaseSlot(task.Type)
+	}
 }
 
-// Stop stops the worker
-func (w *Worker) Stop() {
-	if !w.isRunning {
-		return
+// maintain promotes due scheduled tasks and reclaims expired ones until ctx
+// is cancelled
+func (w *Worker) maintain(ctx context.Context) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.processScheduledTasks(ctx)
+			w.reclaimExpiredTasks(ctx)
+		}
+	}
+}
+
+// acquire takes a concurrency slot for a task type; types without a limit
+// always get one
+func (w *Worker) acquire(taskType string) bool {
+	limit, exists := w.limits[taskType]
+	if !exists {
+		return true
 	}
 
-	w.cancelFunc()
-	w.isRunning = false
+	select {
+	case limit <- struct{}{}:
+		return true
+	default:
+		return false
+	}
 }
 
-// Close closes the worker and its resources
-func (w *Worker) Close() error {
-	w.Stop()
-	return w.queue.Close()
+// releaseSlot gives back the concurrency slot taken by acquire
+func (w *Worker) releaseSlot(taskType string) {
+	if limit, exists := w.limits[taskType]; exists {
+		<-limit
+	}
 }
 
-// processTask processes a task
-func (w *Worker) processTask(task *queue.Task) {
+// processTask processes a task and acknowledges, retries or dead-letters it.
+// Queue bookkeeping uses its own context so it completes during shutdown.
+func (w *Worker) processTask(ctx context.Context, task *queue.Task) {
 	handler, exists := w.handlers[task.Type]
 	if !exists {
 		log.Printf("No handler registered for task type: %s", task.Type)
+		w.deadLetter(task, fmt.Errorf("no handler registered for task type: %s", task.Type))
+		return
+	}
+
+	log.Printf("Processing task: %s (type: %s, attempt: %d)", task.ID, task.Type, task.Attempts+1)
+
+	var jobCtx context.Context
+	var cancel context.CancelFunc
+	if w.config.VisibilityTimeout > 0 {
+		jobCtx, cancel = context.WithTimeout(ctx, w.config.VisibilityTimeout)
+	} else {
+		jobCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	err := handler(jobCtx, task)
+	if err == nil {
+		if err := w.queue.Ack(context.Background(), w.queueName, task); err != nil {
+			log.Printf("Error acknowledging task %s: %v", task.ID, err)
+		}
+		return
+	}
+
+	// A job interrupted by shutdown did not fail; it runs again later
+	if ctx.Err() != nil {
+		log.Printf("Task %s interrupted by shutdown: %v", task.ID, err)
+		w.release(task)
 		return
 	}
 
-	log.Printf("Processing task: %s (type: %s)", task.ID, task.Type)
+	log.Printf("Error processing task %s: %v", task.ID, err)
 
-	if err := handler(task); err != nil {
-		log.Printf("Error processing task %s: %v", task.ID, err)
-		// In a real application, you might want to implement retry logic here
+	var permanent *permanentError
+	if task.Attempts >= w.config.MaxRetries || errors.As(err, &permanent) {
+		w.deadLetter(task, err)
+		return
+	}
+
+	delay := retryBackoff(w.config.RetryBackoff, task.Attempts+1)
+	if err := w.queue.Retry(context.Background(), w.queueName, task, delay, err); err != nil {
+		log.Printf("Error scheduling retry of task %s: %v", task.ID, err)
+		return
 	}
+	log.Printf("Task %s will be retried in %s", task.ID, delay)
 }
 
-// processScheduledTasks processes due scheduled tasks
-func (w *Worker) processScheduledTasks() {
-	tasks, err := w.queue.GetDueScheduledTasks()
-	if err != nil {
-		log.Printf("Error getting due scheduled tasks: %v", err)
+// release hands a task back to the queue without counting an attempt
+func (w *Worker) release(task *queue.Task) {
+	if err := w.queue.Release(context.Background(), w.queueName, task); err != nil {
+		log.Printf("Error releasing task %s: %v", task.ID, err)
+	}
+}
+
+// deadLetter moves a task that cannot be processed to the dead-letter list
+func (w *Worker) deadLetter(task *queue.Task, cause error) {
+	if err := w.queue.DeadLetter(context.Background(), w.queueName, task, cause); err != nil {
+		log.Printf("Error dead-lettering task %s: %v", task.ID, err)
 		return
 	}
+	log.Printf("Task %s moved to the dead-letter list after %d attempts", task.ID, task.Attempts)
+}
+
+// retryBackoff returns the delay before a retry: base, doubled for every
+// previous attempt and capped at maxRetryBackoff
+func retryBackoff(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
 
-	for _, task := range tasks {
-		// Enqueue task to be processed by the worker
-		_, err := w.queue.Enqueue(w.queueName, task.Type, task.Data)
+// processScheduledTasks moves due scheduled tasks, including retries, to the
+// queue in batches
+func (w *Worker) processScheduledTasks(ctx context.Context) {
+	for {
+		promoted, err := w.queue.PromoteDueTasks(ctx, w.queueName, promoteBatchSize)
 		if err != nil {
-			log.Printf("Error enqueueing scheduled task %s: %v", task.ID, err)
+			if ctx.Err() == nil {
+				log.Printf("Error promoting due scheduled tasks: %v", err)
+			}
+			return
 		}
+
+		if promoted < promoteBatchSize {
+			return
+		}
+	}
+}
+
+// reclaimExpiredTasks puts tasks held past their visibility timeout, for
+// example by a worker that crashed, back in the queue
+func (w *Worker) reclaimExpiredTasks(ctx context.Context) {
+	reclaimed, deadLettered, err := w.queue.ReclaimExpired(ctx, w.queueName, w.config.VisibilityTimeout, w.config.MaxRetries)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Error reclaiming expired tasks: %v", err)
+		}
+		return
+	}
+
+	if reclaimed > 0 {
+		log.Printf("Reclaimed %d tasks whose visibility timeout expired", reclaimed)
+	}
+	if deadLettered > 0 {
+		log.Printf("Moved %d tasks whose visibility timeout expired too often to the dead-letter list", deadLettered)
+	}
+}
+
+// wait sleeps for d or until ctx is cancelled
+func wait(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
 	}
 }
@@ -0,0 +1,186 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/services/queue"
+	"github.com/jaimesHub/golang-todo-app/internal/services/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestWorker(t *testing.T, queueCfg config.QueueConfig) (*worker.Worker, *queue.Queue) {
+	server := miniredis.RunT(t)
+	port, err := strconv.Atoi(server.Port())
+	assert.NoError(t, err)
+	redisCfg := config.RedisConfig{Host: server.Host(), Port: port}
+
+	w, err := worker.NewWorker(redisCfg, queueCfg, "jobs")
+	assert.NoError(t, err)
+	q, err := queue.NewQueue(redisCfg)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		w.Close()
+		q.Close()
+	})
+
+	return w, q
+}
+
+func enqueue(t *testing.T, q *queue.Queue, taskType string, n int) {
+	for i := 0; i < n; i++ {
+		_, err := q.Enqueue(context.Background(), "jobs", taskType, nil)
+		assert.NoError(t, err)
+	}
+}
+
+func TestWorkerRunsJobsConcurrently(t *testing.T) {
+	w, q := newTestWorker(t, config.QueueConfig{Concurrency: 3, VisibilityTimeout: time.Minute})
+
+	// Every job waits for the others to start, which only works in parallel
+	var started sync.WaitGroup
+	started.Add(3)
+	var done int32
+	w.RegisterHandler("report", func(ctx context.Context, task *queue.Task) error {
+		started.Done()
+		started.Wait()
+		atomic.AddInt32(&done, 1)
+		return nil
+	})
+
+	enqueue(t, q, "report", 3)
+	w.Start()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&done) == 3 }, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, w.Stop(context.Background()))
+}
+
+func TestWorkerTypeConcurrencyLimit(t *testing.T) {
+	w, q := newTestWorker(t, config.QueueConfig{
+		Concurrency:       4,
+		VisibilityTimeout: time.Minute,
+		TypeConcurrency:   map[string]int{"export": 1},
+	})
+
+	var running, maxRunning, done int32
+	w.RegisterHandler("export", func(ctx context.Context, task *queue.Task) error {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			previous := atomic.LoadInt32(&maxRunning)
+			if current <= previous || atomic.CompareAndSwapInt32(&maxRunning, previous, current) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&done, 1)
+		return nil
+	})
+
+	enqueue(t, q, "export", 3)
+	w.Start()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&done) == 3 }, 10*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&maxRunning))
+	assert.NoError(t, w.Stop(context.Background()))
+}
+
+func TestWorkerStopDrainsRunningJobs(t *testing.T) {
+	w, q := newTestWorker(t, config.QueueConfig{Concurrency: 1, VisibilityTimeout: time.Minute})
+
+	started := make(chan struct{})
+	var finished int32
+	w.RegisterHandler("report", func(ctx context.Context, task *queue.Task) error {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+		return nil
+	})
+
+	enqueue(t, q, "report", 1)
+	w.Start()
+	<-started
+
+	assert.NoError(t, w.Stop(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&finished))
+
+	length, err := q.GetQueueLength(context.Background(), "jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), length)
+}
+
+func TestWorkerStopDeadlineReleasesJobs(t *testing.T) {
+	w, q := newTestWorker(t, config.QueueConfig{Concurrency: 1, VisibilityTimeout: time.Minute, MaxRetries: 3})
+
+	started := make(chan struct{})
+	w.RegisterHandler("report", func(ctx context.Context, task *queue.Task) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	enqueue(t, q, "report", 1)
+	w.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Stop(ctx), context.DeadlineExceeded)
+
+	// The interrupted job is back in the queue without a failed attempt
+	task, err := q.Dequeue(context.Background(), "jobs", time.Second, time.Minute)
+	assert.NoError(t, err)
+	if assert.NotNil(t, task) {
+		assert.Equal(t, 0, task.Attempts)
+	}
+}
+
+func TestWorkerRunsDueScheduledJobs(t *testing.T) {
+	w, q := newTestWorker(t, config.QueueConfig{Concurrency: 2, VisibilityTimeout: time.Minute})
+
+	ran := make(chan string, 1)
+	w.RegisterHandler("task_reminder", func(ctx context.Context, task *queue.Task) error {
+		ran <- task.ID
+		return nil
+	})
+
+	assert.NoError(t, q.ScheduleTaskWithID(context.Background(), "jobs", "reminder:1", "task_reminder", nil, time.Now().Add(-time.Second)))
+	w.Start()
+
+	select {
+	case id := <-ran:
+		assert.Equal(t, "reminder:1", id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled job did not run")
+	}
+	assert.NoError(t, w.Stop(context.Background()))
+}
+
+func TestWorkerDeadLettersPermanentFailures(t *testing.T) {
+	w, q := newTestWorker(t, config.QueueConfig{Concurrency: 1, VisibilityTimeout: time.Minute, MaxRetries: 5})
+
+	w.RegisterHandler("report", func(ctx context.Context, task *queue.Task) error {
+		return worker.Permanent(errors.New("payload is invalid"))
+	})
+
+	enqueue(t, q, "report", 1)
+	w.Start()
+
+	assert.Eventually(t, func() bool {
+		count, _ := q.CountDeadLetters(context.Background(), "jobs")
+		return count == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, w.Stop(context.Background()))
+
+	dead, err := q.ListDeadLetters(context.Background(), "jobs", 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dead[0].Attempts)
+	assert.Equal(t, "payload is invalid", dead[0].LastError)
+}
@@ -0,0 +1,486 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services/jobs"
+)
+
+var (
+	// ErrWorkspaceNotFound is returned when a workspace does not exist or the
+	// user is not a member
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+
+	// ErrWorkspaceForbidden is returned when the user's role in a workspace
+	// does not allow the action
+	ErrWorkspaceForbidden = errors.New("insufficient workspace role")
+
+	// ErrInvalidWorkspaceRole is returned for unknown workspace roles
+	ErrInvalidWorkspaceRole = errors.New("invalid workspace role")
+
+	// ErrMemberNotFound is returned when a user is not a member of the workspace
+	ErrMemberNotFound = errors.New("member not found")
+
+	// ErrAlreadyMember is returned when inviting a user who is a member already
+	ErrAlreadyMember = errors.New("user is already a member")
+
+	// ErrOwnerCannotLeave is returned when the owner leaves their workspace
+	ErrOwnerCannotLeave = errors.New("the owner must transfer ownership before leaving")
+
+	// ErrInvitationNotFound is returned for unknown, answered or expired
+	// invitations and for invitations sent to another email address
+	ErrInvitationNotFound = errors.New("invitation not found or no longer pending")
+)
+
+// workspaceRoleRanks orders the workspace roles by privilege
+var workspaceRoleRanks = map[string]int{
+	models.WorkspaceRoleViewer: 1,
+	models.WorkspaceRoleMember: 2,
+	models.WorkspaceRoleAdmin:  3,
+	models.WorkspaceRoleOwner:  4,
+}
+
+// WorkspaceService manages workspaces, their members and invitations
+type WorkspaceService struct {
+	repos     *repository.Repositories
+	cfg       config.WorkspaceConfig
+	queue     jobs.Enqueuer
+	queueName string
+}
+
+// NewWorkspaceService creates a new workspace service
+func NewWorkspaceService(repos *repository.Repositories, cfg config.WorkspaceConfig) *WorkspaceService {
+	return &WorkspaceService{repos: repos, cfg: cfg}
+}
+
+// SetQueue enables emailing invitations, and removing the attachments of
+// deleted workspaces, through jobs on the named queue. Without a queue,
+// invitations are only listed to the invited users.
+func (s *WorkspaceService) SetQueue(q jobs.Enqueuer, queueName string) {
+	s.queue = q
+	s.queueName = queueName
+}
+
+// CreateWorkspace creates a workspace owned by the user
+func (s *WorkspaceService) CreateWorkspace(userID uuid.UUID, name string) (*models.Workspace, error) {
+	now := time.Now()
+	workspace := &models.Workspace{
+		Name:      strings.TrimSpace(name),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	owner := &models.WorkspaceMember{
+		UserID:    userID,
+		Role:      models.WorkspaceRoleOwner,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.repos.Workspaces.Create(workspace, owner); err != nil {
+		return nil, err
+	}
+
+	workspace.Role = owner.Role
+	return workspace, nil
+}
+
+// GetWorkspaces retrieves the workspaces a user is a member of
+func (s *WorkspaceService) GetWorkspaces(userID uuid.UUID) ([]models.Workspace, error) {
+	return s.repos.Workspaces.ListByUser(userID)
+}
+
+// GetWorkspace retrieves a workspace of which the user is a member, with
+// the user's role
+func (s *WorkspaceService) GetWorkspace(id, userID uuid.UUID) (*models.Workspace, error) {
+	role, err := memberRole(s.repos, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, ErrWorkspaceNotFound
+	}
+
+	workspace, err := s.repos.Workspaces.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+
+	workspace.Role = role
+	return workspace, nil
+}
+
+// UpdateWorkspace renames a workspace; admins and the owner only
+func (s *WorkspaceService) UpdateWorkspace(id, userID uuid.UUID, name string) (*models.Workspace, error) {
+	workspace, err := s.getWorkspaceAs(id, userID, models.WorkspaceRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.Name = strings.TrimSpace(name)
+	workspace.UpdatedAt = time.Now()
+	if err := s.repos.Workspaces.Update(workspace); err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// DeleteWorkspace deletes a workspace with its tasks; the owner only
+func (s *WorkspaceService) DeleteWorkspace(id, userID uuid.UUID) error {
+	workspace, err := s.getWorkspaceAs(id, userID, models.WorkspaceRoleOwner)
+	if err != nil {
+		return err
+	}
+	if err := s.repos.Workspaces.Delete(workspace); err != nil {
+		return err
+	}
+
+	// The attachments of the workspace's tasks are removed by the worker
+	if s.queue == nil {
+		return nil
+	}
+	if _, err := jobs.AttachmentCleanup.Enqueue(context.Background(), s.queue, s.queueName, jobs.AttachmentCleanupPayload{}); err != nil {
+		return fmt.Errorf("failed to enqueue attachment cleanup: %w", err)
+	}
+	return nil
+}
+
+// GetMembers retrieves the members of a workspace of which the user is a member
+func (s *WorkspaceService) GetMembers(id, userID uuid.UUID) ([]models.WorkspaceMember, error) {
+	if _, err := s.GetWorkspace(id, userID); err != nil {
+		return nil, err
+	}
+	return s.repos.Workspaces.ListMembers(id)
+}
+
+// UpdateMemberRole changes the role of a member. The actor must outrank the
+// member and cannot grant a role above their own. The owner making another
+// member owner hands the workspace over and becomes an admin.
+func (s *WorkspaceService) UpdateMemberRole(id, actorID, memberID uuid.UUID, role string) (*models.WorkspaceMember, error) {
+	if _, valid := workspaceRoleRanks[role]; !valid {
+		return nil, ErrInvalidWorkspaceRole
+	}
+
+	actor, member, err := s.findMembers(id, actorID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if !canManageMembers(actor.Role) || !outranks(actor.Role, member.Role) || workspaceRoleRanks[role] > workspaceRoleRanks[actor.Role] {
+		return nil, ErrWorkspaceForbidden
+	}
+
+	now := time.Now()
+	if role == models.WorkspaceRoleOwner {
+		if err := s.repos.Workspaces.TransferOwnership(id, actorID, memberID, now); err != nil {
+			if !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+
+			// The actor lost ownership or the member left in the meantime
+			if _, _, err := s.findMembers(id, actorID, memberID); err != nil {
+				return nil, err
+			}
+			return nil, ErrWorkspaceForbidden
+		}
+		member.Role = role
+		member.UpdatedAt = now
+		return member, nil
+	}
+
+	member.Role = role
+	member.UpdatedAt = now
+	if err := s.repos.Workspaces.UpdateMember(member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember removes a member from a workspace. Members may leave on
+// their own, except the owner; others need to outrank the member.
+func (s *WorkspaceService) RemoveMember(id, actorID, memberID uuid.UUID) error {
+	actor, member, err := s.findMembers(id, actorID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if actorID == memberID {
+		if member.Role == models.WorkspaceRoleOwner {
+			return ErrOwnerCannotLeave
+		}
+	} else if !canManageMembers(actor.Role) || !outranks(actor.Role, member.Role) {
+		return ErrWorkspaceForbidden
+	}
+
+	return s.repos.Workspaces.RemoveMember(id, memberID)
+}
+
+// findMembers retrieves the membership of the acting user and of the member
+// they act on
+func (s *WorkspaceService) findMembers(id, actorID, memberID uuid.UUID) (*models.WorkspaceMember, *models.WorkspaceMember, error) {
+	actor, err := s.repos.Workspaces.FindMember(id, actorID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrWorkspaceNotFound
+		}
+		return nil, nil, err
+	}
+
+	member, err := s.repos.Workspaces.FindMember(id, memberID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrMemberNotFound
+		}
+		return nil, nil, err
+	}
+
+	return actor, member, nil
+}
+
+// Invite invites an email address to join a workspace with a role no higher
+// than the inviter's. A pending invitation to the same address is renewed
+// instead of duplicated. The invitation is emailed when a queue is set.
+func (s *WorkspaceService) Invite(ctx context.Context, id uuid.UUID, inviter *models.User, email, role string) (*models.WorkspaceInvitation, error) {
+	if _, valid := workspaceRoleRanks[role]; !valid || role == models.WorkspaceRoleOwner {
+		return nil, ErrInvalidWorkspaceRole
+	}
+
+	workspace, err := s.getWorkspaceAs(id, inviter.ID, models.WorkspaceRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if workspaceRoleRanks[role] > workspaceRoleRanks[workspace.Role] {
+		return nil, ErrWorkspaceForbidden
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	invitee, err := s.repos.Users.FindByEmail(email)
+	switch {
+	case err == nil:
+		if _, err := s.repos.Workspaces.FindMember(id, invitee.ID); err == nil {
+			return nil, ErrAlreadyMember
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	case errors.Is(err, repository.ErrNotFound):
+		invitee = nil
+	default:
+		return nil, err
+	}
+
+	now := time.Now()
+	invitation, err := s.findPendingInvitation(id, email, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation != nil {
+		invitation.Role = role
+		invitation.InvitedByID = inviter.ID
+		invitation.ExpiresAt = now.Add(s.cfg.InvitationTTL)
+		invitation.UpdatedAt = now
+		err = s.repos.Invitations.Update(invitation)
+	} else {
+		invitation = &models.WorkspaceInvitation{
+			WorkspaceID: id,
+			Email:       email,
+			Role:        role,
+			InvitedByID: inviter.ID,
+			ExpiresAt:   now.Add(s.cfg.InvitationTTL),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		err = s.repos.Invitations.Create(invitation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sendInvitation(ctx, workspace, inviter, invitee, invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// findPendingInvitation retrieves the pending invitation of an email
+// address to a workspace, or nil if there is none
+func (s *WorkspaceService) findPendingInvitation(id uuid.UUID, email string, now time.Time) (*models.WorkspaceInvitation, error) {
+	invitations, err := s.repos.Invitations.ListByWorkspace(id, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range invitations {
+		if invitations[i].Email == email {
+			return &invitations[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// sendInvitation emails an invitation; invitee is nil when the address has
+// no account yet
+func (s *WorkspaceService) sendInvitation(ctx context.Context, workspace *models.Workspace, inviter, invitee *models.User, invitation *models.WorkspaceInvitation) error {
+	if s.queue == nil {
+		return nil
+	}
+
+	payload := jobs.EmailNotificationPayload{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You are invited to join %s", workspace.Name),
+		Body: fmt.Sprintf("Hi,\n\n%s invited you to join the workspace %q as %s. Sign in to accept or decline the invitation:\n\n%s/invitations\n\nThe invitation expires in %s.\n",
+			greetingName(inviter), workspace.Name, invitation.Role, s.cfg.AppURL, formatTTL(s.cfg.InvitationTTL)),
+	}
+	if invitee != nil {
+		payload.UserID = invitee.ID
+	}
+
+	if _, err := jobs.EmailNotification.Enqueue(ctx, s.queue, s.queueName, payload); err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
+
+// GetInvitations retrieves the pending invitations of a workspace; admins
+// and the owner only
+func (s *WorkspaceService) GetInvitations(id, userID uuid.UUID) ([]models.WorkspaceInvitation, error) {
+	if _, err := s.getWorkspaceAs(id, userID, models.WorkspaceRoleAdmin); err != nil {
+		return nil, err
+	}
+	return s.repos.Invitations.ListByWorkspace(id, time.Now())
+}
+
+// RevokeInvitation deletes an invitation of a workspace; admins and the
+// owner only
+func (s *WorkspaceService) RevokeInvitation(id, userID, invitationID uuid.UUID) error {
+	if _, err := s.getWorkspaceAs(id, userID, models.WorkspaceRoleAdmin); err != nil {
+		return err
+	}
+
+	invitation, err := s.repos.Invitations.FindByID(invitationID)
+	if err != nil || invitation.WorkspaceID != id {
+		if err == nil || errors.Is(err, repository.ErrNotFound) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+
+	return s.repos.Invitations.Delete(invitation)
+}
+
+// GetUserInvitations retrieves the pending invitations sent to a user's
+// email address
+func (s *WorkspaceService) GetUserInvitations(user *models.User) ([]models.WorkspaceInvitation, error) {
+	return s.repos.Invitations.ListByEmail(strings.ToLower(user.Email), time.Now())
+}
+
+// AcceptInvitation makes the user a member of the workspace they were
+// invited to and returns the workspace
+func (s *WorkspaceService) AcceptInvitation(invitationID uuid.UUID, user *models.User) (*models.Workspace, error) {
+	invitation, err := s.findUserInvitation(invitationID, user)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	member := &models.WorkspaceMember{
+		WorkspaceID: invitation.WorkspaceID,
+		UserID:      user.ID,
+		Role:        invitation.Role,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	accepted, err := s.repos.Invitations.Accept(invitation, member, now)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrInvitationNotFound
+	}
+
+	return s.GetWorkspace(invitation.WorkspaceID, user.ID)
+}
+
+// DeclineInvitation declines an invitation sent to the user
+func (s *WorkspaceService) DeclineInvitation(invitationID uuid.UUID, user *models.User) error {
+	if _, err := s.findUserInvitation(invitationID, user); err != nil {
+		return err
+	}
+
+	declined, err := s.repos.Invitations.Decline(invitationID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !declined {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// findUserInvitation retrieves a pending invitation sent to a user's email address
+func (s *WorkspaceService) findUserInvitation(invitationID uuid.UUID, user *models.User) (*models.WorkspaceInvitation, error) {
+	invitation, err := s.repos.Invitations.FindByID(invitationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+
+	if invitation.Email != strings.ToLower(user.Email) || !invitation.Pending(time.Now()) {
+		return nil, ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+// getWorkspaceAs retrieves a workspace of which the user is a member with
+// at least the given role
+func (s *WorkspaceService) getWorkspaceAs(id, userID uuid.UUID, role string) (*models.Workspace, error) {
+	workspace, err := s.GetWorkspace(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if workspaceRoleRanks[workspace.Role] < workspaceRoleRanks[role] {
+		return nil, ErrWorkspaceForbidden
+	}
+	return workspace, nil
+}
+
+// memberRole returns the role of a user in a workspace, or "" if the user
+// is not a member
+func memberRole(repos *repository.Repositories, workspaceID, userID uuid.UUID) (string, error) {
+	member, err := repos.Workspaces.FindMember(workspaceID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// outranks reports whether one workspace role is more privileged than another
+func outranks(role, other string) bool {
+	return workspaceRoleRanks[role] > workspaceRoleRanks[other]
+}
+
+// canEditTasks reports whether a workspace role may create and change tasks
+func canEditTasks(role string) bool {
+	return workspaceRoleRanks[role] >= workspaceRoleRanks[models.WorkspaceRoleMember]
+}
+
+// canManageMembers reports whether a workspace role may manage members and
+// invitations
+func canManageMembers(role string) bool {
+	return workspaceRoleRanks[role] >= workspaceRoleRanks[models.WorkspaceRoleAdmin]
+}
@@ -0,0 +1,324 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaimesHub/golang-todo-app/internal/config"
+	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWorkspaceService(repos *repository.Repositories) (*services.WorkspaceService, *fakeAccountQueue) {
+	workspaceService := services.NewWorkspaceService(repos, config.WorkspaceConfig{
+		AppURL:        "https://todo.example.com",
+		InvitationTTL: time.Hour,
+	})
+	mailQueue := &fakeAccountQueue{scheduled: make(map[string]time.Time)}
+	workspaceService.SetQueue(mailQueue, "tasks")
+	return workspaceService, mailQueue
+}
+
+// joinWorkspace invites a user to a workspace and accepts the invitation
+func joinWorkspace(t *testing.T, workspaceService *services.WorkspaceService, workspaceID uuid.UUID, inviter, user *models.User, role string) {
+	invitation, err := workspaceService.Invite(context.Background(), workspaceID, inviter, user.Email, role)
+	assert.NoError(t, err)
+	_, err = workspaceService.AcceptInvitation(invitation.ID, user)
+	assert.NoError(t, err)
+}
+
+func TestWorkspaceInvitations(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	workspaceService, mailQueue := newWorkspaceService(repos)
+	userService := services.NewUserService(repos)
+	ctx := context.Background()
+
+	owner, err := userService.CreateUser("owner@example.com", "password123", "Olive", "Owner")
+	assert.NoError(t, err)
+	invitee, err := userService.CreateUser("invitee@example.com", "password123", "Ivy", "Invitee")
+	assert.NoError(t, err)
+	stranger, err := userService.CreateUser("stranger@example.com", "password123", "", "")
+	assert.NoError(t, err)
+
+	workspace, err := workspaceService.CreateWorkspace(owner.ID, " Team ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Team", workspace.Name)
+	assert.Equal(t, models.WorkspaceRoleOwner, workspace.Role)
+
+	_, err = workspaceService.Invite(ctx, workspace.ID, owner, "invitee@example.com", models.WorkspaceRoleOwner)
+	assert.ErrorIs(t, err, services.ErrInvalidWorkspaceRole)
+	_, err = workspaceService.Invite(ctx, workspace.ID, stranger, "invitee@example.com", models.WorkspaceRoleMember)
+	assert.ErrorIs(t, err, services.ErrWorkspaceNotFound)
+
+	// Inviting the same address again renews the pending invitation
+	invitation, err := workspaceService.Invite(ctx, workspace.ID, owner, "Invitee@Example.com", models.WorkspaceRoleViewer)
+	assert.NoError(t, err)
+	assert.Equal(t, "invitee@example.com", invitation.Email)
+	renewed, err := workspaceService.Invite(ctx, workspace.ID, owner, "invitee@example.com", models.WorkspaceRoleMember)
+	assert.NoError(t, err)
+	assert.Equal(t, invitation.ID, renewed.ID)
+	assert.Equal(t, models.WorkspaceRoleMember, renewed.Role)
+
+	// Each invitation is emailed with a link to the pending invitations
+	if assert.Len(t, mailQueue.tasks, 2) {
+		assert.Equal(t, "invitee@example.com", mailQueue.tasks[1].Data["to"])
+		assert.Contains(t, mailQueue.tasks[1].Data["body"], "https://todo.example.com/invitations")
+		assert.Contains(t, mailQueue.tasks[1].Data["body"], "Olive invited you")
+	}
+
+	pending, err := workspaceService.GetUserInvitations(invitee)
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, "Team", pending[0].Workspace.Name)
+	}
+
+	// Only the invited user can answer
+	_, err = workspaceService.AcceptInvitation(invitation.ID, stranger)
+	assert.ErrorIs(t, err, services.ErrInvitationNotFound)
+
+	joined, err := workspaceService.AcceptInvitation(invitation.ID, invitee)
+	assert.NoError(t, err)
+	assert.Equal(t, models.WorkspaceRoleMember, joined.Role)
+	_, err = workspaceService.AcceptInvitation(invitation.ID, invitee)
+	assert.ErrorIs(t, err, services.ErrInvitationNotFound)
+
+	_, err = workspaceService.Invite(ctx, workspace.ID, owner, "invitee@example.com", models.WorkspaceRoleMember)
+	assert.ErrorIs(t, err, services.ErrAlreadyMember)
+
+	// Members cannot invite; declined invitations stop being pending
+	_, err = workspaceService.Invite(ctx, workspace.ID, invitee, "stranger@example.com", models.WorkspaceRoleViewer)
+	assert.ErrorIs(t, err, services.ErrWorkspaceForbidden)
+	declined, err := workspaceService.Invite(ctx, workspace.ID, owner, "stranger@example.com", models.WorkspaceRoleViewer)
+	assert.NoError(t, err)
+	assert.NoError(t, workspaceService.DeclineInvitation(declined.ID, stranger))
+	assert.ErrorIs(t, workspaceService.DeclineInvitation(declined.ID, stranger), services.ErrInvitationNotFound)
+
+	invitations, err := workspaceService.GetInvitations(workspace.ID, owner.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, invitations)
+}
+
+func TestWorkspaceMembers(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	workspaceService, _ := newWorkspaceService(repos)
+	userService := services.NewUserService(repos)
+	taskService := services.NewTaskService(repos)
+
+	owner, _ := userService.CreateUser("owner@example.com", "password123", "", "")
+	admin, _ := userService.CreateUser("admin@example.com", "password123", "", "")
+	member, _ := userService.CreateUser("member@example.com", "password123", "", "")
+
+	workspace, err := workspaceService.CreateWorkspace(owner.ID, "Team")
+	assert.NoError(t, err)
+	joinWorkspace(t, workspaceService, workspace.ID, owner, admin, models.WorkspaceRoleAdmin)
+	joinWorkspace(t, workspaceService, workspace.ID, admin, member, models.WorkspaceRoleMember)
+
+	members, err := workspaceService.GetMembers(workspace.ID, member.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, members, 3) {
+		assert.Equal(t, owner.ID, members[0].UserID)
+		assert.Equal(t, "member@example.com", members[2].User.Email)
+	}
+
+	// Admins manage lower roles only and cannot grant more than their own
+	_, err = workspaceService.UpdateMemberRole(workspace.ID, admin.ID, owner.ID, models.WorkspaceRoleViewer)
+	assert.ErrorIs(t, err, services.ErrWorkspaceForbidden)
+	_, err = workspaceService.UpdateMemberRole(workspace.ID, admin.ID, member.ID, models.WorkspaceRoleOwner)
+	assert.ErrorIs(t, err, services.ErrWorkspaceForbidden)
+	_, err = workspaceService.UpdateMemberRole(workspace.ID, member.ID, member.ID, models.WorkspaceRoleAdmin)
+	assert.ErrorIs(t, err, services.ErrWorkspaceForbidden)
+	_, err = workspaceService.UpdateMemberRole(workspace.ID, admin.ID, member.ID, "boss")
+	assert.ErrorIs(t, err, services.ErrInvalidWorkspaceRole)
+	updated, err := workspaceService.UpdateMemberRole(workspace.ID, admin.ID, member.ID, models.WorkspaceRoleViewer)
+	assert.NoError(t, err)
+	assert.Equal(t, models.WorkspaceRoleViewer, updated.Role)
+
+	// Removing a member unassigns their tasks
+	_, err = workspaceService.UpdateMemberRole(workspace.ID, admin.ID, member.ID, models.WorkspaceRoleMember)
+	assert.NoError(t, err)
+	task, err := taskService.CreateTask(owner.ID, services.TaskInput{Title: "Shared", WorkspaceID: &workspace.ID, AssigneeID: &member.ID})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, workspaceService.RemoveMember(workspace.ID, member.ID, admin.ID), services.ErrWorkspaceForbidden)
+	assert.NoError(t, workspaceService.RemoveMember(workspace.ID, admin.ID, member.ID))
+	task, err = repos.Tasks.FindByID(task.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, task.AssigneeID)
+	_, err = workspaceService.GetWorkspace(workspace.ID, member.ID)
+	assert.ErrorIs(t, err, services.ErrWorkspaceNotFound)
+
+	// The owner hands the workspace over before leaving
+	assert.ErrorIs(t, workspaceService.RemoveMember(workspace.ID, owner.ID, owner.ID), services.ErrOwnerCannotLeave)
+	assert.ErrorIs(t, workspaceService.DeleteWorkspace(workspace.ID, admin.ID), services.ErrWorkspaceForbidden)
+	_, err = workspaceService.UpdateMemberRole(workspace.ID, owner.ID, admin.ID, models.WorkspaceRoleOwner)
+	assert.NoError(t, err)
+	previous, err := workspaceService.GetWorkspace(workspace.ID, owner.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.WorkspaceRoleAdmin, previous.Role)
+	assert.NoError(t, workspaceService.RemoveMember(workspace.ID, owner.ID, owner.ID))
+
+	// Deleting the workspace deletes its tasks
+	assert.NoError(t, workspaceService.DeleteWorkspace(workspace.ID, admin.ID))
+	_, err = repos.Tasks.FindByID(task.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	workspaces, err := workspaceService.GetWorkspaces(admin.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, workspaces)
+}
+
+func TestTransferOwnershipKeepsOneOwner(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	workspaceService, _ := newWorkspaceService(repos)
+	userService := services.NewUserService(repos)
+
+	owner, _ := userService.CreateUser("owner@example.com", "password123", "", "")
+	first, _ := userService.CreateUser("first@example.com", "password123", "", "")
+	second, _ := userService.CreateUser("second@example.com", "password123", "", "")
+
+	workspace, err := workspaceService.CreateWorkspace(owner.ID, "Team")
+	assert.NoError(t, err)
+	joinWorkspace(t, workspaceService, workspace.ID, owner, first, models.WorkspaceRoleAdmin)
+	joinWorkspace(t, workspaceService, workspace.ID, owner, second, models.WorkspaceRoleAdmin)
+
+	// A second transfer that checked the owner before the first one
+	// completed changes nothing
+	assert.NoError(t, repos.Workspaces.TransferOwnership(workspace.ID, owner.ID, first.ID, time.Now()))
+	assert.ErrorIs(t, repos.Workspaces.TransferOwnership(workspace.ID, owner.ID, second.ID, time.Now()), repository.ErrNotFound)
+	_, err = workspaceService.UpdateMemberRole(workspace.ID, owner.ID, second.ID, models.WorkspaceRoleOwner)
+	assert.ErrorIs(t, err, services.ErrWorkspaceForbidden)
+
+	members, err := workspaceService.GetMembers(workspace.ID, owner.ID)
+	assert.NoError(t, err)
+	owners := []uuid.UUID{}
+	for _, member := range members {
+		if member.Role == models.WorkspaceRoleOwner {
+			owners = append(owners, member.UserID)
+		}
+	}
+	assert.Equal(t, []uuid.UUID{first.ID}, owners)
+}
+
+func TestWorkspaceTasks(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	workspaceService, _ := newWorkspaceService(repos)
+	userService := services.NewUserService(repos)
+	taskService := services.NewTaskService(repos)
+	projectService := services.NewProjectService(repos)
+
+	owner, _ := userService.CreateUser("owner@example.com", "password123", "", "")
+	member, _ := userService.CreateUser("member@example.com", "password123", "", "")
+	viewer, _ := userService.CreateUser("viewer@example.com", "password123", "", "")
+	stranger, _ := userService.CreateUser("stranger@example.com", "password123", "", "")
+
+	workspace, err := workspaceService.CreateWorkspace(owner.ID, "Team")
+	assert.NoError(t, err)
+	joinWorkspace(t, workspaceService, workspace.ID, owner, member, models.WorkspaceRoleMember)
+	joinWorkspace(t, workspaceService, workspace.ID, owner, viewer, models.WorkspaceRoleViewer)
+
+	_, err = taskService.CreateTask(viewer.ID, services.TaskInput{Title: "Nope", WorkspaceID: &workspace.ID})
+	assert.ErrorIs(t, err, services.ErrWorkspaceForbidden)
+	_, err = taskService.CreateTask(stranger.ID, services.TaskInput{Title: "Nope", WorkspaceID: &workspace.ID})
+	assert.ErrorIs(t, err, services.ErrWorkspaceNotFound)
+	_, err = taskService.CreateTask(owner.ID, services.TaskInput{Title: "Nope", WorkspaceID: &workspace.ID, AssigneeID: &viewer.ID})
+	assert.ErrorIs(t, err, services.ErrInvalidAssignee)
+
+	task, err := taskService.CreateTask(owner.ID, services.TaskInput{Title: "Team task", WorkspaceID: &workspace.ID, AssigneeID: &member.ID})
+	assert.NoError(t, err)
+	personal, err := taskService.CreateTask(member.ID, services.TaskInput{Title: "Personal", AssigneeID: &member.ID})
+	assert.NoError(t, err)
+	_, err = taskService.CreateTask(member.ID, services.TaskInput{Title: "Nope", AssigneeID: &owner.ID})
+	assert.ErrorIs(t, err, services.ErrInvalidAssignee)
+
+	// Members edit workspace tasks, viewers only read them, others do not see them
+	_, err = taskService.UpdateTask(task.ID, member.ID, services.TaskInput{Status: "in_progress", Priority: -1})
+	assert.NoError(t, err)
+	_, err = taskService.GetTaskByID(task.ID, viewer.ID)
+	assert.NoError(t, err)
+	_, err = taskService.UpdateTask(task.ID, viewer.ID, services.TaskInput{Title: "Renamed", Priority: -1})
+	assert.ErrorIs(t, err, services.ErrWorkspaceForbidden)
+	assert.ErrorIs(t, taskService.DeleteTask(task.ID, viewer.ID), services.ErrWorkspaceForbidden)
+	_, err = taskService.GetTaskByID(task.ID, stranger.ID)
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+
+	// Workspace and personal tasks do not mix
+	project, err := projectService.CreateProject(owner.ID, services.ProjectInput{Name: stringPtr("Mine")})
+	assert.NoError(t, err)
+	_, err = taskService.UpdateTask(task.ID, owner.ID, services.TaskInput{Priority: -1, ProjectID: &project.ID})
+	assert.ErrorIs(t, err, services.ErrProjectInWorkspace)
+	_, err = taskService.UpdateTask(personal.ID, member.ID, services.TaskInput{Priority: -1, ParentID: &task.ID})
+	assert.ErrorIs(t, err, services.ErrParentNotFound)
+	_, err = taskService.AddDependency(task.ID, member.ID, personal.ID)
+	assert.ErrorIs(t, err, services.ErrTaskNotFound)
+	subtask, err := taskService.CreateTask(member.ID, services.TaskInput{Title: "Step", WorkspaceID: &workspace.ID, ParentID: &task.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, task.ID, *subtask.ParentID)
+
+	// Workspace tasks are listed per workspace, not with personal tasks
+	tasks, err := taskService.GetTasks(repository.TaskFilter{UserID: member.ID, Priority: -1})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+	tasks, err = taskService.GetTasks(repository.TaskFilter{UserID: member.ID, Priority: -1, WorkspaceID: &workspace.ID})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+
+	// assignee=me covers personal and workspace tasks
+	tasks, err = taskService.GetTasks(repository.TaskFilter{UserID: member.ID, Priority: -1, AssigneeID: &member.ID})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+	tasks, err = taskService.GetTasks(repository.TaskFilter{UserID: member.ID, Priority: -1, WorkspaceID: &workspace.ID, AssigneeID: &member.ID})
+	assert.NoError(t, err)
+	if assert.Len(t, tasks, 1) {
+		assert.Equal(t, task.ID, tasks[0].ID)
+	}
+
+	unassigned, err := taskService.UpdateTask(task.ID, owner.ID, services.TaskInput{Priority: -1, AssigneeID: &uuid.Nil})
+	assert.NoError(t, err)
+	assert.Nil(t, unassigned.AssigneeID)
+}
+
+func TestDeleteAccountOwningWorkspace(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	accountService, _, _ := newAccountService(t, repos, false)
+	workspaceService, _ := newWorkspaceService(repos)
+	userService := services.NewUserService(repos)
+	taskService := services.NewTaskService(repos)
+	ctx := context.Background()
+
+	owner, _ := userService.CreateUser("owner@example.com", "password123", "", "")
+	member, _ := userService.CreateUser("member@example.com", "password123", "", "")
+	shared, err := workspaceService.CreateWorkspace(owner.ID, "Shared")
+	assert.NoError(t, err)
+	joinWorkspace(t, workspaceService, shared.ID, owner, member, models.WorkspaceRoleMember)
+	solo, err := workspaceService.CreateWorkspace(member.ID, "Solo")
+	assert.NoError(t, err)
+
+	sharedTask, err := taskService.CreateTask(member.ID, services.TaskInput{Title: "Team work", WorkspaceID: &shared.ID, AssigneeID: &member.ID})
+	assert.NoError(t, err)
+	soloTask, err := taskService.CreateTask(member.ID, services.TaskInput{Title: "Solo work", WorkspaceID: &solo.ID})
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, accountService.DeleteAccount(ctx, owner.ID, "password123"), services.ErrOwnsWorkspaces)
+
+	// Workspace tasks outlive their creator's account; once it is purged
+	// the member has left and workspaces of their own are gone
+	assert.NoError(t, accountService.DeleteAccount(ctx, member.ID, "password123"))
+	_, err = repos.Tasks.FindByID(sharedTask.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, repos.Users.Purge(member.ID))
+
+	sharedTask, err = repos.Tasks.FindByID(sharedTask.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, sharedTask.AssigneeID)
+	_, err = repos.Tasks.FindByID(soloTask.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repos.Workspaces.FindByID(solo.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	members, err := workspaceService.GetMembers(shared.ID, owner.ID)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+
+	assert.NoError(t, accountService.DeleteAccount(ctx, owner.ID, "password123"))
+}
@@ -3,60 +3,105 @@ package integration_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"github.com/jaimesHub/golang-todo-app/internal/config"
-	"github.com/jaimesHub/golang-todo-app/internal/handlers"
+	"github.com/jaimesHub/golang-todo-app/internal/logger"
 	"github.com/jaimesHub/golang-todo-app/internal/middleware"
 	"github.com/jaimesHub/golang-todo-app/internal/models"
+	"github.com/jaimesHub/golang-todo-app/internal/repository"
+	"github.com/jaimesHub/golang-todo-app/internal/routes"
 	"github.com/jaimesHub/golang-todo-app/internal/services"
 	"github.com/jaimesHub/golang-todo-app/internal/services/auth"
+	"github.com/jaimesHub/golang-todo-app/internal/services/storage"
+	"github.com/jaimesHub/golang-todo-app/internal/services/totp"
 	"github.com/stretchr/testify/assert"
 )
 
-func setupTestRouter() (*gin.Engine, *services.UserService, *auth.JWTService) {
+func setupTestRouter(t *testing.T) (*gin.Engine, *services.UserService, *auth.JWTService) {
+	router, _, userService, jwtService := setupTestRouterWithRepos(t)
+	return router, userService, jwtService
+}
+
+// setupTestRouterWithRepos is setupTestRouter for tests that need to reach
+// the repositories directly
+func setupTestRouterWithRepos(t *testing.T) (*gin.Engine, *repository.Repositories, *services.UserService, *auth.JWTService) {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
 
 	// Create a test router
-	router := gin.Default()
-
-	// Create a mock DB (in a real test, you would use a test database)
-	// For simplicity, we'll use nil here and mock the service methods
-	db := nil
-
-	// Create services
-	userService := services.NewUserService(db)
-	jwtService := auth.NewJWTService(&config.JWTConfig{
-		Secret:     "test-secret-key",
-		Expiration: 24,
-	})
-
-	// Create handlers
-	userHandler := handlers.NewUserHandler(userService)
-	authHandler := handlers.NewAuthHandler(userService, jwtService)
-
-	// Setup routes
-	router.POST("/api/v1/auth/register", userHandler.Register)
-	router.POST("/api/v1/auth/login", authHandler.Login)
-
-	// Protected routes
-	protected := router.Group("/api/v1")
-	protected.Use(middleware.AuthMiddleware(jwtService))
-	{
-		protected.GET("/users/me", userHandler.GetProfile)
+	router := gin.New()
+
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:          "test-secret-key",
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: time.Hour,
+		},
+		Login: config.LoginConfig{
+			MaxAttemptsPerEmail: 3,
+			MaxAttemptsPerIP:    20,
+			AttemptWindow:       15 * time.Minute,
+			LockoutDuration:     15 * time.Minute,
+		},
+		TwoFactor: config.TwoFactorConfig{
+			Issuer:       "Todo App",
+			ChallengeTTL: 5 * time.Minute,
+		},
+		Workspace: config.WorkspaceConfig{
+			InvitationTTL: time.Hour,
+		},
+		Attachment: config.AttachmentConfig{
+			MaxSize:      1 << 20,
+			AllowedTypes: []string{"image/*", "application/pdf", "text/plain"},
+		},
 	}
 
-	return router, userService, jwtService
+	appLogger, err := logger.NewLogger(config.LoggingConfig{Level: "error"})
+	assert.NoError(t, err)
+
+	// Use the in-memory repositories so no database is required
+	repos := repository.NewMemoryRepositories()
+
+	jwtService, err := auth.NewJWTService(&cfg.JWT)
+	assert.NoError(t, err)
+
+	fileStorage, err := storage.NewLocalStorage(t.TempDir())
+	assert.NoError(t, err)
+
+	// Setup middleware and routes
+	assert.NoError(t, middleware.Setup(router, cfg, jwtService, appLogger))
+	routes.Register(router, repos, nil, nil, fileStorage, jwtService, cfg, appLogger)
+
+	return router, repos, services.NewUserService(repos), jwtService
+}
+
+// performRequest sends a JSON request to the router and decodes the JSON response
+func performRequest(t *testing.T, router *gin.Engine, method, path, body, token string) (int, map[string]interface{}) {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(resp.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	return resp.Code, response
 }
 
 func TestUserRegistrationAndLogin(t *testing.T) {
 	// Setup
-	router, _, _ := setupTestRouter()
+	router, _, _ := setupTestRouter(t)
 
 	// Test registration
 	registrationPayload := `{
@@ -66,17 +111,10 @@ func TestUserRegistrationAndLogin(t *testing.T) {
 		"last_name": "User"
 	}`
 
-	req, _ := http.NewRequest("POST", "/api/v1/auth/register", bytes.NewBufferString(registrationPayload))
-	req.Header.Set("Content-Type", "application/json")
-	resp := httptest.NewRecorder()
-	router.ServeHTTP(resp, req)
+	code, registrationResponse := performRequest(t, router, "POST", "/api/v1/auth/register", registrationPayload, "")
 
 	// Assert registration response
-	assert.Equal(t, http.StatusCreated, resp.Code)
-
-	var registrationResponse map[string]interface{}
-	err := json.Unmarshal(resp.Body.Bytes(), &registrationResponse)
-	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, code)
 	assert.Equal(t, "User registered successfully", registrationResponse["message"])
 
 	// Test login
@@ -85,56 +123,805 @@ func TestUserRegistrationAndLogin(t *testing.T) {
 		"password": "password123"
 	}`
 
-	req, _ = http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBufferString(loginPayload))
+	code, loginResponse := performRequest(t, router, "POST", "/api/v1/auth/login", loginPayload, "")
+
+	// Assert login response
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Login successful", loginResponse["message"])
+	assert.NotEmpty(t, loginResponse["token"])
+
+	// Test login with a wrong password
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/login", `{"email": "test@example.com", "password": "wrong"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+// login logs a user in and returns the access and refresh tokens
+func login(t *testing.T, router *gin.Engine, email string) (string, string) {
+	code, response := performRequest(t, router, "POST", "/api/v1/auth/login", `{"email": "`+email+`", "password": "password123"}`, "")
+	assert.Equal(t, http.StatusOK, code)
+	return response["token"].(string), response["refresh_token"].(string)
+}
+
+func TestRefreshRotationAndLogout(t *testing.T) {
+	router, userService, _ := setupTestRouter(t)
+	_, err := userService.CreateUser("session@example.com", "password123", "Session", "User")
+	assert.NoError(t, err)
+
+	// Refreshing rotates the refresh token
+	_, refreshToken := login(t, router, "session@example.com")
+	code, refreshed := performRequest(t, router, "POST", "/api/v1/auth/refresh", `{"refresh_token": "`+refreshToken+`"}`, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, refreshed["token"])
+	assert.NotEqual(t, refreshToken, refreshed["refresh_token"])
+
+	// Reusing the old refresh token revokes the whole family
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/refresh", `{"refresh_token": "`+refreshToken+`"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/refresh", `{"refresh_token": "`+refreshed["refresh_token"].(string)+`"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// Logging out revokes the access token and its refresh token
+	accessToken, refreshToken := login(t, router, "session@example.com")
+	otherAccessToken, _ := login(t, router, "session@example.com")
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/logout", `{"refresh_token": "`+refreshToken+`"}`, accessToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/users/me", "", accessToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/refresh", `{"refresh_token": "`+refreshToken+`"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/users/me", "", otherAccessToken)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Logging out everywhere revokes every session
+	_, otherRefreshToken := login(t, router, "session@example.com")
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/logout-all", "", otherAccessToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/users/me", "", otherAccessToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/refresh", `{"refresh_token": "`+otherRefreshToken+`"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestLoginLockout(t *testing.T) {
+	router, userService, _ := setupTestRouter(t)
+	user, err := userService.CreateUser("lockout@example.com", "password123", "Lockout", "User")
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		code, _ := performRequest(t, router, "POST", "/api/v1/auth/login", `{"email": "lockout@example.com", "password": "wrong"}`, "")
+		assert.Equal(t, http.StatusUnauthorized, code)
+	}
+
+	// Even the right password is refused during the lockout
+	req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBufferString(`{"email": "lockout@example.com", "password": "password123"}`))
 	req.Header.Set("Content-Type", "application/json")
-	resp = httptest.NewRecorder()
+	resp := httptest.NewRecorder()
 	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "900", resp.Header().Get("Retry-After"))
 
-	// Assert login response
-	assert.Equal(t, http.StatusOK, resp.Code)
+	// The lockout is recorded on the account
+	activities, err := userService.GetUserActivities(user.ID, 10, 0)
+	assert.NoError(t, err)
+	var locked bool
+	for _, activity := range activities {
+		locked = locked || activity.Action == "login_locked"
+	}
+	assert.True(t, locked)
 
-	var loginResponse map[string]interface{}
-	err = json.Unmarshal(resp.Body.Bytes(), &loginResponse)
+	// Other accounts can still log in
+	_, err = userService.CreateUser("other@example.com", "password123", "Other", "User")
 	assert.NoError(t, err)
-	assert.Equal(t, "Login successful", loginResponse["message"])
-	assert.NotEmpty(t, loginResponse["token"])
+	login(t, router, "other@example.com")
+}
+
+func TestLoginLockoutIgnoresForwardedFor(t *testing.T) {
+	router, _, _ := setupTestRouter(t)
+
+	// Without trusted proxies a new X-Forwarded-For per attempt does not
+	// count as a new client
+	for i := 0; i <= 20; i++ {
+		body := fmt.Sprintf(`{"email": "spoof%d@example.com", "password": "wrong"}`, i)
+		req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.1:40000"
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i+1))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if i < 20 {
+			assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		} else {
+			assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		}
+	}
+}
+
+func TestChangePasswordAndDeleteAccount(t *testing.T) {
+	router, userService, _ := setupTestRouter(t)
+	_, err := userService.CreateUser("account@example.com", "password123", "Account", "User")
+	assert.NoError(t, err)
+	accessToken, _ := login(t, router, "account@example.com")
+
+	code, _ := performRequest(t, router, "PUT", "/api/v1/users/me/password", `{"current_password": "wrong", "new_password": "new-password"}`, accessToken)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// Changing the password ends the old session and returns a new one
+	code, response := performRequest(t, router, "PUT", "/api/v1/users/me/password", `{"current_password": "password123", "new_password": "new-password"}`, accessToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/users/me", "", accessToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	accessToken = response["token"].(string)
+
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/login", `{"email": "account@example.com", "password": "password123"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// Deleting the account ends its sessions and blocks login
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/users/me", `{"password": "new-password"}`, accessToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/users/me", "", accessToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/login", `{"email": "account@example.com", "password": "new-password"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	router, repos, userService, _ := setupTestRouterWithRepos(t)
+	user, err := userService.CreateUser("2fa@example.com", "password123", "Two", "Factor")
+	assert.NoError(t, err)
+	accessToken, _ := login(t, router, "2fa@example.com")
+
+	// Enroll and confirm with the first code
+	code, enrollment := performRequest(t, router, "POST", "/api/v1/users/me/2fa/enroll", `{"password": "password123"}`, accessToken)
+	assert.Equal(t, http.StatusOK, code)
+	secret := enrollment["secret"].(string)
+	counter := totp.Counter(time.Now())
+	first, _ := totp.Code(secret, counter-1)
+	code, confirmed := performRequest(t, router, "POST", "/api/v1/users/me/2fa/confirm", `{"code": "`+first+`"}`, accessToken)
+	assert.Equal(t, http.StatusOK, code)
+	recoveryCodes := confirmed["recovery_codes"].([]interface{})
+	assert.Len(t, recoveryCodes, 10)
+
+	code, status := performRequest(t, router, "GET", "/api/v1/users/me/2fa", "", accessToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, status["enabled"])
+
+	// The password alone only yields a challenge
+	code, challenge := performRequest(t, router, "POST", "/api/v1/auth/login", `{"email": "2fa@example.com", "password": "password123"}`, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, challenge["two_factor_required"])
+	assert.Nil(t, challenge["token"])
+	challengeToken := challenge["challenge_token"].(string)
+
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/login/2fa", `{"challenge_token": "`+challengeToken+`", "code": "`+first+`"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	current, _ := totp.Code(secret, counter)
+	code, session := performRequest(t, router, "POST", "/api/v1/auth/login/2fa", `{"challenge_token": "`+challengeToken+`", "code": "`+current+`"}`, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, session["token"])
+
+	// Accounts deactivated between the two steps get no session
+	_, challenge = performRequest(t, router, "POST", "/api/v1/auth/login", `{"email": "2fa@example.com", "password": "password123"}`, "")
+	stored, err := repos.Users.FindByID(user.ID)
+	assert.NoError(t, err)
+	stored.IsActive = false
+	assert.NoError(t, repos.Users.Update(stored))
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/login/2fa", `{"challenge_token": "`+challenge["challenge_token"].(string)+`", "code": "`+recoveryCodes[1].(string)+`"}`, "")
+	assert.Equal(t, http.StatusForbidden, code)
+	stored.IsActive = true
+	assert.NoError(t, repos.Users.Update(stored))
+
+	// Disabling takes the password and a recovery code
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/users/me/2fa", `{"password": "password123", "code": "`+recoveryCodes[0].(string)+`"}`, session["token"].(string))
+	assert.Equal(t, http.StatusOK, code)
+	login(t, router, "2fa@example.com")
+}
+
+func TestAPITokens(t *testing.T) {
+	router, userService, _ := setupTestRouter(t)
+	_, err := userService.CreateUser("pat@example.com", "password123", "Pat", "User")
+	assert.NoError(t, err)
+	accessToken, _ := login(t, router, "pat@example.com")
+
+	code, _ := performRequest(t, router, "POST", "/api/v1/users/me/tokens/", `{"name": "CI", "scopes": ["tasks:admin"]}`, accessToken)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, created := performRequest(t, router, "POST", "/api/v1/users/me/tokens/", `{"name": "CI", "scopes": ["tasks:read"]}`, accessToken)
+	assert.Equal(t, http.StatusCreated, code)
+	readToken := created["token"].(string)
+	tokenID := created["api_token"].(map[string]interface{})["id"].(string)
+
+	// The token reads tasks but cannot write them or reach other groups
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/", "", readToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "From CI"}`, readToken)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/projects/", "", readToken)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// Tokens cannot manage tokens, even with the user scope
+	_, created = performRequest(t, router, "POST", "/api/v1/users/me/tokens/", `{"name": "Admin", "scopes": ["user:write", "tasks:write"]}`, accessToken)
+	writeToken := created["token"].(string)
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "From CI"}`, writeToken)
+	assert.Equal(t, http.StatusCreated, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/users/me", "", writeToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/users/me/tokens/", "", writeToken)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, listed := performRequest(t, router, "GET", "/api/v1/users/me/tokens/", "", accessToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, listed["api_tokens"], 2)
+
+	// Deleting a token revokes it
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/users/me/tokens/"+tokenID, "", accessToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/", "", readToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestAdminAPI(t *testing.T) {
+	router, repos, userService, jwtService := setupTestRouterWithRepos(t)
+	admin, err := userService.CreateUser("admin@example.com", "password123", "Ada", "Admin")
+	assert.NoError(t, err)
+	admin.Role = models.RoleAdmin
+	assert.NoError(t, repos.Users.Update(admin))
+	user, err := userService.CreateUser("member@example.com", "password123", "Mem", "Ber")
+	assert.NoError(t, err)
+
+	adminToken, _ := login(t, router, "admin@example.com")
+	userToken, _ := login(t, router, "member@example.com")
+
+	// Regular users have no admin permissions
+	code, _ := performRequest(t, router, "GET", "/api/v1/admin/users", "", userToken)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, listed := performRequest(t, router, "GET", "/api/v1/admin/users?q=member", "", adminToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, listed["users"], 1)
+
+	code, _ = performRequest(t, router, "GET", "/api/v1/admin/users/"+user.ID.String()+"/activities", "", adminToken)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Deactivating ends the user's sessions and refuses any other token.
+	// Revocation has millisecond precision, so the token must be older.
+	time.Sleep(2 * time.Millisecond)
+	code, _ = performRequest(t, router, "POST", "/api/v1/admin/users/"+user.ID.String()+"/deactivate", "", adminToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/users/me", "", userToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	lateToken, _ := jwtService.GenerateToken(user.ID)
+	code, _ = performRequest(t, router, "GET", "/api/v1/users/me", "", lateToken)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/auth/login", `{"email": "member@example.com", "password": "password123"}`, "")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = performRequest(t, router, "POST", "/api/v1/admin/users/"+user.ID.String()+"/reactivate", "", adminToken)
+	assert.Equal(t, http.StatusOK, code)
+	userToken, _ = login(t, router, "member@example.com")
+
+	// A custom role grants only its permissions
+	code, _ = performRequest(t, router, "POST", "/api/v1/admin/roles", `{"name": "support", "permissions": ["users:read"]}`, adminToken)
+	assert.Equal(t, http.StatusCreated, code)
+	code, _ = performRequest(t, router, "PUT", "/api/v1/admin/users/"+user.ID.String()+"/role", `{"role": "support"}`, adminToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/admin/users", "", userToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/admin/users/"+admin.ID.String()+"/logout", "", userToken)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// Forced logout ends the sessions of the user
+	time.Sleep(2 * time.Millisecond)
+	code, _ = performRequest(t, router, "POST", "/api/v1/admin/users/"+user.ID.String()+"/logout", "", adminToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/users/me", "", userToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
 }
 
 func TestProtectedEndpoint(t *testing.T) {
 	// Setup
-	router, userService, jwtService := setupTestRouter()
+	router, userService, jwtService := setupTestRouter(t)
 
 	// Create a test user and generate a token
-	userID := uuid.New()
-	token, _ := jwtService.GenerateToken(userID)
-
-	// Mock the GetUserByID method
-	// In a real test, you would use a test database or a proper mock
-	// This is a simplified example
-	userService.GetUserByID = func(id uuid.UUID) (*models.User, error) {
-		return &models.User{
-			ID:        userID,
-			Email:     "test@example.com",
-			FirstName: "Test",
-			LastName:  "User",
-		}, nil
-	}
+	testUser, err := userService.CreateUser("test@example.com", "password123", "Test", "User")
+	assert.NoError(t, err)
+	token, _ := jwtService.GenerateToken(testUser.ID)
+
+	// Test accessing a protected endpoint without a token
+	code, _ := performRequest(t, router, "GET", "/api/v1/users/me", "", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
 
 	// Test accessing a protected endpoint
-	req, _ := http.NewRequest("GET", "/api/v1/users/me", nil)
+	code, profileResponse := performRequest(t, router, "GET", "/api/v1/users/me", "", token)
+
+	// Assert response
+	assert.Equal(t, http.StatusOK, code)
+
+	user := profileResponse["user"].(map[string]interface{})
+	assert.Equal(t, "test@example.com", user["email"])
+	assert.Equal(t, "Test", user["first_name"])
+	assert.Equal(t, "User", user["last_name"])
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	router, userService, jwtService := setupTestRouter(t)
+
+	testUser, err := userService.CreateUser("tasks@example.com", "password123", "Task", "Owner")
+	assert.NoError(t, err)
+	token, _ := jwtService.GenerateToken(testUser.ID)
+
+	// Create
+	code, created := performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Write tests", "priority": 2}`, token)
+	assert.Equal(t, http.StatusCreated, code)
+	taskID := created["task"].(map[string]interface{})["id"].(string)
+
+	// List
+	code, listed := performRequest(t, router, "GET", "/api/v1/tasks/", "", token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, listed["tasks"], 1)
+	assert.Equal(t, float64(1), listed["pagination"].(map[string]interface{})["total"])
+
+	// Update
+	code, updated := performRequest(t, router, "PUT", "/api/v1/tasks/"+taskID, `{"status": "completed", "priority": 2}`, token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "completed", updated["task"].(map[string]interface{})["status"])
+
+	// Another user cannot read it
+	otherUser, err := userService.CreateUser("other@example.com", "password123", "Other", "User")
+	assert.NoError(t, err)
+	otherToken, _ := jwtService.GenerateToken(otherUser.ID)
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID, "", otherToken)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// Delete
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/tasks/"+taskID, "", token)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID, "", token)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// Activities were logged for the task operations
+	code, activities := performRequest(t, router, "GET", "/api/v1/users/activities?limit=50", "", token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, activities["activities"], 4)
+}
+
+func TestTagFiltering(t *testing.T) {
+	router, userService, jwtService := setupTestRouter(t)
+
+	testUser, err := userService.CreateUser("tags@example.com", "password123", "Tag", "User")
+	assert.NoError(t, err)
+	token, _ := jwtService.GenerateToken(testUser.ID)
+
+	code, created := performRequest(t, router, "POST", "/api/v1/tags/", `{"name": "bug", "color": "#ff0000"}`, token)
+	assert.Equal(t, http.StatusCreated, code)
+	bugID := created["tag"].(map[string]interface{})["id"].(string)
+
+	_, first := performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Crash on save"}`, token)
+	firstID := first["task"].(map[string]interface{})["id"].(string)
+	_, second := performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Slow login"}`, token)
+	secondID := second["task"].(map[string]interface{})["id"].(string)
+
+	// Attach by ID and by name (creating the "urgent" tag)
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/"+firstID+"/tags", `{"tag_id": "`+bugID+`"}`, token)
+	assert.Equal(t, http.StatusOK, code)
+	code, attached := performRequest(t, router, "POST", "/api/v1/tasks/"+firstID+"/tags", `{"name": "urgent"}`, token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, attached["task"].(map[string]interface{})["tags"], 2)
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/"+secondID+"/tags", `{"tag_id": "`+bugID+`"}`, token)
+	assert.Equal(t, http.StatusOK, code)
+
+	_, listed := performRequest(t, router, "GET", "/api/v1/tasks/?tags=bug,urgent", "", token)
+	assert.Len(t, listed["tasks"], 2)
+
+	_, listed = performRequest(t, router, "GET", "/api/v1/tasks/?tags=bug,urgent&tag_match=all", "", token)
+	assert.Len(t, listed["tasks"], 1)
+
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/?tags=bug&tag_match=some", "", token)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/tasks/"+secondID+"/tags/"+bugID, "", token)
+	assert.Equal(t, http.StatusOK, code)
+	_, listed = performRequest(t, router, "GET", "/api/v1/tasks/?tags=bug", "", token)
+	assert.Len(t, listed["tasks"], 1)
+
+	_, tags := performRequest(t, router, "GET", "/api/v1/tags/", "", token)
+	assert.Len(t, tags["tags"], 2)
+}
+
+func TestProjectTasks(t *testing.T) {
+	router, userService, jwtService := setupTestRouter(t)
+
+	testUser, err := userService.CreateUser("projects@example.com", "password123", "Project", "User")
+	assert.NoError(t, err)
+	token, _ := jwtService.GenerateToken(testUser.ID)
+
+	code, created := performRequest(t, router, "POST", "/api/v1/projects/", `{"name": "Work", "color": "#0000ff"}`, token)
+	assert.Equal(t, http.StatusCreated, code)
+	projectID := created["project"].(map[string]interface{})["id"].(string)
+
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Quarterly report", "priority": 2, "project_id": "`+projectID+`"}`, token)
+	assert.Equal(t, http.StatusCreated, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Expenses", "project_id": "`+projectID+`"}`, token)
+	assert.Equal(t, http.StatusCreated, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Groceries"}`, token)
+	assert.Equal(t, http.StatusCreated, code)
+
+	// Project task list reuses the task filters
+	code, listed := performRequest(t, router, "GET", "/api/v1/projects/"+projectID+"/tasks?priority=2", "", token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, listed["tasks"], 1)
+
+	// Archiving hides the project's tasks from the default list
+	code, _ = performRequest(t, router, "PUT", "/api/v1/projects/"+projectID, `{"archived": true}`, token)
+	assert.Equal(t, http.StatusOK, code)
+	_, listed = performRequest(t, router, "GET", "/api/v1/tasks/", "", token)
+	assert.Len(t, listed["tasks"], 1)
+	_, listed = performRequest(t, router, "GET", "/api/v1/tasks/?include_archived=true", "", token)
+	assert.Len(t, listed["tasks"], 3)
+	_, listed = performRequest(t, router, "GET", "/api/v1/projects/", "", token)
+	assert.Len(t, listed["projects"], 0)
+
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Too late", "project_id": "`+projectID+`"}`, token)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestTaskDependencies(t *testing.T) {
+	router, userService, jwtService := setupTestRouter(t)
+
+	testUser, err := userService.CreateUser("deps@example.com", "password123", "Dependency", "User")
+	assert.NoError(t, err)
+	token, _ := jwtService.GenerateToken(testUser.ID)
+
+	_, first := performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Write spec"}`, token)
+	firstID := first["task"].(map[string]interface{})["id"].(string)
+	_, second := performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Implement spec"}`, token)
+	secondID := second["task"].(map[string]interface{})["id"].(string)
+
+	code, _ := performRequest(t, router, "POST", "/api/v1/tasks/"+secondID+"/dependencies", `{"blocked_by_id": "`+firstID+`"}`, token)
+	assert.Equal(t, http.StatusCreated, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/"+firstID+"/dependencies", `{"blocked_by_id": "`+secondID+`"}`, token)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = performRequest(t, router, "PUT", "/api/v1/tasks/"+secondID, `{"status": "in_progress", "priority": -1}`, token)
+	assert.Equal(t, http.StatusConflict, code)
+
+	_, listed := performRequest(t, router, "GET", "/api/v1/tasks/?blocked=true", "", token)
+	assert.Len(t, listed["tasks"], 1)
+	_, listed = performRequest(t, router, "GET", "/api/v1/tasks/?blocked=false", "", token)
+	assert.Len(t, listed["tasks"], 1)
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/?blocked=maybe", "", token)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, removed := performRequest(t, router, "DELETE", "/api/v1/tasks/"+secondID+"/dependencies/"+firstID, "", token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, removed["dependencies"].(map[string]interface{})["blocked_by"], 0)
+	code, _ = performRequest(t, router, "PUT", "/api/v1/tasks/"+secondID, `{"status": "in_progress", "priority": -1}`, token)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestWorkspaceAPI(t *testing.T) {
+	router, userService, _ := setupTestRouter(t)
+	owner, err := userService.CreateUser("owner@example.com", "password123", "Olive", "Owner")
+	assert.NoError(t, err)
+	_, err = userService.CreateUser("viewer@example.com", "password123", "Vic", "Viewer")
+	assert.NoError(t, err)
+	ownerToken, _ := login(t, router, "owner@example.com")
+	viewerToken, _ := login(t, router, "viewer@example.com")
+
+	code, response := performRequest(t, router, "POST", "/api/v1/workspaces/", `{"name": "Team"}`, ownerToken)
+	assert.Equal(t, http.StatusCreated, code)
+	workspaceID := response["workspace"].(map[string]interface{})["id"].(string)
+
+	// Only members see the workspace and its tasks
+	code, _ = performRequest(t, router, "GET", "/api/v1/workspaces/"+workspaceID, "", viewerToken)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/?workspace_id="+workspaceID, "", viewerToken)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// The invited user accepts from their list of invitations
+	code, _ = performRequest(t, router, "POST", "/api/v1/workspaces/"+workspaceID+"/invitations", `{"email": "viewer@example.com", "role": "viewer"}`, ownerToken)
+	assert.Equal(t, http.StatusCreated, code)
+	code, response = performRequest(t, router, "GET", "/api/v1/invitations/", "", viewerToken)
+	assert.Equal(t, http.StatusOK, code)
+	invitations := response["invitations"].([]interface{})
+	if !assert.Len(t, invitations, 1) {
+		return
+	}
+	invitationID := invitations[0].(map[string]interface{})["id"].(string)
+	code, _ = performRequest(t, router, "POST", "/api/v1/invitations/"+invitationID+"/accept", "", ownerToken)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, response = performRequest(t, router, "POST", "/api/v1/invitations/"+invitationID+"/accept", "", viewerToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "viewer", response["workspace"].(map[string]interface{})["role"])
+
+	code, response = performRequest(t, router, "GET", "/api/v1/workspaces/"+workspaceID+"/members", "", viewerToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["members"], 2)
+
+	// Workspace tasks can be read but not changed by viewers
+	code, response = performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Team task", "workspace_id": "`+workspaceID+`", "assignee_id": "`+owner.ID.String()+`"}`, ownerToken)
+	assert.Equal(t, http.StatusCreated, code)
+	taskID := response["task"].(map[string]interface{})["id"].(string)
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Viewer task", "workspace_id": "`+workspaceID+`"}`, viewerToken)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID, "", viewerToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "PUT", "/api/v1/tasks/"+taskID, `{"status": "completed", "priority": -1}`, viewerToken)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/tasks/"+taskID, "", viewerToken)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, response = performRequest(t, router, "GET", "/api/v1/tasks/?workspace_id="+workspaceID, "", viewerToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["tasks"], 1)
+	code, response = performRequest(t, router, "GET", "/api/v1/tasks/?assignee=me", "", ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["tasks"], 1)
+	code, response = performRequest(t, router, "GET", "/api/v1/tasks/", "", ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["tasks"], 0)
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/?assignee="+owner.ID.String(), "", viewerToken)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Viewers leave on their own; the owner cannot
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/workspaces/"+workspaceID+"/members/"+owner.ID.String(), "", ownerToken)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/workspaces/"+workspaceID, "", viewerToken)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, response = performRequest(t, router, "GET", "/api/v1/users/me", "", viewerToken)
+	assert.Equal(t, http.StatusOK, code)
+	viewerID := response["user"].(map[string]interface{})["id"].(string)
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/workspaces/"+workspaceID+"/members/"+viewerID, "", viewerToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID, "", viewerToken)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/workspaces/"+workspaceID, "", ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID, "", ownerToken)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestSharingAPI(t *testing.T) {
+	router, userService, _ := setupTestRouter(t)
+	_, err := userService.CreateUser("owner@example.com", "password123", "Olive", "Owner")
+	assert.NoError(t, err)
+	friend, err := userService.CreateUser("friend@example.com", "password123", "Fay", "Friend")
+	assert.NoError(t, err)
+	ownerToken, _ := login(t, router, "owner@example.com")
+	friendToken, _ := login(t, router, "friend@example.com")
+
+	code, response := performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Plan trip"}`, ownerToken)
+	assert.Equal(t, http.StatusCreated, code)
+	taskID := response["task"].(map[string]interface{})["id"].(string)
+
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID, "", friendToken)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/"+taskID+"/shares", `{"email": "friend@example.com", "permission": "owner"}`, ownerToken)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// A read share shows the task but does not allow changing it
+	code, response = performRequest(t, router, "POST", "/api/v1/tasks/"+taskID+"/shares", `{"email": "friend@example.com", "permission": "read"}`, ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Fay", response["share"].(map[string]interface{})["first_name"])
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID, "", friendToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "PUT", "/api/v1/tasks/"+taskID, `{"title": "Plan holiday", "priority": -1}`, friendToken)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, response = performRequest(t, router, "GET", "/api/v1/tasks/?shared_with_me=true", "", friendToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["tasks"], 1)
+	code, response = performRequest(t, router, "GET", "/api/v1/tasks/", "", friendToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["tasks"], 0)
+
+	// An edit share allows changes, but deleting stays with the owner
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/"+taskID+"/shares", `{"email": "friend@example.com", "permission": "edit"}`, ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, response = performRequest(t, router, "PUT", "/api/v1/tasks/"+taskID, `{"title": "Plan holiday", "priority": -1}`, friendToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Plan holiday", response["task"].(map[string]interface{})["title"])
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/tasks/"+taskID, "", friendToken)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID+"/shares", "", friendToken)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, response = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID+"/shares", "", ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	if shares := response["shares"].([]interface{}); assert.Len(t, shares, 1) {
+		assert.Equal(t, "edit", shares[0].(map[string]interface{})["permission"])
+	}
+
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/tasks/"+taskID+"/shares/"+friend.ID.String(), "", ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID, "", friendToken)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// Sharing a project shares all of its tasks
+	code, response = performRequest(t, router, "POST", "/api/v1/projects/", `{"name": "Home"}`, ownerToken)
+	assert.Equal(t, http.StatusCreated, code)
+	projectID := response["project"].(map[string]interface{})["id"].(string)
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Paint", "project_id": "`+projectID+`"}`, ownerToken)
+	assert.Equal(t, http.StatusCreated, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/projects/"+projectID+"/shares", `{"email": "friend@example.com", "permission": "read"}`, ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, response = performRequest(t, router, "GET", "/api/v1/tasks/?shared_with_me=true&project_id="+projectID, "", friendToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["tasks"], 1)
+}
+
+func TestCommentsAPI(t *testing.T) {
+	router, userService, _ := setupTestRouter(t)
+	owner, err := userService.CreateUser("owner@example.com", "password123", "Olive", "Owner")
+	assert.NoError(t, err)
+	_, err = userService.CreateUser("stranger@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	ownerToken, _ := login(t, router, "owner@example.com")
+	strangerToken, _ := login(t, router, "stranger@example.com")
+
+	code, response := performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Plan trip"}`, ownerToken)
+	assert.Equal(t, http.StatusCreated, code)
+	taskID := response["task"].(map[string]interface{})["id"].(string)
+
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/"+taskID+"/comments", `{"body": "Hello"}`, strangerToken)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = performRequest(t, router, "POST", "/api/v1/tasks/"+taskID+"/comments", `{"body": ""}`, ownerToken)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	for _, body := range []string{"First", "Second", "Third"} {
+		code, _ = performRequest(t, router, "POST", "/api/v1/tasks/"+taskID+"/comments", `{"body": "`+body+`"}`, ownerToken)
+		assert.Equal(t, http.StatusCreated, code)
+	}
+
+	// Pages follow each other through the cursor
+	code, response = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID+"/comments?limit=2", "", ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	comments := response["comments"].([]interface{})
+	if !assert.Len(t, comments, 2) {
+		return
+	}
+	first := comments[0].(map[string]interface{})
+	assert.Equal(t, "First", first["body"])
+	assert.Equal(t, "Olive", first["author"].(map[string]interface{})["first_name"])
+	cursor := response["pagination"].(map[string]interface{})["next_cursor"].(string)
+	code, response = performRequest(t, router, "GET", "/api/v1/tasks/"+taskID+"/comments?limit=2&cursor="+cursor, "", ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	if comments := response["comments"].([]interface{}); assert.Len(t, comments, 1) {
+		assert.Equal(t, "Third", comments[0].(map[string]interface{})["body"])
+	}
+	assert.Nil(t, response["pagination"].(map[string]interface{})["next_cursor"])
+
+	commentID := first["id"].(string)
+	code, response = performRequest(t, router, "PUT", "/api/v1/tasks/"+taskID+"/comments/"+commentID, `{"body": "First, edited"}`, ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotNil(t, response["comment"].(map[string]interface{})["edited_at"])
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/tasks/"+taskID+"/comments/"+commentID, "", strangerToken)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = performRequest(t, router, "DELETE", "/api/v1/tasks/"+taskID+"/comments/"+commentID, "", ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Comment events are part of the activity log
+	activities, err := userService.GetUserActivities(owner.ID, 20, 0)
+	assert.NoError(t, err)
+	actions := map[string]int{}
+	for _, activity := range activities {
+		if activity.Entity == "comment" {
+			actions[activity.Action]++
+		}
+	}
+	assert.Equal(t, map[string]int{"create": 3, "update": 1, "delete": 1}, actions)
+}
+
+// uploadFile sends a file as the "file" field of a multipart form and
+// decodes the JSON response
+func uploadFile(t *testing.T, router *gin.Engine, path, fileName string, content []byte, token string) (int, map[string]interface{}) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", fileName)
+	assert.NoError(t, err)
+	part.Write(content)
+	assert.NoError(t, form.Close())
+
+	req, _ := http.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
 	req.Header.Set("Authorization", "Bearer "+token)
 	resp := httptest.NewRecorder()
 	router.ServeHTTP(resp, req)
 
-	// Assert response
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+	return resp.Code, response
+}
+
+func TestAttachmentsAPI(t *testing.T) {
+	router, userService, _ := setupTestRouter(t)
+	_, err := userService.CreateUser("owner@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	_, err = userService.CreateUser("stranger@example.com", "password123", "", "")
+	assert.NoError(t, err)
+	ownerToken, _ := login(t, router, "owner@example.com")
+	strangerToken, _ := login(t, router, "stranger@example.com")
+
+	code, response := performRequest(t, router, "POST", "/api/v1/tasks/", `{"title": "Fix login page"}`, ownerToken)
+	assert.Equal(t, http.StatusCreated, code)
+	taskID := response["task"].(map[string]interface{})["id"].(string)
+	attachmentsPath := "/api/v1/tasks/" + taskID + "/attachments"
+
+	// Files above the limit or of other types are refused
+	code, _ = uploadFile(t, router, attachmentsPath, "huge.txt", bytes.Repeat([]byte("a"), 2<<20), ownerToken)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+	code, _ = uploadFile(t, router, attachmentsPath, "page.html", []byte("<html><script>alert(1)</script></html>"), ownerToken)
+	assert.Equal(t, http.StatusUnsupportedMediaType, code)
+	code, _ = performRequest(t, router, "POST", attachmentsPath, `{}`, ownerToken)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = uploadFile(t, router, attachmentsPath, "notes.txt", []byte("hello"), strangerToken)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, response = uploadFile(t, router, attachmentsPath, "notes.txt", []byte("Steps to reproduce"), ownerToken)
+	assert.Equal(t, http.StatusCreated, code)
+	attachment := response["attachment"].(map[string]interface{})
+	assert.Equal(t, "notes.txt", attachment["file_name"])
+	assert.Equal(t, "text/plain", attachment["content_type"])
+	assert.Nil(t, attachment["storage_key"])
+	attachmentID := attachment["id"].(string)
+
+	code, response = performRequest(t, router, "GET", attachmentsPath, "", ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["attachments"].([]interface{}), 1)
+
+	// Downloads are served as attachments with the stored type
+	req, _ := http.NewRequest("GET", attachmentsPath+"/"+attachmentID, nil)
+	req.Header.Set("Authorization", "Bearer "+ownerToken)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
 	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "Steps to reproduce", resp.Body.String())
+	assert.Equal(t, "text/plain", resp.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=notes.txt`, resp.Header().Get("Content-Disposition"))
+	assert.Equal(t, "nosniff", resp.Header().Get("X-Content-Type-Options"))
+
+	code, _ = performRequest(t, router, "GET", attachmentsPath+"/"+attachmentID, "", strangerToken)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = performRequest(t, router, "DELETE", attachmentsPath+"/"+attachmentID, "", ownerToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = performRequest(t, router, "GET", attachmentsPath+"/"+attachmentID, "", ownerToken)
+	assert.Equal(t, http.StatusNotFound, code)
+}
 
-	var profileResponse map[string]interface{}
-	err := json.Unmarshal(resp.Body.Bytes(), &profileResponse)
+func TestTaskSearchAPI(t *testing.T) {
+	router, userService, _ := setupTestRouter(t)
+	_, err := userService.CreateUser("search@example.com", "password123", "", "")
 	assert.NoError(t, err)
+	token, _ := login(t, router, "search@example.com")
 
-	user := profileResponse["user"].(map[string]interface{})
-	assert.Equal(t, "test@example.com", user["email"])
-	assert.Equal(t, "Test", user["first_name"])
-	assert.Equal(t, "User", user["last_name"])
+	for _, body := range []string{
+		`{"title": "Fix login page", "description": "Blank page after submit", "priority": 2}`,
+		`{"title": "Release notes", "description": "Mention the login fix", "priority": 1}`,
+		`{"title": "Water plants"}`,
+	} {
+		code, _ := performRequest(t, router, "POST", "/api/v1/tasks/", body, token)
+		assert.Equal(t, http.StatusCreated, code)
+	}
+
+	code, response := performRequest(t, router, "GET", "/api/v1/tasks/search?q=logi", "", token)
+	assert.Equal(t, http.StatusOK, code)
+	results := response["results"].([]interface{})
+	if assert.Len(t, results, 2) {
+		first := results[0].(map[string]interface{})
+		assert.Equal(t, "Fix login page", first["task"].(map[string]interface{})["title"])
+		assert.Equal(t, "Fix <mark>login</mark> page", first["highlights"].(map[string]interface{})["title"])
+		second := results[1].(map[string]interface{})
+		assert.Equal(t, "Mention the <mark>login</mark> fix", second["highlights"].(map[string]interface{})["description"])
+	}
+	assert.Equal(t, float64(2), response["pagination"].(map[string]interface{})["total"])
+
+	// Search combines with the list filters
+	code, response = performRequest(t, router, "GET", "/api/v1/tasks/search?q=login&priority=1", "", token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["results"].([]interface{}), 1)
+
+	code, _ = performRequest(t, router, "GET", "/api/v1/tasks/search?q=", "", token)
+	assert.Equal(t, http.StatusBadRequest, code)
 }
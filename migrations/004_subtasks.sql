@@ -0,0 +1,5 @@
+-- Allow tasks to hold subtasks
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES tasks(id) ON DELETE CASCADE;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks(parent_id);
@@ -0,0 +1,2 @@
+-- Allow tasks to repeat on a schedule
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurrence VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Allow tasks to send a reminder before their due date
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS reminder_minutes INTEGER;
@@ -0,0 +1,2 @@
+-- Allow users to change their email address once the new one is verified
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS roles;
+DROP INDEX IF EXISTS idx_users_role;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,5 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS assignee_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS workspace_id;
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,18 @@
+-- Create shares table: access of other users to a personal task or to every
+-- task of a personal project
+CREATE TABLE IF NOT EXISTS shares (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID REFERENCES tasks(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((task_id IS NULL) <> (project_id IS NULL))
+);
+
+-- A task or project is shared at most once with each user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_task_user ON shares(task_id, user_id) WHERE task_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_project_user ON shares(project_id, user_id) WHERE project_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_shares_user_id ON shares(user_id);
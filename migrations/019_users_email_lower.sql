@@ -0,0 +1,2 @@
+-- Users are looked up by email ignoring case
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email));
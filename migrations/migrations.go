@@ -0,0 +1,12 @@
+// Package migrations embeds the versioned SQL migration files.
+//
+// Files are named NNN_description.sql (up) and NNN_description.down.sql (down),
+// where NNN is the migration version.
+package migrations
+
+import "embed"
+
+// FS holds the SQL migration files
+//
+//go:embed *.sql
+var FS embed.FS
@@ -22,21 +22,6 @@ DB_PORT=${DB_PORT:-5432}
 DB_USER=${DB_USER:-postgres}
 DB_NAME=${DB_NAME:-todo_app}
 
-# Function to run a migration file
-run_migration() {
-  local file=$1
-  echo "Running migration: $file"
-  
-  psql -h $DB_HOST -p $DB_PORT -U $DB_USER -d $DB_NAME -f $file
-  
-  if [ $? -eq 0 ]; then
-    echo "Migration successful: $file"
-  else
-    echo "Migration failed: $file"
-    exit 1
-  fi
-}
-
 # Create database if it doesn't exist
 echo "Checking if database exists..."
 DB_EXISTS=$(psql -h $DB_HOST -p $DB_PORT -U $DB_USER -lqt | cut -d \| -f 1 | grep -w $DB_NAME | wc -l)
@@ -48,10 +33,18 @@ else
   echo "Database $DB_NAME already exists."
 fi
 
-# Run all migration files in order
+# Apply pending migrations with the migration runner, which records
+# applied versions in the schema_migrations table
 echo "Running migrations..."
-for file in migrations/*.sql; do
-  run_migration $file
-done
+if [ -x ./app ]; then
+  ./app migrate up
+else
+  go run ./cmd/api migrate up
+fi
+
+if [ $? -ne 0 ]; then
+  echo "Migrations failed."
+  exit 1
+fi
 
 echo "All migrations completed successfully."